@@ -30,6 +30,7 @@ type BuildOptions struct {
 	// NOTE: This list does not automatically include the current platform. For example, if you are building images on
 	// an Apple Silicon based MacBook, and you configure this variable to []string{"linux/amd64"} to build an amd64
 	// image, the buildx command will not automatically include linux/arm64 - you must include that explicitly.
+	// Use AssertMultiArchImage to verify the pushed image for every architecture.
 	Architectures []string
 
 	// Whether or not to push images directly to the registry on build. Note that for multiarch images (Architectures is
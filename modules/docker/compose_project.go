@@ -0,0 +1,288 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/shell"
+)
+
+const (
+	// DefaultComposeWaitTimeout is the default time to wait for the services of a compose project to be healthy.
+	DefaultComposeWaitTimeout = 2 * time.Minute
+
+	// composeWaitInterval is the time between two checks of the status of the services of a compose project.
+	composeWaitInterval = 1 * time.Second
+)
+
+// ComposeProject is a docker compose project started with StartComposeProject. It is torn down, along with its
+// volumes, when the test completes.
+type ComposeProject struct {
+	// The name of the project, from Options.ProjectName or the name of the test.
+	Name string
+
+	options  *Options
+	downOnce sync.Once
+}
+
+// ComposeService is the status of a container of a service of a compose project, as reported by
+// 'docker compose ps'.
+type ComposeService struct {
+	Service    string
+	Name       string // The name of the container
+	ID         string
+	Image      string
+	State      string // e.g. running or exited
+	Health     string // healthy, unhealthy or starting, or empty if the service has no health check
+	ExitCode   int
+	Publishers []ComposePublishedPort
+}
+
+// ComposePublishedPort is a port of a service of a compose project, and the host port it is published on, if any.
+type ComposePublishedPort struct {
+	URL           string
+	TargetPort    uint16
+	PublishedPort uint16
+	Protocol      string
+}
+
+// IsReady returns true if the container is running, and healthy if the service has a health check, or if it exited
+// successfully, as one-off services do.
+func (service ComposeService) IsReady() bool {
+	switch service.State {
+	case "running":
+		return service.Health == "" || service.Health == "healthy"
+	case "exited":
+		return service.ExitCode == 0
+	}
+	return false
+}
+
+// StartComposeProject runs 'docker compose up -d' for the given services, or all the services if none is given, in
+// options.WorkingDir, and waits until all the services are ready, as defined by ComposeService.IsReady. The project is
+// torn down with 'docker compose down -v' when the test completes. This will fail the test if there is an error.
+func StartComposeProject(t *testing.T, options *Options, services ...string) *ComposeProject {
+	project, err := StartComposeProjectE(t, options, services...)
+	require.NoError(t, err)
+	return project
+}
+
+// StartComposeProjectE runs 'docker compose up -d' for the given services, or all the services if none is given, in
+// options.WorkingDir, and waits until all the services are ready, as defined by ComposeService.IsReady. The project is
+// torn down with 'docker compose down -v' when the test completes, even if an error is returned.
+func StartComposeProjectE(t *testing.T, options *Options, services ...string) (*ComposeProject, error) {
+	projectName := options.ProjectName
+	if projectName == "" {
+		projectName = strings.ToLower(t.Name())
+	}
+	// Subtests must keep talking to the same project, whatever their names.
+	projectOptions := *options
+	projectOptions.ProjectName = generateValidDockerComposeProjectName(projectName)
+	project := &ComposeProject{Name: projectOptions.ProjectName, options: &projectOptions}
+
+	t.Cleanup(func() {
+		if err := project.DownE(t); err != nil {
+			t.Errorf("Failed to tear down compose project %s: %s", project.Name, err)
+		}
+	})
+	if _, err := RunDockerComposeE(t, project.options, append([]string{"up", "--detach"}, services...)...); err != nil {
+		return project, err
+	}
+	return project, project.WaitUntilReadyE(t, int(DefaultComposeWaitTimeout/composeWaitInterval), composeWaitInterval)
+}
+
+// Services returns the status of the containers of all the services of the project, including the ones that exited.
+// This will fail the test if there is an error.
+func (project *ComposeProject) Services(t *testing.T) []ComposeService {
+	services, err := project.ServicesE(t)
+	require.NoError(t, err)
+	return services
+}
+
+// ServicesE returns the status of the containers of all the services of the project, including the ones that exited.
+func (project *ComposeProject) ServicesE(t *testing.T) ([]ComposeService, error) {
+	out, err := runDockerComposeE(t, true, project.options, "ps", "--all", "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+	return parseComposePsOutputE(out)
+}
+
+// parseComposePsOutputE parses the output of 'docker compose ps --format json', which is a JSON array up to compose
+// v2.20, and one JSON object per line since.
+func parseComposePsOutputE(out string) ([]ComposeService, error) {
+	out = strings.TrimSpace(out)
+	services := []ComposeService{}
+	if out == "" {
+		return services, nil
+	}
+	if strings.HasPrefix(out, "[") {
+		if err := json.Unmarshal([]byte(out), &services); err != nil {
+			return nil, err
+		}
+		return services, nil
+	}
+	for _, line := range strings.Split(out, "\n") {
+		var service ComposeService
+		if err := json.Unmarshal([]byte(line), &service); err != nil {
+			return nil, err
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+// Service returns the status of the container of the given service. If the service has several replicas, the first
+// one is returned. This will fail the test if there is an error.
+func (project *ComposeProject) Service(t *testing.T, service string) ComposeService {
+	status, err := project.ServiceE(t, service)
+	require.NoError(t, err)
+	return status
+}
+
+// ServiceE returns the status of the container of the given service. If the service has several replicas, the first
+// one is returned.
+func (project *ComposeProject) ServiceE(t *testing.T, service string) (ComposeService, error) {
+	services, err := project.ServicesE(t)
+	if err != nil {
+		return ComposeService{}, err
+	}
+	for _, status := range services {
+		if status.Service == service {
+			return status, nil
+		}
+	}
+	return ComposeService{}, ComposeServiceNotFound{Project: project.Name, Service: service}
+}
+
+// ServicePort returns the host port the given port of the given service is published on. This will fail the test if
+// there is an error.
+func (project *ComposeProject) ServicePort(t *testing.T, service string, targetPort uint16) uint16 {
+	port, err := project.ServicePortE(t, service, targetPort)
+	require.NoError(t, err)
+	return port
+}
+
+// ServicePortE returns the host port the given port of the given service is published on.
+func (project *ComposeProject) ServicePortE(t *testing.T, service string, targetPort uint16) (uint16, error) {
+	status, err := project.ServiceE(t, service)
+	if err != nil {
+		return 0, err
+	}
+	for _, publisher := range status.Publishers {
+		if publisher.TargetPort == targetPort && publisher.PublishedPort != 0 {
+			return publisher.PublishedPort, nil
+		}
+	}
+	return 0, PortNotPublished{ID: status.Name, ContainerPort: targetPort}
+}
+
+// WaitUntilReady waits until all the services of the project are ready, as defined by ComposeService.IsReady,
+// retrying the check for the specified amount of times, sleeping for the provided duration between each try. This will
+// fail the test if there is an error.
+func (project *ComposeProject) WaitUntilReady(t *testing.T, retries int, sleepBetweenRetries time.Duration) {
+	require.NoError(t, project.WaitUntilReadyE(t, retries, sleepBetweenRetries))
+}
+
+// WaitUntilReadyE waits until all the services of the project are ready, as defined by ComposeService.IsReady,
+// retrying the check for the specified amount of times, sleeping for the provided duration between each try. It stops
+// waiting as soon as a service exits with a non zero code.
+func (project *ComposeProject) WaitUntilReadyE(t *testing.T, retries int, sleepBetweenRetries time.Duration) error {
+	statusMsg := fmt.Sprintf("Wait for the services of compose project %s to be ready", project.Name)
+	_, err := retry.DoWithRetryE(t, statusMsg, retries, sleepBetweenRetries, func() (string, error) {
+		services, err := project.ServicesE(t)
+		if err != nil {
+			return "", err
+		}
+		if len(services) == 0 {
+			return "", ComposeServicesNotReady{Project: project.Name}
+		}
+		notReady := []string{}
+		for _, service := range services {
+			if service.State == "exited" && service.ExitCode != 0 {
+				return "", retry.FatalError{Underlying: ComposeServiceFailed{Project: project.Name, Service: service, Logs: project.logsOrError(t, service.Service)}}
+			}
+			if !service.IsReady() {
+				notReady = append(notReady, fmt.Sprintf("%s (%s %s)", service.Name, service.State, service.Health))
+			}
+		}
+		if len(notReady) > 0 {
+			return "", ComposeServicesNotReady{Project: project.Name, Containers: notReady}
+		}
+		return "All services are ready", nil
+	})
+	return err
+}
+
+// Logs returns the logs of the given service, without the prefix of the container names. This will fail the test if
+// there is an error.
+func (project *ComposeProject) Logs(t *testing.T, service string) string {
+	logs, err := project.LogsE(t, service)
+	require.NoError(t, err)
+	return logs
+}
+
+// LogsE returns the logs of the given service, without the prefix of the container names.
+func (project *ComposeProject) LogsE(t *testing.T, service string) (string, error) {
+	return RunDockerComposeE(t, project.options, "logs", "--no-color", "--no-log-prefix", service)
+}
+
+// logsOrError returns the logs of the given service, or the error getting them, for error messages.
+func (project *ComposeProject) logsOrError(t *testing.T, service string) string {
+	logs, err := project.LogsE(t, service)
+	if err != nil {
+		return fmt.Sprintf("<could not get logs: %s>", err)
+	}
+	return logs
+}
+
+// DefaultNetwork returns the name of the network compose creates for the project when the compose file does not
+// configure the networks of the services. Pass it with --network in RunOptions.OtherOptions to run test containers
+// that can reach the services by name.
+func (project *ComposeProject) DefaultNetwork() string {
+	return project.Name + "_default"
+}
+
+// Networks returns the names of all the networks of the project. This will fail the test if there is an error.
+func (project *ComposeProject) Networks(t *testing.T) []string {
+	networks, err := project.NetworksE(t)
+	require.NoError(t, err)
+	return networks
+}
+
+// NetworksE returns the names of all the networks of the project.
+func (project *ComposeProject) NetworksE(t *testing.T) ([]string, error) {
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    []string{"network", "ls", "--filter", "label=com.docker.compose.project=" + project.Name, "--format", "{{.Name}}"},
+		Logger:  project.options.Logger,
+	}
+	out, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// Down runs 'docker compose down -v' to tear down the project, along with its volumes. This is called automatically
+// when the test completes. This will fail the test if there is an error.
+func (project *ComposeProject) Down(t *testing.T) {
+	require.NoError(t, project.DownE(t))
+}
+
+// DownE runs 'docker compose down -v' to tear down the project, along with its volumes. Tearing down a project more
+// than once is a no-op.
+func (project *ComposeProject) DownE(t *testing.T) error {
+	var err error
+	project.downOnce.Do(func() {
+		_, err = RunDockerComposeE(t, project.options, "down", "--volumes", "--remove-orphans")
+	})
+	return err
+}
@@ -0,0 +1,85 @@
+package docker
+
+import (
+	"fmt"
+	"testing"
+
+	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartComposeProject(t *testing.T) {
+	t.Parallel()
+
+	project := StartComposeProject(t, &Options{
+		WorkingDir:  "../../test/fixtures/docker-compose-project",
+		ProjectName: "compose-project-" + random.UniqueId(),
+	})
+
+	web := project.Service(t, "web")
+	assert.Equal(t, "running", web.State)
+	assert.Equal(t, "healthy", web.Health)
+	initService := project.Service(t, "init")
+	assert.Equal(t, "exited", initService.State)
+	assert.Contains(t, project.Logs(t, "init"), "initialized")
+
+	port := project.ServicePort(t, "web", 80)
+	statusCode, _ := http_helper.HttpGet(t, fmt.Sprintf("http://%s:%d", GetDockerHost(), port), nil)
+	assert.Equal(t, 200, statusCode)
+
+	assert.Equal(t, []string{project.DefaultNetwork()}, project.Networks(t))
+	out := Run(t, "busybox", &RunOptions{
+		Command:      []string{"wget", "-q", "-O", "-", "http://web"},
+		Remove:       true,
+		OtherOptions: []string{"--network", project.DefaultNetwork()},
+	})
+	assert.Contains(t, out, "Welcome to nginx")
+
+	_, err := project.ServiceE(t, "db")
+	assert.Equal(t, ComposeServiceNotFound{Project: project.Name, Service: "db"}, err)
+}
+
+func TestParseComposePsOutput(t *testing.T) {
+	t.Parallel()
+
+	expected := []ComposeService{
+		{
+			Service:    "web",
+			Name:       "project-web-1",
+			ID:         "1a2b",
+			Image:      "nginx:1.17-alpine",
+			State:      "running",
+			Health:     "healthy",
+			Publishers: []ComposePublishedPort{{URL: "0.0.0.0", TargetPort: 80, PublishedPort: 32768, Protocol: "tcp"}},
+		},
+		{
+			Service:  "init",
+			Name:     "project-init-1",
+			ID:       "3c4d",
+			Image:    "busybox",
+			State:    "exited",
+			ExitCode: 1,
+		},
+	}
+	web := `{"ID":"1a2b","Name":"project-web-1","Image":"nginx:1.17-alpine","Project":"project","Service":"web","State":"running","Health":"healthy","ExitCode":0,"Publishers":[{"URL":"0.0.0.0","TargetPort":80,"PublishedPort":32768,"Protocol":"tcp"}]}`
+	initJSON := `{"ID":"3c4d","Name":"project-init-1","Image":"busybox","Project":"project","Service":"init","State":"exited","Health":"","ExitCode":1,"Publishers":null}`
+
+	services, err := parseComposePsOutputE(web + "\n" + initJSON + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, expected, services)
+
+	services, err = parseComposePsOutputE("[" + web + "," + initJSON + "]")
+	require.NoError(t, err)
+	assert.Equal(t, expected, services)
+
+	services, err = parseComposePsOutputE("")
+	require.NoError(t, err)
+	assert.Empty(t, services)
+
+	assert.True(t, expected[0].IsReady())
+	assert.False(t, expected[1].IsReady())
+	assert.False(t, ComposeService{State: "running", Health: "starting"}.IsReady())
+	assert.True(t, ComposeService{State: "exited"}.IsReady())
+}
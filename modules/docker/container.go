@@ -0,0 +1,376 @@
+package docker
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/shell"
+)
+
+const (
+	// DefaultContainerWaitTimeout is the default time to wait for a container to be ready.
+	DefaultContainerWaitTimeout = 1 * time.Minute
+
+	// containerWaitInterval is the time between two checks of the wait strategies of a container.
+	containerWaitInterval = 500 * time.Millisecond
+)
+
+// ContainerOptions defines the options to start a container with StartContainer.
+type ContainerOptions struct {
+	// The options passed to 'docker run'. The container is always run detached.
+	RunOptions RunOptions
+
+	// Publish these container ports on the host, e.g. "80" to publish port 80 on a random host port, or "8080:80". Use
+	// Container.HostPort to look up the host port.
+	PublishPorts []string
+
+	// The strategies that must all report the container as ready before StartContainer returns.
+	WaitFor []WaitStrategy
+
+	// Time to wait for the container to be ready. 0 => DefaultContainerWaitTimeout.
+	WaitTimeout time.Duration
+
+	// If set to true, do not stream the logs of the container to the logger.
+	DisableLogStreaming bool
+
+	// If set to true, do not remove the container when the test completes, e.g. to debug it.
+	KeepContainer bool
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// Container is a container started with StartContainer. It is removed, along with its anonymous volumes, when the test
+// completes.
+type Container struct {
+	ID    string
+	Image string
+
+	logger     *logger.Logger
+	logsCmd    *exec.Cmd
+	logsDone   chan struct{}
+	removeOnce sync.Once
+}
+
+// WaitStrategy decides when a container started with StartContainer is ready.
+type WaitStrategy interface {
+	// IsReady returns nil if the container is ready, or an error describing why it is not. Return a retry.FatalError to
+	// stop waiting.
+	IsReady(t *testing.T, container *Container) error
+}
+
+// StartContainer runs the given image in the background, and waits until all the wait strategies of the options report
+// it as ready. The container is removed when the test completes. This will fail the test if the container can not be
+// started or is not ready in time.
+func StartContainer(t *testing.T, image string, options *ContainerOptions) *Container {
+	container, err := StartContainerE(t, image, options)
+	require.NoError(t, err)
+	return container
+}
+
+// StartContainerE runs the given image in the background, and waits until all the wait strategies of the options
+// report it as ready. The container is removed when the test completes, even if an error is returned.
+func StartContainerE(t *testing.T, image string, options *ContainerOptions) (*Container, error) {
+	runOptions := options.RunOptions
+	runOptions.Detach = true
+	runOptions.OtherOptions = append([]string{}, runOptions.OtherOptions...)
+	for _, port := range options.PublishPorts {
+		runOptions.OtherOptions = append(runOptions.OtherOptions, "--publish", port)
+	}
+	if runOptions.Logger == nil {
+		runOptions.Logger = options.Logger
+	}
+
+	id, err := RunAndGetIDE(t, image, &runOptions)
+	if err != nil {
+		return nil, err
+	}
+	container := &Container{ID: id, Image: image, logger: options.Logger}
+	t.Cleanup(func() {
+		if options.KeepContainer {
+			options.Logger.Logf(t, "Keeping container %s", container.ID)
+			container.stopLogStreaming()
+			return
+		}
+		if err := container.RemoveE(t); err != nil {
+			t.Errorf("Failed to remove container %s: %s", container.ID, err)
+		}
+	})
+	if !options.DisableLogStreaming {
+		if err := container.streamLogsE(t); err != nil {
+			return container, err
+		}
+	}
+
+	timeout := options.WaitTimeout
+	if timeout == 0 {
+		timeout = DefaultContainerWaitTimeout
+	}
+	retries := int(timeout / containerWaitInterval)
+	_, err = retry.DoWithRetryE(t, fmt.Sprintf("Wait for container %s to be ready", container.ID), retries, containerWaitInterval, func() (string, error) {
+		inspect, err := InspectE(t, container.ID)
+		if err != nil {
+			return "", err
+		}
+		if !inspect.Running {
+			return "", retry.FatalError{Underlying: ContainerExited{ID: container.ID, ExitCode: inspect.ExitCode, Logs: container.logsOrError(t)}}
+		}
+		for _, strategy := range options.WaitFor {
+			if err := strategy.IsReady(t, container); err != nil {
+				return "", err
+			}
+		}
+		return "", nil
+	})
+	if err != nil {
+		return container, err
+	}
+	options.Logger.Logf(t, "Container %s of image %s is ready", container.ID, image)
+	return container, nil
+}
+
+// Inspect returns the state of the container, as reported by 'docker inspect'. This will fail the test if there is an
+// error.
+func (container *Container) Inspect(t *testing.T) *ContainerInspect {
+	return Inspect(t, container.ID)
+}
+
+// HostPort returns the host port the given container port is published on. This will fail the test if the port is not
+// published.
+func (container *Container) HostPort(t *testing.T, containerPort uint16) uint16 {
+	hostPort, err := container.HostPortE(t, containerPort)
+	require.NoError(t, err)
+	return hostPort
+}
+
+// HostPortE returns the host port the given container port is published on.
+func (container *Container) HostPortE(t *testing.T, containerPort uint16) (uint16, error) {
+	inspect, err := InspectE(t, container.ID)
+	if err != nil {
+		return 0, err
+	}
+	hostPort := inspect.GetExposedHostPort(containerPort)
+	if hostPort == 0 {
+		return 0, PortNotPublished{ID: container.ID, ContainerPort: containerPort}
+	}
+	return hostPort, nil
+}
+
+// Endpoint returns the host:port address the given container port is reachable at from the host, using
+// GetDockerHost. This will fail the test if the port is not published.
+func (container *Container) Endpoint(t *testing.T, containerPort uint16) string {
+	endpoint, err := container.EndpointE(t, containerPort)
+	require.NoError(t, err)
+	return endpoint
+}
+
+// EndpointE returns the host:port address the given container port is reachable at from the host, using
+// GetDockerHost.
+func (container *Container) EndpointE(t *testing.T, containerPort uint16) (string, error) {
+	hostPort, err := container.HostPortE(t, containerPort)
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(GetDockerHost(), fmt.Sprintf("%d", hostPort)), nil
+}
+
+// Logs returns the stdout and stderr of the container so far. This will fail the test if there is an error.
+func (container *Container) Logs(t *testing.T) string {
+	logs, err := container.LogsE(t)
+	require.NoError(t, err)
+	return logs
+}
+
+// LogsE returns the stdout and stderr of the container so far.
+func (container *Container) LogsE(t *testing.T) (string, error) {
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    []string{"container", "logs", container.ID},
+		// The logs are already streamed to the logger.
+		Logger: logger.Discard,
+	}
+	return shell.RunCommandAndGetOutputE(t, cmd)
+}
+
+// logsOrError returns the logs of the container, or the error getting them, for error messages.
+func (container *Container) logsOrError(t *testing.T) string {
+	logs, err := container.LogsE(t)
+	if err != nil {
+		return fmt.Sprintf("<could not get logs: %s>", err)
+	}
+	return logs
+}
+
+// Remove force removes the container along with its anonymous volumes. This is called automatically when the test
+// completes, unless ContainerOptions.KeepContainer is set. This will fail the test if there is an error.
+func (container *Container) Remove(t *testing.T) {
+	require.NoError(t, container.RemoveE(t))
+}
+
+// RemoveE force removes the container along with its anonymous volumes. Removing a container more than once is a
+// no-op.
+func (container *Container) RemoveE(t *testing.T) error {
+	var err error
+	container.removeOnce.Do(func() {
+		cmd := shell.Command{
+			Command: "docker",
+			Args:    []string{"container", "rm", "--force", "--volumes", container.ID},
+			Logger:  container.logger,
+		}
+		_, err = shell.RunCommandAndGetOutputE(t, cmd)
+		container.stopLogStreaming()
+	})
+	return err
+}
+
+// streamLogsE follows the logs of the container in the background, writing every line to the logger.
+func (container *Container) streamLogsE(t *testing.T) error {
+	cmd := exec.Command("docker", "container", "logs", "--follow", container.ID)
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	container.logsCmd = cmd
+	container.logsDone = make(chan struct{})
+	go func() {
+		// Close the pipe once docker logs exits, so that the scanner below stops.
+		writer.CloseWithError(cmd.Wait())
+	}()
+	go func() {
+		defer close(container.logsDone)
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			container.logger.Logf(t, "[%s] %s", shortContainerID(container.ID), scanner.Text())
+		}
+	}()
+	return nil
+}
+
+// stopLogStreaming stops following the logs of the container, and waits until all the lines read so far are logged,
+// as the test must not be logged to once it completes.
+func (container *Container) stopLogStreaming() {
+	if container.logsCmd == nil {
+		return
+	}
+	// docker logs exits on its own once the container is removed, so killing it may fail: ignore that.
+	_ = container.logsCmd.Process.Kill()
+	<-container.logsDone
+}
+
+// shortContainerID returns the short form of a container ID, as shown by 'docker ps'.
+func shortContainerID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// WaitForLog returns a wait strategy that waits until the logs of the container match the given regular expression.
+func WaitForLog(pattern string) WaitStrategy {
+	return logWaitStrategy{pattern: pattern}
+}
+
+type logWaitStrategy struct {
+	pattern string
+}
+
+func (strategy logWaitStrategy) IsReady(t *testing.T, container *Container) error {
+	regex, err := regexp.Compile(strategy.pattern)
+	if err != nil {
+		return retry.FatalError{Underlying: err}
+	}
+	logs, err := container.LogsE(t)
+	if err != nil {
+		return err
+	}
+	if !regex.MatchString(logs) {
+		return ContainerNotReady{ID: container.ID, Reason: fmt.Sprintf("logs do not match %q yet", strategy.pattern)}
+	}
+	return nil
+}
+
+// WaitForPort returns a wait strategy that waits until a TCP connection can be opened to the host port the given
+// container port is published on.
+func WaitForPort(containerPort uint16) WaitStrategy {
+	return portWaitStrategy{containerPort: containerPort}
+}
+
+type portWaitStrategy struct {
+	containerPort uint16
+}
+
+func (strategy portWaitStrategy) IsReady(t *testing.T, container *Container) error {
+	endpoint, err := container.EndpointE(t, strategy.containerPort)
+	if err != nil {
+		return retry.FatalError{Underlying: err}
+	}
+	conn, err := net.DialTimeout("tcp", endpoint, containerWaitInterval)
+	if err != nil {
+		return ContainerNotReady{ID: container.ID, Reason: err.Error()}
+	}
+	return conn.Close()
+}
+
+// WaitForHTTP returns a wait strategy that waits until an HTTP GET on the given path of the host port the given
+// container port is published on returns a 200.
+func WaitForHTTP(containerPort uint16, path string) WaitStrategy {
+	return httpWaitStrategy{containerPort: containerPort, path: path}
+}
+
+type httpWaitStrategy struct {
+	containerPort uint16
+	path          string
+}
+
+func (strategy httpWaitStrategy) IsReady(t *testing.T, container *Container) error {
+	endpoint, err := container.EndpointE(t, strategy.containerPort)
+	if err != nil {
+		return retry.FatalError{Underlying: err}
+	}
+	url := fmt.Sprintf("http://%s%s", endpoint, strategy.path)
+	statusCode, _, err := http_helper.HttpGetE(t, url, nil)
+	if err != nil {
+		return ContainerNotReady{ID: container.ID, Reason: err.Error()}
+	}
+	if statusCode != 200 {
+		return ContainerNotReady{ID: container.ID, Reason: fmt.Sprintf("GET %s returned %d", url, statusCode)}
+	}
+	return nil
+}
+
+// WaitForHealthy returns a wait strategy that waits until the health check of the container reports it as healthy.
+// The image, or the run options, must define a health check.
+func WaitForHealthy() WaitStrategy {
+	return healthWaitStrategy{}
+}
+
+type healthWaitStrategy struct{}
+
+func (strategy healthWaitStrategy) IsReady(t *testing.T, container *Container) error {
+	inspect, err := InspectE(t, container.ID)
+	if err != nil {
+		return err
+	}
+	switch inspect.Health.Status {
+	case "healthy":
+		return nil
+	case "":
+		return retry.FatalError{Underlying: ContainerNotReady{ID: container.ID, Reason: "the container has no health check"}}
+	default:
+		return ContainerNotReady{ID: container.ID, Reason: fmt.Sprintf("health status is %s", inspect.Health.Status)}
+	}
+}
@@ -0,0 +1,75 @@
+package docker
+
+import (
+	"testing"
+
+	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartContainerWaitsForHTTP(t *testing.T) {
+	t.Parallel()
+
+	container := StartContainer(t, dockerInspectTestImage, &ContainerOptions{
+		PublishPorts: []string{"80"},
+		WaitFor:      []WaitStrategy{WaitForPort(80), WaitForHTTP(80, "/")},
+	})
+
+	assert.True(t, container.Inspect(t).Running)
+	assert.NotZero(t, container.HostPort(t, 80))
+
+	statusCode, body := http_helper.HttpGet(t, "http://"+container.Endpoint(t, 80), nil)
+	assert.Equal(t, 200, statusCode)
+	assert.Contains(t, body, "Welcome to nginx")
+}
+
+func TestStartContainerWaitsForLog(t *testing.T) {
+	t.Parallel()
+
+	container := StartContainer(t, "alpine:3.19", &ContainerOptions{
+		RunOptions: RunOptions{Command: []string{"sh", "-c", "sleep 1 && echo server started && sleep 60"}},
+		WaitFor:    []WaitStrategy{WaitForLog(`server \w+`)},
+	})
+
+	assert.Contains(t, container.Logs(t), "server started")
+}
+
+func TestStartContainerWaitsForHealthy(t *testing.T) {
+	t.Parallel()
+
+	StartContainer(t, "alpine:3.19", &ContainerOptions{
+		RunOptions: RunOptions{
+			Command:      []string{"sh", "-c", "sleep 2 && touch /tmp/ready && sleep 60"},
+			OtherOptions: []string{"--health-cmd", "test -f /tmp/ready", "--health-interval", "1s"},
+		},
+		WaitFor: []WaitStrategy{WaitForHealthy()},
+	})
+}
+
+func TestStartContainerFailsWithoutHealthCheck(t *testing.T) {
+	t.Parallel()
+
+	_, err := StartContainerE(t, "alpine:3.19", &ContainerOptions{
+		RunOptions: RunOptions{Command: []string{"sleep", "60"}},
+		WaitFor:    []WaitStrategy{WaitForHealthy()},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no health check")
+}
+
+func TestStartContainerFailsWhenContainerExits(t *testing.T) {
+	t.Parallel()
+
+	_, err := StartContainerE(t, "alpine:3.19", &ContainerOptions{
+		RunOptions: RunOptions{Command: []string{"sh", "-c", "echo boom && exit 3"}},
+		WaitFor:    []WaitStrategy{WaitForLog("never")},
+	})
+	require.Error(t, err)
+	require.IsType(t, retry.FatalError{}, err)
+	exited, ok := err.(retry.FatalError).Underlying.(ContainerExited)
+	require.True(t, ok)
+	assert.EqualValues(t, 3, exited.ExitCode)
+	assert.Contains(t, exited.Logs, "boom")
+}
@@ -0,0 +1,65 @@
+package docker
+
+import (
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// CopyOptions defines options that can be passed to the 'docker cp' command.
+type CopyOptions struct {
+	// If set to true, copy with the UID and GID of the source, rather than the ones of the destination.
+	Archive bool
+
+	// If set to true, copy the target of a symbolic link in the source, rather than the link itself.
+	FollowLink bool
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// CopyTo runs the 'docker cp' command to copy the given file or directory from the host to the given path in the
+// given container, which does not need to be running. This will fail the test if there is an error.
+func CopyTo(t testing.TestingT, container string, hostPath string, containerPath string, options *CopyOptions) {
+	require.NoError(t, CopyToE(t, container, hostPath, containerPath, options))
+}
+
+// CopyToE runs the 'docker cp' command to copy the given file or directory from the host to the given path in the
+// given container, which does not need to be running. As with 'cp -r', a directory is copied into containerPath if it
+// exists, or to containerPath otherwise.
+func CopyToE(t testing.TestingT, container string, hostPath string, containerPath string, options *CopyOptions) error {
+	return runDockerCopyE(t, hostPath, container+":"+containerPath, options)
+}
+
+// CopyFrom runs the 'docker cp' command to copy the given file or directory in the given container to the given path
+// on the host. This will fail the test if there is an error.
+func CopyFrom(t testing.TestingT, container string, containerPath string, hostPath string, options *CopyOptions) {
+	require.NoError(t, CopyFromE(t, container, containerPath, hostPath, options))
+}
+
+// CopyFromE runs the 'docker cp' command to copy the given file or directory in the given container to the given path
+// on the host. As with 'cp -r', a directory is copied into hostPath if it exists, or to hostPath otherwise.
+func CopyFromE(t testing.TestingT, container string, containerPath string, hostPath string, options *CopyOptions) error {
+	return runDockerCopyE(t, container+":"+containerPath, hostPath, options)
+}
+
+// runDockerCopyE runs the 'docker cp' command to copy source to destination.
+func runDockerCopyE(t testing.TestingT, source string, destination string, options *CopyOptions) error {
+	args := []string{"cp"}
+	if options.Archive {
+		args = append(args, "--archive")
+	}
+	if options.FollowLink {
+		args = append(args, "--follow-link")
+	}
+	args = append(args, source, destination)
+
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    args,
+		Logger:  options.Logger,
+	}
+	return shell.RunCommandE(t, cmd)
+}
@@ -0,0 +1,39 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyToAndFrom(t *testing.T) {
+	t.Parallel()
+
+	container := StartContainer(t, "alpine:3.19", &ContainerOptions{
+		RunOptions: RunOptions{Command: []string{"sleep", "60"}},
+	})
+
+	hostDir := t.TempDir()
+	sourceDir := filepath.Join(hostDir, "config")
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "conf.d"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "app.conf"), []byte("port=8080\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "conf.d", "extra.conf"), []byte("debug=true\n"), 0644))
+
+	CopyTo(t, container.ID, sourceDir, "/etc/app", &CopyOptions{})
+	out := Exec(t, container.ID, []string{"cat", "/etc/app/app.conf", "/etc/app/conf.d/extra.conf"}, &ExecOptions{})
+	assert.Equal(t, "port=8080\ndebug=true\n", out.Stdout)
+
+	Exec(t, container.ID, []string{"sh", "-c", "echo generated > /etc/app/generated.conf"}, &ExecOptions{})
+	CopyFrom(t, container.ID, "/etc/app/generated.conf", filepath.Join(hostDir, "generated.conf"), &CopyOptions{})
+	contents, err := os.ReadFile(filepath.Join(hostDir, "generated.conf"))
+	require.NoError(t, err)
+	assert.Equal(t, "generated\n", string(contents))
+
+	CopyFrom(t, container.ID, "/etc/app", filepath.Join(hostDir, "copied"), &CopyOptions{})
+	assert.FileExists(t, filepath.Join(hostDir, "copied", "conf.d", "extra.conf"))
+
+	assert.Error(t, CopyFromE(t, container.ID, "/does/not/exist", hostDir, &CopyOptions{}))
+}
@@ -86,7 +86,7 @@ func runDockerComposeE(t testing.TestingT, stdout bool, options *Options, args .
 	}
 
 	if stdout {
-		return shell.RunCommandAndGetStdOut(t, cmd), nil
+		return shell.RunCommandAndGetStdOutE(t, cmd)
 	}
 
 	return shell.RunCommandAndGetOutputE(t, cmd)
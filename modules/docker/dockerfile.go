@@ -0,0 +1,203 @@
+package docker
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/command"
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/moby/buildkit/frontend/dockerfile/shell"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// Dockerfile is a Dockerfile parsed with the BuildKit parser, which is the one used by 'docker build'.
+type Dockerfile struct {
+	Path string
+
+	// All the instructions of the Dockerfile, in order, including the ARG instructions before the first FROM.
+	Instructions []DockerfileInstruction
+
+	// The build stages of the Dockerfile, in order. The last one is the stage that is built by default.
+	Stages []DockerfileStage
+
+	// The warnings of the parser, e.g. for empty continuation lines.
+	Warnings []string
+}
+
+// DockerfileInstruction is a single instruction of a Dockerfile.
+type DockerfileInstruction struct {
+	Command  string   // In upper case, e.g. RUN
+	Args     []string // The arguments, without the flags. An instruction in exec form has one argument per element.
+	Flags    []string // e.g. --platform=linux/amd64 or --chown=1000
+	JSONForm bool     // Whether the arguments are in exec (JSON array) form, e.g. CMD ["cat", "file"]
+	Original string   // The original line, with continuation lines joined
+
+	// The lines of the instruction in the Dockerfile, starting from 1.
+	StartLine int
+	EndLine   int
+
+	// The index of the stage of the instruction in Dockerfile.Stages, or -1 for the ARG instructions before the first
+	// FROM.
+	Stage int
+}
+
+// DockerfileStage is a build stage of a Dockerfile, which starts with a FROM instruction.
+type DockerfileStage struct {
+	Index int
+	Name  string // The name set with FROM ... AS NAME, if any
+
+	// The base image of the stage, with the defaults of the global ARGs expanded, e.g. alpine:3.19, scratch or the name
+	// of an earlier stage. If it expands to nothing, e.g. for an ARG without a default, it is left as is.
+	BaseImage string
+
+	// The value of the --platform flag of FROM, if any, as is, since it usually refers to ARGs that are only set during
+	// the build, e.g. $BUILDPLATFORM.
+	Platform string
+
+	// The instructions of the stage, starting with its FROM instruction.
+	Instructions []DockerfileInstruction
+}
+
+// FinalStage returns the last stage of the Dockerfile, which is the one that is built by default, or nil if the
+// Dockerfile has no stages.
+func (dockerfile *Dockerfile) FinalStage() *DockerfileStage {
+	if len(dockerfile.Stages) == 0 {
+		return nil
+	}
+	return &dockerfile.Stages[len(dockerfile.Stages)-1]
+}
+
+// Stage returns the stage with the given name, or nil if there is none.
+func (dockerfile *Dockerfile) Stage(name string) *DockerfileStage {
+	for i := range dockerfile.Stages {
+		if name != "" && strings.EqualFold(dockerfile.Stages[i].Name, name) {
+			return &dockerfile.Stages[i]
+		}
+	}
+	return nil
+}
+
+// FindInstructions returns the instructions of the stage with the given command, e.g. USER, in order.
+func (stage *DockerfileStage) FindInstructions(command string) []DockerfileInstruction {
+	instructions := []DockerfileInstruction{}
+	for _, instruction := range stage.Instructions {
+		if strings.EqualFold(instruction.Command, command) {
+			instructions = append(instructions, instruction)
+		}
+	}
+	return instructions
+}
+
+// ParseDockerfile parses the Dockerfile at the given path. This will fail the test if the file can not be read or is
+// not a valid Dockerfile.
+func ParseDockerfile(t testing.TestingT, path string) *Dockerfile {
+	dockerfile, err := ParseDockerfileE(t, path)
+	require.NoError(t, err)
+	return dockerfile
+}
+
+// ParseDockerfileE parses the Dockerfile at the given path.
+func ParseDockerfileE(t testing.TestingT, path string) (*Dockerfile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseDockerfileE(path, file)
+}
+
+// parseDockerfileE parses the contents of a Dockerfile, and splits its instructions into stages.
+func parseDockerfileE(path string, reader io.Reader) (*Dockerfile, error) {
+	result, err := parser.Parse(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	dockerfile := &Dockerfile{
+		Path:         path,
+		Instructions: []DockerfileInstruction{},
+		Stages:       []DockerfileStage{},
+		Warnings:     []string{},
+	}
+	for _, warning := range result.Warnings {
+		dockerfile.Warnings = append(dockerfile.Warnings, warning.Short)
+	}
+
+	lex := shell.NewLex(result.EscapeToken)
+	globalArgs := map[string]string{}
+	for _, node := range result.AST.Children {
+		instruction := newDockerfileInstruction(node, len(dockerfile.Stages)-1)
+
+		switch {
+		case instruction.Command == strings.ToUpper(command.From):
+			stage, err := newDockerfileStage(instruction, lex, globalArgs)
+			if err != nil {
+				return nil, err
+			}
+			instruction.Stage = stage.Index
+			dockerfile.Stages = append(dockerfile.Stages, stage)
+		case instruction.Stage < 0 && instruction.Command == strings.ToUpper(command.Arg):
+			for _, arg := range instruction.Args {
+				name, value, _ := strings.Cut(arg, "=")
+				globalArgs[name] = value
+			}
+		}
+
+		dockerfile.Instructions = append(dockerfile.Instructions, instruction)
+		if instruction.Stage >= 0 {
+			stage := &dockerfile.Stages[instruction.Stage]
+			stage.Instructions = append(stage.Instructions, instruction)
+		}
+	}
+	return dockerfile, nil
+}
+
+// newDockerfileInstruction converts a node of the AST of the BuildKit parser to an instruction.
+func newDockerfileInstruction(node *parser.Node, stage int) DockerfileInstruction {
+	instruction := DockerfileInstruction{
+		Command:   strings.ToUpper(node.Value),
+		Args:      []string{},
+		Flags:     node.Flags,
+		JSONForm:  node.Attributes["json"],
+		Original:  node.Original,
+		StartLine: node.StartLine,
+		EndLine:   node.EndLine,
+		Stage:     stage,
+	}
+	if instruction.Flags == nil {
+		instruction.Flags = []string{}
+	}
+	for next := node.Next; next != nil; next = next.Next {
+		instruction.Args = append(instruction.Args, next.Value)
+	}
+	return instruction
+}
+
+// newDockerfileStage returns the stage started by the given FROM instruction, expanding the global ARGs in its base
+// image.
+func newDockerfileStage(from DockerfileInstruction, lex *shell.Lex, globalArgs map[string]string) (DockerfileStage, error) {
+	stage := DockerfileStage{Index: from.Stage + 1, Instructions: []DockerfileInstruction{}}
+	if len(from.Args) > 0 {
+		baseImage, err := lex.ProcessWordWithMap(from.Args[0], globalArgs)
+		if err != nil {
+			return stage, err
+		}
+		stage.BaseImage = baseImage
+		if baseImage == "" {
+			stage.BaseImage = from.Args[0]
+		}
+	}
+	if len(from.Args) == 3 && strings.EqualFold(from.Args[1], "AS") {
+		stage.Name = from.Args[2]
+	}
+	for _, flag := range from.Flags {
+		if platform, hasPlatform := strings.CutPrefix(flag, "--platform="); hasPlatform {
+			stage.Platform = platform
+		}
+	}
+	return stage, nil
+}
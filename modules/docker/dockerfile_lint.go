@@ -0,0 +1,242 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/collections"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// The names of the rules returned by DefaultDockerfileRules.
+const (
+	RulePinnedBaseImage  = "pinned-base-image"
+	RuleNoRemoteAdd      = "no-remote-add"
+	RuleNonRootUser      = "non-root-user"
+	RuleHealthcheckIsSet = "healthcheck-is-set"
+)
+
+// DockerfileRule is a rule evaluated by LintDockerfile. Write your own by setting Check to a function that returns a
+// finding for each violation of the rule in the Dockerfile.
+type DockerfileRule struct {
+	Name        string
+	Description string
+	Check       func(dockerfile *Dockerfile) []DockerfileFinding
+}
+
+// DockerfileFinding is a violation of a rule in a Dockerfile.
+type DockerfileFinding struct {
+	Rule    string // The name of the rule. Set by LintDockerfile if empty.
+	Line    int    // The line of the instruction in the Dockerfile, or 0 if the violation is not about an instruction
+	Message string
+}
+
+func (finding DockerfileFinding) String() string {
+	if finding.Line == 0 {
+		return fmt.Sprintf("%s: %s", finding.Rule, finding.Message)
+	}
+	return fmt.Sprintf("line %d: %s: %s", finding.Line, finding.Rule, finding.Message)
+}
+
+// DockerfileLintOptions defines options that can be passed to LintDockerfile.
+type DockerfileLintOptions struct {
+	// The rules to evaluate. Empty => DefaultDockerfileRules.
+	Rules []DockerfileRule
+
+	// The names of the rules to skip, e.g. RuleHealthcheckIsSet for an image whose health is checked by its orchestrator.
+	IgnoreRules []string
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// DockerfileLintReport is the result of LintDockerfile.
+type DockerfileLintReport struct {
+	Dockerfile *Dockerfile
+	Findings   []DockerfileFinding
+}
+
+// Passed returns true if no rule was violated.
+func (report DockerfileLintReport) Passed() bool {
+	return len(report.Findings) == 0
+}
+
+// FindingsForRule returns the findings of the rule with the given name.
+func (report DockerfileLintReport) FindingsForRule(rule string) []DockerfileFinding {
+	findings := []DockerfileFinding{}
+	for _, finding := range report.Findings {
+		if finding.Rule == rule {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// DefaultDockerfileRules returns the rules evaluated by LintDockerfile when no rules are set in the options:
+//   - RulePinnedBaseImage: the base image of every stage is pinned to a digest, e.g. alpine:3.19@sha256:...
+//   - RuleNoRemoteAdd: ADD is not used to download remote URLs, which are not checked. Use RUN curl with a checksum.
+//   - RuleNonRootUser: the final stage sets USER, to a user other than root.
+//   - RuleHealthcheckIsSet: the final stage sets HEALTHCHECK, other than HEALTHCHECK NONE.
+func DefaultDockerfileRules() []DockerfileRule {
+	return []DockerfileRule{
+		{Name: RulePinnedBaseImage, Description: "Base images are pinned to a digest", Check: checkPinnedBaseImage},
+		{Name: RuleNoRemoteAdd, Description: "ADD does not download remote URLs", Check: checkNoRemoteAdd},
+		{Name: RuleNonRootUser, Description: "The final stage runs as a user other than root", Check: checkNonRootUser},
+		{Name: RuleHealthcheckIsSet, Description: "The final stage sets HEALTHCHECK", Check: checkHealthcheckIsSet},
+	}
+}
+
+// LintDockerfile parses the Dockerfile at the given path, and evaluates the rules of the options against it. This will
+// fail the test if the Dockerfile can not be parsed, but not if rules are violated: use AssertDockerfileLint for that.
+func LintDockerfile(t testing.TestingT, path string, options *DockerfileLintOptions) *DockerfileLintReport {
+	report, err := LintDockerfileE(t, path, options)
+	require.NoError(t, err)
+	return report
+}
+
+// LintDockerfileE parses the Dockerfile at the given path, and evaluates the rules of the options against it.
+func LintDockerfileE(t testing.TestingT, path string, options *DockerfileLintOptions) (*DockerfileLintReport, error) {
+	options.Logger.Logf(t, "Linting Dockerfile %s", path)
+
+	dockerfile, err := ParseDockerfileE(t, path)
+	if err != nil {
+		return nil, err
+	}
+	report := lintDockerfile(dockerfile, options)
+	options.Logger.Logf(t, "Found %d violations in Dockerfile %s", len(report.Findings), path)
+	return report, nil
+}
+
+// AssertDockerfileLint parses the Dockerfile at the given path, and fails the test if any of the rules of the options
+// is violated.
+func AssertDockerfileLint(t testing.TestingT, path string, options *DockerfileLintOptions) *DockerfileLintReport {
+	report, err := AssertDockerfileLintE(t, path, options)
+	require.NoError(t, err)
+	return report
+}
+
+// AssertDockerfileLintE parses the Dockerfile at the given path, and returns a DockerfileLintFailed error, along with
+// the report, if any of the rules of the options is violated.
+func AssertDockerfileLintE(t testing.TestingT, path string, options *DockerfileLintOptions) (*DockerfileLintReport, error) {
+	report, err := LintDockerfileE(t, path, options)
+	if err != nil {
+		return nil, err
+	}
+	if !report.Passed() {
+		return report, DockerfileLintFailed{Path: path, Findings: report.Findings}
+	}
+	return report, nil
+}
+
+// lintDockerfile evaluates the rules of the options against the given Dockerfile.
+func lintDockerfile(dockerfile *Dockerfile, options *DockerfileLintOptions) *DockerfileLintReport {
+	rules := options.Rules
+	if len(rules) == 0 {
+		rules = DefaultDockerfileRules()
+	}
+
+	report := &DockerfileLintReport{Dockerfile: dockerfile, Findings: []DockerfileFinding{}}
+	for _, rule := range rules {
+		if collections.ListContains(options.IgnoreRules, rule.Name) {
+			continue
+		}
+		for _, finding := range rule.Check(dockerfile) {
+			if finding.Rule == "" {
+				finding.Rule = rule.Name
+			}
+			report.Findings = append(report.Findings, finding)
+		}
+	}
+	return report
+}
+
+// checkPinnedBaseImage checks that the base image of every stage is pinned to a digest. Stages built from scratch or
+// from an earlier stage are skipped.
+func checkPinnedBaseImage(dockerfile *Dockerfile) []DockerfileFinding {
+	findings := []DockerfileFinding{}
+	for _, stage := range dockerfile.Stages {
+		if strings.EqualFold(stage.BaseImage, "scratch") || isEarlierStage(dockerfile, stage) || strings.Contains(stage.BaseImage, "@sha256:") {
+			continue
+		}
+		findings = append(findings, DockerfileFinding{
+			Line:    stage.Instructions[0].StartLine,
+			Message: fmt.Sprintf("base image %s is not pinned to a digest", stage.BaseImage),
+		})
+	}
+	return findings
+}
+
+// isEarlierStage returns true if the base image of the given stage is a stage defined before it.
+func isEarlierStage(dockerfile *Dockerfile, stage DockerfileStage) bool {
+	for _, earlier := range dockerfile.Stages[:stage.Index] {
+		if earlier.Name != "" && strings.EqualFold(earlier.Name, stage.BaseImage) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNoRemoteAdd checks that no ADD instruction has a remote URL as a source.
+func checkNoRemoteAdd(dockerfile *Dockerfile) []DockerfileFinding {
+	findings := []DockerfileFinding{}
+	for _, instruction := range dockerfile.Instructions {
+		if instruction.Command != "ADD" || len(instruction.Args) < 2 {
+			continue
+		}
+		// The last argument is the destination.
+		for _, source := range instruction.Args[:len(instruction.Args)-1] {
+			if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+				findings = append(findings, DockerfileFinding{
+					Line:    instruction.StartLine,
+					Message: fmt.Sprintf("ADD downloads remote URL %s", source),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// checkNonRootUser checks that the last USER instruction of the final stage sets a user other than root. A USER set in
+// the base image is not taken into account.
+func checkNonRootUser(dockerfile *Dockerfile) []DockerfileFinding {
+	stage := dockerfile.FinalStage()
+	if stage == nil {
+		return []DockerfileFinding{}
+	}
+
+	users := stage.FindInstructions("USER")
+	if len(users) == 0 {
+		return []DockerfileFinding{{Line: stage.Instructions[0].StartLine, Message: "the final stage does not set USER"}}
+	}
+	last := users[len(users)-1]
+	if len(last.Args) == 0 {
+		return []DockerfileFinding{}
+	}
+	user, _, _ := strings.Cut(last.Args[0], ":")
+	if user == "root" || user == "0" {
+		return []DockerfileFinding{{Line: last.StartLine, Message: "the final stage runs as root"}}
+	}
+	return []DockerfileFinding{}
+}
+
+// checkHealthcheckIsSet checks that the last HEALTHCHECK instruction of the final stage is not HEALTHCHECK NONE. A
+// HEALTHCHECK set in the base image is not taken into account.
+func checkHealthcheckIsSet(dockerfile *Dockerfile) []DockerfileFinding {
+	stage := dockerfile.FinalStage()
+	if stage == nil {
+		return []DockerfileFinding{}
+	}
+
+	healthchecks := stage.FindInstructions("HEALTHCHECK")
+	if len(healthchecks) == 0 {
+		return []DockerfileFinding{{Line: stage.Instructions[0].StartLine, Message: "the final stage does not set HEALTHCHECK"}}
+	}
+	last := healthchecks[len(healthchecks)-1]
+	if len(last.Args) > 0 && strings.EqualFold(last.Args[0], "NONE") {
+		return []DockerfileFinding{{Line: last.StartLine, Message: "the final stage disables HEALTHCHECK"}}
+	}
+	return []DockerfileFinding{}
+}
@@ -0,0 +1,107 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDockerfile(t *testing.T) {
+	t.Parallel()
+
+	dockerfile := ParseDockerfile(t, "../../test/fixtures/docker-lint/Dockerfile")
+	require.Len(t, dockerfile.Stages, 2)
+	assert.Len(t, dockerfile.Instructions, 8)
+	assert.Equal(t, -1, dockerfile.Instructions[0].Stage)
+
+	build := dockerfile.Stage("build")
+	require.NotNil(t, build)
+	assert.Equal(t, 0, build.Index)
+	assert.Equal(t, "alpine:3.19@sha256:c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b", build.BaseImage)
+	assert.Equal(t, "$BUILDPLATFORM", build.Platform)
+	assert.Len(t, build.Instructions, 2)
+
+	final := dockerfile.FinalStage()
+	assert.Equal(t, 1, final.Index)
+	assert.Empty(t, final.Name)
+	assert.Equal(t, build.BaseImage, final.BaseImage)
+
+	copyInstructions := final.FindInstructions("COPY")
+	require.Len(t, copyInstructions, 1)
+	assert.Equal(t, []string{"--from=build"}, copyInstructions[0].Flags)
+	assert.Equal(t, []string{"/greeting.txt", "/greeting.txt"}, copyInstructions[0].Args)
+	assert.Equal(t, 8, copyInstructions[0].StartLine)
+
+	cmd := final.FindInstructions("cmd")
+	require.Len(t, cmd, 1)
+	assert.True(t, cmd[0].JSONForm)
+	assert.Equal(t, []string{"cat", "/greeting.txt"}, cmd[0].Args)
+}
+
+func TestParseDockerfileUnknownArg(t *testing.T) {
+	t.Parallel()
+
+	dockerfile, err := parseDockerfileE("Dockerfile", strings.NewReader("ARG BASE\nFROM ${BASE}\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "${BASE}", dockerfile.FinalStage().BaseImage)
+
+	_, err = ParseDockerfileE(t, "../../test/fixtures/docker-lint/does-not-exist")
+	assert.Error(t, err)
+}
+
+func TestLintDockerfilePasses(t *testing.T) {
+	t.Parallel()
+
+	report := AssertDockerfileLint(t, "../../test/fixtures/docker-lint/Dockerfile", &DockerfileLintOptions{})
+	assert.True(t, report.Passed())
+}
+
+func TestLintDockerfileFindings(t *testing.T) {
+	t.Parallel()
+
+	dockerfile, err := parseDockerfileE("Dockerfile", strings.NewReader(strings.Join([]string{
+		"FROM golang:1.21 AS build",
+		"ADD https://example.com/tool.tar.gz /tmp/",
+		"ADD local.tar.gz /tmp/",
+		"FROM build",
+		"USER app",
+		"USER root:root",
+		"HEALTHCHECK NONE",
+	}, "\n")))
+	require.NoError(t, err)
+
+	report := lintDockerfile(dockerfile, &DockerfileLintOptions{})
+	assert.False(t, report.Passed())
+	assert.Equal(t, []DockerfileFinding{{Rule: RulePinnedBaseImage, Line: 1, Message: "base image golang:1.21 is not pinned to a digest"}}, report.FindingsForRule(RulePinnedBaseImage))
+	assert.Equal(t, []DockerfileFinding{{Rule: RuleNoRemoteAdd, Line: 2, Message: "ADD downloads remote URL https://example.com/tool.tar.gz"}}, report.FindingsForRule(RuleNoRemoteAdd))
+	assert.Equal(t, []DockerfileFinding{{Rule: RuleNonRootUser, Line: 6, Message: "the final stage runs as root"}}, report.FindingsForRule(RuleNonRootUser))
+	assert.Equal(t, []DockerfileFinding{{Rule: RuleHealthcheckIsSet, Line: 7, Message: "the final stage disables HEALTHCHECK"}}, report.FindingsForRule(RuleHealthcheckIsSet))
+
+	report = lintDockerfile(dockerfile, &DockerfileLintOptions{IgnoreRules: []string{RulePinnedBaseImage, RuleNoRemoteAdd, RuleNonRootUser}})
+	require.Len(t, report.Findings, 1)
+	assert.Equal(t, "line 7: healthcheck-is-set: the final stage disables HEALTHCHECK", report.Findings[0].String())
+}
+
+func TestLintDockerfileCustomRule(t *testing.T) {
+	t.Parallel()
+
+	dockerfile, err := parseDockerfileE("Dockerfile", strings.NewReader("FROM scratch\nMAINTAINER someone\n"))
+	require.NoError(t, err)
+
+	noMaintainer := DockerfileRule{
+		Name: "no-maintainer",
+		Check: func(dockerfile *Dockerfile) []DockerfileFinding {
+			findings := []DockerfileFinding{}
+			for _, instruction := range dockerfile.Instructions {
+				if instruction.Command == "MAINTAINER" {
+					findings = append(findings, DockerfileFinding{Line: instruction.StartLine, Message: "MAINTAINER is deprecated"})
+				}
+			}
+			return findings
+		},
+	}
+	report := lintDockerfile(dockerfile, &DockerfileLintOptions{Rules: []DockerfileRule{noMaintainer}})
+	assert.Equal(t, []DockerfileFinding{{Rule: "no-maintainer", Line: 2, Message: "MAINTAINER is deprecated"}}, report.Findings)
+}
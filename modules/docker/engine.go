@@ -0,0 +1,360 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/files"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// podmanEngineComponent is the name of the component Podman reports in the version of its Docker compatible API.
+const podmanEngineComponent = "Podman Engine"
+
+// EngineOptions defines the options to connect to the Docker Engine API.
+type EngineOptions struct {
+	// The address of the engine, e.g. unix:///run/user/1000/podman/podman.sock or tcp://10.0.0.1:2376. If empty, the
+	// DOCKER_HOST environment variable is used, as the docker CLI does. If that is empty too, the first socket that
+	// exists among the default Docker socket and the Podman sockets is used. Note that GetDockerHost only returns the
+	// host name of DOCKER_HOST, to reach the published ports of containers, not an address of the engine.
+	Host string
+
+	// The version of the API to use, e.g. 1.41. If empty, the version is negotiated with the engine.
+	APIVersion string
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// EngineClient talks to the Docker Engine API, or to the compatible API of Podman, instead of running the docker CLI.
+// It returns the structs of the Docker SDK, which include all the data the engine reports, e.g. the networks, mounts
+// and labels of a container. Close it once done.
+//
+// EngineClient is a separate API, not a backend of the other functions of this package: Run, Inspect, Stop and
+// ListImages keep running the docker CLI, and only its methods of the same names use the engine API.
+type EngineClient struct {
+	// The address of the engine the client talks to.
+	Host string
+
+	client *client.Client
+}
+
+// NewEngineClient creates a client of the Docker Engine API. This will fail the test if there is an error.
+func NewEngineClient(t testing.TestingT, options *EngineOptions) *EngineClient {
+	engine, err := NewEngineClientE(t, options)
+	require.NoError(t, err)
+	return engine
+}
+
+// NewEngineClientE creates a client of the Docker Engine API. The TLS settings are read from the DOCKER_TLS_VERIFY and
+// DOCKER_CERT_PATH environment variables, as the docker CLI does.
+func NewEngineClientE(t testing.TestingT, options *EngineOptions) (*EngineClient, error) {
+	clientOptions := []client.Opt{client.FromEnv}
+
+	host := options.Host
+	if host == "" && os.Getenv("DOCKER_HOST") == "" {
+		host = findEngineSocket(engineSocketCandidates(os.Getenv("XDG_RUNTIME_DIR")))
+	}
+	if host != "" {
+		clientOptions = append(clientOptions, client.WithHost(host))
+	}
+	if options.APIVersion != "" {
+		clientOptions = append(clientOptions, client.WithVersion(options.APIVersion))
+	} else {
+		clientOptions = append(clientOptions, client.WithAPIVersionNegotiation())
+	}
+
+	engineClient, err := client.NewClientWithOpts(clientOptions...)
+	if err != nil {
+		return nil, err
+	}
+	options.Logger.Logf(t, "Using the engine API at %s", engineClient.DaemonHost())
+	return &EngineClient{Host: engineClient.DaemonHost(), client: engineClient}, nil
+}
+
+// engineSocketCandidates returns the sockets the engine usually listens on, in order of preference: Docker first, then
+// rootless Podman, then rootful Podman.
+func engineSocketCandidates(runtimeDir string) []string {
+	candidates := []string{"/var/run/docker.sock"}
+	if runtimeDir != "" {
+		candidates = append(candidates, filepath.Join(runtimeDir, "podman", "podman.sock"))
+	}
+	return append(candidates, "/run/podman/podman.sock")
+}
+
+// findEngineSocket returns the address of the first of the given sockets that exists, or an empty string if there is
+// none, in which case the default of the SDK is used.
+func findEngineSocket(candidates []string) string {
+	for _, candidate := range candidates {
+		if files.FileExists(candidate) {
+			return "unix://" + candidate
+		}
+	}
+	return ""
+}
+
+// Close closes the connections of the client to the engine.
+func (engine *EngineClient) Close() error {
+	return engine.client.Close()
+}
+
+// ServerVersion returns the version of the engine. This will fail the test if there is an error.
+func (engine *EngineClient) ServerVersion(t testing.TestingT) types.Version {
+	version, err := engine.ServerVersionE(t)
+	require.NoError(t, err)
+	return version
+}
+
+// ServerVersionE returns the version of the engine.
+func (engine *EngineClient) ServerVersionE(t testing.TestingT) (types.Version, error) {
+	return engine.client.ServerVersion(context.Background())
+}
+
+// IsPodman returns true if the engine is Podman. This will fail the test if there is an error.
+func (engine *EngineClient) IsPodman(t testing.TestingT) bool {
+	isPodman, err := engine.IsPodmanE(t)
+	require.NoError(t, err)
+	return isPodman
+}
+
+// IsPodmanE returns true if the engine is Podman.
+func (engine *EngineClient) IsPodmanE(t testing.TestingT) (bool, error) {
+	version, err := engine.ServerVersionE(t)
+	if err != nil {
+		return false, err
+	}
+	for _, component := range version.Components {
+		if component.Name == podmanEngineComponent {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListImages lists the images of the engine. This will fail the test if there is an error.
+func (engine *EngineClient) ListImages(t testing.TestingT) []types.ImageSummary {
+	images, err := engine.ListImagesE(t)
+	require.NoError(t, err)
+	return images
+}
+
+// ListImagesE lists the images of the engine.
+func (engine *EngineClient) ListImagesE(t testing.TestingT) ([]types.ImageSummary, error) {
+	return engine.client.ImageList(context.Background(), types.ImageListOptions{})
+}
+
+// Inspect returns the full inspect data of the given container. This will fail the test if there is an error.
+func (engine *EngineClient) Inspect(t testing.TestingT, id string) *types.ContainerJSON {
+	inspect, err := engine.InspectE(t, id)
+	require.NoError(t, err)
+	return inspect
+}
+
+// InspectE returns the full inspect data of the given container.
+func (engine *EngineClient) InspectE(t testing.TestingT, id string) (*types.ContainerJSON, error) {
+	inspect, err := engine.client.ContainerInspect(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	return &inspect, nil
+}
+
+// Run runs a container of the given image with the given options, as 'docker run' does, and returns its stdout, or
+// its ID if options.Detach is set. The image is pulled if it does not exist. This will fail the test if there is an
+// error.
+func (engine *EngineClient) Run(t testing.TestingT, image string, options *RunOptions) string {
+	out, err := engine.RunE(t, image, options)
+	require.NoError(t, err)
+	return out
+}
+
+// RunE runs a container of the given image with the given options, as 'docker run' does, and returns its stdout, or
+// its ID if options.Detach is set. The image is pulled if it does not exist. A ContainerFailed error is returned if
+// the container exits with a non zero code. options.OtherOptions are CLI flags, so they are not supported.
+func (engine *EngineClient) RunE(t testing.TestingT, image string, options *RunOptions) (string, error) {
+	id, err := engine.RunAndGetIDE(t, image, options)
+	if err != nil {
+		return "", err
+	}
+	if options.Detach {
+		return id, nil
+	}
+
+	ctx := context.Background()
+	if options.Remove {
+		// The container is only removed once its logs are read, which auto removal would prevent.
+		defer func() {
+			if err := engine.client.ContainerRemove(ctx, id, types.ContainerRemoveOptions{Force: true}); err != nil {
+				options.Logger.Logf(t, "Failed to remove container %s: %s", id, err)
+			}
+		}()
+	}
+
+	waitCh, errCh := engine.client.ContainerWait(ctx, id, container.WaitConditionNotRunning)
+	var exitCode int64
+	select {
+	case err := <-errCh:
+		return "", err
+	case result := <-waitCh:
+		exitCode = result.StatusCode
+	}
+
+	stdout, stderr, err := engine.containerLogsE(id, options.Tty)
+	if err != nil {
+		return "", err
+	}
+	if exitCode != 0 {
+		return stdout, ContainerFailed{ID: id, ExitCode: exitCode, Stderr: stderr}
+	}
+	return stdout, nil
+}
+
+// RunAndGetID runs a container of the given image with the given options in the background, and returns its ID. The
+// image is pulled if it does not exist. This will fail the test if there is an error.
+func (engine *EngineClient) RunAndGetID(t testing.TestingT, image string, options *RunOptions) string {
+	id, err := engine.RunAndGetIDE(t, image, options)
+	require.NoError(t, err)
+	return id
+}
+
+// RunAndGetIDE runs a container of the given image with the given options in the background, and returns its ID. The
+// image is pulled if it does not exist. options.OtherOptions are CLI flags, so they are not supported.
+func (engine *EngineClient) RunAndGetIDE(t testing.TestingT, image string, options *RunOptions) (string, error) {
+	config, hostConfig, err := engineContainerConfigE(image, options)
+	if err != nil {
+		return "", err
+	}
+	options.Logger.Logf(t, "Running image %s with the engine API at %s", image, engine.Host)
+
+	ctx := context.Background()
+	created, err := engine.client.ContainerCreate(ctx, config, hostConfig, nil, nil, options.Name)
+	if errdefs.IsNotFound(err) {
+		if err := engine.pullImageE(t, image, options.Logger); err != nil {
+			return "", err
+		}
+		created, err = engine.client.ContainerCreate(ctx, config, hostConfig, nil, nil, options.Name)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := engine.client.ContainerStart(ctx, created.ID, types.ContainerStartOptions{}); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// engineContainerConfigE converts the options of 'docker run' into the configuration of a container for the engine
+// API.
+func engineContainerConfigE(image string, options *RunOptions) (*container.Config, *container.HostConfig, error) {
+	if len(options.OtherOptions) > 0 {
+		return nil, nil, UnsupportedEngineOptions{Options: options.OtherOptions}
+	}
+
+	config := &container.Config{
+		Image: image,
+		Env:   options.EnvironmentVariables,
+		Tty:   options.Tty,
+		User:  options.User,
+	}
+	if len(options.Command) > 0 {
+		config.Cmd = strslice.StrSlice(options.Command)
+	}
+	if options.Entrypoint != "" {
+		config.Entrypoint = strslice.StrSlice{options.Entrypoint}
+	}
+	if !options.Detach {
+		config.AttachStdout = true
+		config.AttachStderr = true
+	}
+
+	hostConfig := &container.HostConfig{
+		Binds:      options.Volumes,
+		Privileged: options.Privileged,
+		// A foreground container is removed by RunE once its logs are read.
+		AutoRemove: options.Remove && options.Detach,
+	}
+	if options.Init {
+		enableInit := true
+		hostConfig.Init = &enableInit
+	}
+	return config, hostConfig, nil
+}
+
+// pullImageE pulls the given image, waiting until the pull completes.
+func (engine *EngineClient) pullImageE(t testing.TestingT, image string, logger *logger.Logger) error {
+	logger.Logf(t, "Pulling image %s", image)
+	progress, err := engine.client.ImagePull(context.Background(), image, types.ImagePullOptions{})
+	if err != nil {
+		return err
+	}
+	defer progress.Close()
+	// The pull is only done once its progress has been read entirely.
+	_, err = io.Copy(io.Discard, progress)
+	return err
+}
+
+// containerLogsE returns the stdout and stderr of the given container. Without a TTY, the engine multiplexes both
+// streams, while with a TTY there is only one stream, returned as stdout.
+func (engine *EngineClient) containerLogsE(id string, tty bool) (string, string, error) {
+	logs, err := engine.client.ContainerLogs(context.Background(), id, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return "", "", err
+	}
+	defer logs.Close()
+
+	var stdout, stderr bytes.Buffer
+	if tty {
+		_, err = io.Copy(&stdout, logs)
+	} else {
+		_, err = stdcopy.StdCopy(&stdout, &stderr, logs)
+	}
+	return strings.TrimSpace(stdout.String()), strings.TrimSpace(stderr.String()), err
+}
+
+// Stop stops the given containers, killing them if they do not stop within options.Time seconds. This will fail the
+// test if there is an error.
+func (engine *EngineClient) Stop(t testing.TestingT, containers []string, options *StopOptions) {
+	require.NoError(t, engine.StopE(t, containers, options))
+}
+
+// StopE stops the given containers, killing them if they do not stop within options.Time seconds (default 10).
+func (engine *EngineClient) StopE(t testing.TestingT, containers []string, options *StopOptions) error {
+	options.Logger.Logf(t, "Stopping containers '%s'", containers)
+
+	stopOptions := container.StopOptions{}
+	if options.Time != 0 {
+		stopOptions.Timeout = &options.Time
+	}
+	for _, id := range containers {
+		if err := engine.client.ContainerStop(context.Background(), id, stopOptions); err != nil {
+			return fmt.Errorf("failed to stop container %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// Remove force removes the given container, along with its anonymous volumes. This will fail the test if there is an
+// error.
+func (engine *EngineClient) Remove(t testing.TestingT, id string, logger *logger.Logger) {
+	require.NoError(t, engine.RemoveE(t, id, logger))
+}
+
+// RemoveE force removes the given container, along with its anonymous volumes.
+func (engine *EngineClient) RemoveE(t testing.TestingT, id string, logger *logger.Logger) error {
+	logger.Logf(t, "Removing container %s", id)
+	return engine.client.ContainerRemove(context.Background(), id, types.ContainerRemoveOptions{Force: true, RemoveVolumes: true})
+}
@@ -0,0 +1,99 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineClientRunAndInspect(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngineClient(t, &EngineOptions{})
+	defer engine.Close()
+
+	name := "engine-test-" + random.UniqueId()
+	id := engine.RunAndGetID(t, dockerInspectTestImage, &RunOptions{
+		Detach:               true,
+		Name:                 name,
+		EnvironmentVariables: []string{"APP_ENV=test"},
+	})
+	defer engine.Remove(t, id, nil)
+
+	inspect := engine.Inspect(t, id)
+	assert.Equal(t, "/"+name, inspect.Name)
+	assert.True(t, inspect.State.Running)
+	assert.Contains(t, inspect.Config.Env, "APP_ENV=test")
+	assert.NotEmpty(t, inspect.NetworkSettings.Networks)
+
+	engine.Stop(t, []string{id}, &StopOptions{Time: 1})
+	assert.False(t, engine.Inspect(t, id).State.Running)
+}
+
+func TestEngineClientRun(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngineClient(t, &EngineOptions{})
+	defer engine.Close()
+
+	out := engine.Run(t, "alpine:3.19", &RunOptions{Command: []string{"echo", "Hello, World!"}, Remove: true})
+	assert.Equal(t, "Hello, World!", out)
+
+	_, err := engine.RunE(t, "alpine:3.19", &RunOptions{Command: []string{"sh", "-c", "echo boom >&2 && exit 2"}, Remove: true})
+	require.Error(t, err)
+	require.IsType(t, ContainerFailed{}, err)
+	assert.EqualValues(t, 2, err.(ContainerFailed).ExitCode)
+	assert.Equal(t, "boom", err.(ContainerFailed).Stderr)
+}
+
+func TestEngineContainerConfig(t *testing.T) {
+	t.Parallel()
+
+	config, hostConfig, err := engineContainerConfigE("nginx:1.17-alpine", &RunOptions{
+		Command:              []string{"nginx", "-g", "daemon off;"},
+		Entrypoint:           "/docker-entrypoint.sh",
+		EnvironmentVariables: []string{"FOO=bar"},
+		Init:                 true,
+		User:                 "101",
+		Volumes:              []string{"/tmp:/data"},
+		Remove:               true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "nginx:1.17-alpine", config.Image)
+	assert.Equal(t, strslice.StrSlice{"nginx", "-g", "daemon off;"}, config.Cmd)
+	assert.Equal(t, strslice.StrSlice{"/docker-entrypoint.sh"}, config.Entrypoint)
+	assert.Equal(t, []string{"FOO=bar"}, config.Env)
+	assert.Equal(t, "101", config.User)
+	assert.True(t, config.AttachStdout)
+	assert.Equal(t, []string{"/tmp:/data"}, hostConfig.Binds)
+	assert.True(t, *hostConfig.Init)
+	assert.False(t, hostConfig.AutoRemove)
+
+	_, hostConfig, err = engineContainerConfigE("nginx:1.17-alpine", &RunOptions{Detach: true, Remove: true})
+	require.NoError(t, err)
+	assert.True(t, hostConfig.AutoRemove)
+	assert.Nil(t, hostConfig.Init)
+
+	_, _, err = engineContainerConfigE("nginx:1.17-alpine", &RunOptions{OtherOptions: []string{"--network", "host"}})
+	assert.Equal(t, UnsupportedEngineOptions{Options: []string{"--network", "host"}}, err)
+}
+
+func TestFindEngineSocket(t *testing.T) {
+	t.Parallel()
+
+	runtimeDir := t.TempDir()
+	candidates := engineSocketCandidates(runtimeDir)
+	assert.Equal(t, []string{"/var/run/docker.sock", filepath.Join(runtimeDir, "podman", "podman.sock"), "/run/podman/podman.sock"}, candidates)
+
+	podmanSocket := filepath.Join(runtimeDir, "podman", "podman.sock")
+	assert.Equal(t, "", findEngineSocket([]string{filepath.Join(runtimeDir, "docker.sock"), podmanSocket}))
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(podmanSocket), 0755))
+	require.NoError(t, os.WriteFile(podmanSocket, nil, 0600))
+	assert.Equal(t, "unix://"+podmanSocket, findEngineSocket([]string{filepath.Join(runtimeDir, "docker.sock"), podmanSocket}))
+}
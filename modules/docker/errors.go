@@ -0,0 +1,195 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ContainerExited is returned when a container exits while waiting for it to be ready.
+type ContainerExited struct {
+	ID       string
+	ExitCode uint8
+	Logs     string
+}
+
+func (err ContainerExited) Error() string {
+	return fmt.Sprintf("container %s exited with code %d before it was ready. Logs:\n%s", err.ID, err.ExitCode, err.Logs)
+}
+
+// ContainerNotReady is returned when a wait strategy does not report a container as ready yet.
+type ContainerNotReady struct {
+	ID     string
+	Reason string
+}
+
+func (err ContainerNotReady) Error() string {
+	return fmt.Sprintf("container %s is not ready: %s", err.ID, err.Reason)
+}
+
+// PortNotPublished is returned when a container port is not published on the host.
+type PortNotPublished struct {
+	ID            string
+	ContainerPort uint16
+}
+
+func (err PortNotPublished) Error() string {
+	return fmt.Sprintf("port %d of container %s is not published on the host", err.ContainerPort, err.ID)
+}
+
+// StructureTestsFailed is returned when checks of a structure test spec fail for an image.
+type StructureTestsFailed struct {
+	Report StructureTestReport
+}
+
+func (err StructureTestsFailed) Error() string {
+	return err.Report.String()
+}
+
+// ContainerFailed is returned when a container run in the foreground exits with a non zero code.
+type ContainerFailed struct {
+	ID       string
+	ExitCode int64
+	Stderr   string
+}
+
+func (err ContainerFailed) Error() string {
+	return fmt.Sprintf("container %s exited with code %d: %s", err.ID, err.ExitCode, err.Stderr)
+}
+
+// UnsupportedEngineOptions is returned when RunOptions.OtherOptions are passed to the engine API, as they are CLI flags.
+type UnsupportedEngineOptions struct {
+	Options []string
+}
+
+func (err UnsupportedEngineOptions) Error() string {
+	return fmt.Sprintf("the engine API does not support the docker CLI options %v: set the equivalent fields of RunOptions instead", err.Options)
+}
+
+// ComposeServiceNotFound is returned when a compose project has no container for a service.
+type ComposeServiceNotFound struct {
+	Project string
+	Service string
+}
+
+func (err ComposeServiceNotFound) Error() string {
+	return fmt.Sprintf("no container found for service %s of compose project %s", err.Service, err.Project)
+}
+
+// ComposeServicesNotReady is returned when containers of a compose project are not ready yet.
+type ComposeServicesNotReady struct {
+	Project    string
+	Containers []string
+}
+
+func (err ComposeServicesNotReady) Error() string {
+	if len(err.Containers) == 0 {
+		return fmt.Sprintf("compose project %s has no containers yet", err.Project)
+	}
+	return fmt.Sprintf("containers of compose project %s are not ready: %s", err.Project, strings.Join(err.Containers, ", "))
+}
+
+// ComposeServiceFailed is returned when a container of a compose project exits with a non zero code.
+type ComposeServiceFailed struct {
+	Project string
+	Service ComposeService
+	Logs    string
+}
+
+func (err ComposeServiceFailed) Error() string {
+	return fmt.Sprintf("container %s of compose project %s exited with code %d. Logs:\n%s", err.Service.Name, err.Project, err.Service.ExitCode, err.Logs)
+}
+
+// UnsupportedScanner is returned when a vulnerability scanner other than trivy or grype is requested.
+type UnsupportedScanner struct {
+	Scanner string
+}
+
+func (err UnsupportedScanner) Error() string {
+	return fmt.Sprintf("unsupported vulnerability scanner %q: use %s or %s", err.Scanner, ScannerTrivy, ScannerGrype)
+}
+
+// VulnerabilitiesFound is returned when vulnerabilities at or above a severity are found in an image.
+type VulnerabilitiesFound struct {
+	Image           string
+	Threshold       Severity
+	Vulnerabilities []Vulnerability
+}
+
+func (err VulnerabilitiesFound) Error() string {
+	lines := []string{fmt.Sprintf("found %d vulnerabilities with severity %s or higher in image %s:", len(err.Vulnerabilities), err.Threshold, err.Image)}
+	for _, vulnerability := range err.Vulnerabilities {
+		lines = append(lines, "  - "+vulnerability.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ImageNotMultiArch is returned when an image is a single manifest rather than a manifest list.
+type ImageNotMultiArch struct {
+	Image string
+}
+
+func (err ImageNotMultiArch) Error() string {
+	return fmt.Sprintf("image %s is not a manifest list: build it for several platforms with BuildOptions.Architectures", err.Image)
+}
+
+// PlatformNotFound is returned when a platform is missing from the manifest list of an image.
+type PlatformNotFound struct {
+	Image     string
+	Platform  string
+	Platforms []ImagePlatform
+}
+
+func (err PlatformNotFound) Error() string {
+	return fmt.Sprintf("platform %s not found in the manifest list of image %s, which has: %s", err.Platform, err.Image, strings.Join(platformNames(err.Platforms), ", "))
+}
+
+// MultiArchVerificationFailed is returned when platforms of a multi-arch image fail their verification.
+type MultiArchVerificationFailed struct {
+	Image    string
+	Failures []PlatformResult
+}
+
+func (err MultiArchVerificationFailed) Error() string {
+	lines := []string{fmt.Sprintf("%d platforms of image %s failed:", len(err.Failures), err.Image)}
+	for _, failure := range err.Failures {
+		lines = append(lines, fmt.Sprintf("  - %s: %s", failure.Platform, failure.Err))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ExecFailed is returned when a command run with Exec exits with a non zero code.
+type ExecFailed struct {
+	Container string
+	Command   []string
+	Output    ExecOutput
+}
+
+func (err ExecFailed) Error() string {
+	return fmt.Sprintf("command %v in container %s exited with code %d: %s", err.Command, err.Container, err.Output.ExitCode, strings.TrimSpace(err.Output.Stderr))
+}
+
+// DockerCLIFailed is returned when the docker CLI fails to run a command in a container, e.g. because the container is
+// not running, as opposed to the command exiting with a non zero code.
+type DockerCLIFailed struct {
+	Args     []string
+	ExitCode int
+	Stderr   string
+}
+
+func (err DockerCLIFailed) Error() string {
+	return fmt.Sprintf("docker %v failed with exit code %d: %s", err.Args, err.ExitCode, err.Stderr)
+}
+
+// DockerfileLintFailed is returned when rules are violated in a Dockerfile.
+type DockerfileLintFailed struct {
+	Path     string
+	Findings []DockerfileFinding
+}
+
+func (err DockerfileLintFailed) Error() string {
+	lines := []string{fmt.Sprintf("found %d violations in Dockerfile %s:", len(err.Findings), err.Path)}
+	for _, finding := range err.Findings {
+		lines = append(lines, "  - "+finding.String())
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,162 @@
+package docker
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// ExecOptions defines options that can be passed to the 'docker exec' command.
+type ExecOptions struct {
+	// Username or UID to run the command as. Empty => the user of the container.
+	User string
+
+	// The working directory of the command. Empty => the working directory of the container.
+	WorkingDir string
+
+	// Set environment variables, e.g. FOO=bar
+	EnvironmentVariables []string
+
+	// If set to true, pass the --privileged flag to 'docker exec' to give extended privileges to the command
+	Privileged bool
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// dockerRunFailedExitCode is the exit code of 'docker run' when the docker CLI itself fails, rather than the command run
+// in the container.
+const dockerRunFailedExitCode = 125
+
+// dockerRunErrorPrefix is the prefix of the errors printed by 'docker run' itself, e.g. "docker: Error response from
+// daemon: pull access denied", as opposed to the output of the command run in the container.
+const dockerRunErrorPrefix = "docker: "
+
+// ExecOutput is the output of a command run with Exec, as is.
+type ExecOutput struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Exec runs the 'docker exec' command to run the given command in the given running container, and returns its
+// output. This will fail the test if the command can not be run or exits with a non zero code.
+func Exec(t testing.TestingT, container string, command []string, options *ExecOptions) *ExecOutput {
+	out, err := ExecE(t, container, command, options)
+	require.NoError(t, err)
+	return out
+}
+
+// ExecE runs the 'docker exec' command to run the given command in the given running container, and returns its
+// stdout, stderr and exit code separately. An ExecFailed error is returned, along with the output, if the command exits
+// with a non zero code, and a DockerCLIFailed error if the command could not be run because the container does not
+// exist or is not running.
+func ExecE(t testing.TestingT, container string, command []string, options *ExecOptions) (*ExecOutput, error) {
+	// The exit code and the output of 'docker exec' are the ones of the command once it started, whatever it is, e.g.
+	// the docker CLI of a Docker-in-Docker image, so check that it can start first.
+	if err := checkContainerRunningE(t, container, options.Logger); err != nil {
+		return nil, err
+	}
+
+	args := formatDockerExecArgs(container, command, options)
+	out, err := runDockerAndGetOutputE(t, args, options.Logger)
+	if err != nil {
+		return nil, err
+	}
+	if out.ExitCode != 0 {
+		return out, ExecFailed{Container: container, Command: command, Output: *out}
+	}
+	return out, nil
+}
+
+// checkContainerRunningE returns a DockerCLIFailed error if the given container does not exist or is not running.
+func checkContainerRunningE(t testing.TestingT, container string, logger *logger.Logger) error {
+	args := []string{"inspect", "--type", "container", "--format", "{{.State.Running}}", container}
+	out, err := runDockerAndGetOutputE(t, args, logger)
+	if err != nil {
+		return err
+	}
+	if out.ExitCode != 0 {
+		return DockerCLIFailed{Args: args, ExitCode: out.ExitCode, Stderr: strings.TrimSpace(out.Stderr)}
+	}
+	if strings.TrimSpace(out.Stdout) != "true" {
+		return DockerCLIFailed{Args: args, Stderr: fmt.Sprintf("container %s is not running", container)}
+	}
+	return nil
+}
+
+// formatDockerExecArgs formats the arguments for the 'docker exec' command.
+func formatDockerExecArgs(container string, command []string, options *ExecOptions) []string {
+	args := []string{"exec"}
+
+	if options.User != "" {
+		args = append(args, "--user", options.User)
+	}
+
+	if options.WorkingDir != "" {
+		args = append(args, "--workdir", options.WorkingDir)
+	}
+
+	for _, envVar := range options.EnvironmentVariables {
+		args = append(args, "--env", envVar)
+	}
+
+	if options.Privileged {
+		args = append(args, "--privileged")
+	}
+
+	args = append(args, container)
+
+	return append(args, command...)
+}
+
+// runDockerAndGetOutputE runs the docker CLI with the given arguments, e.g. to run a command in a container, and returns
+// the stdout, stderr and exit code of the command separately, as is, which the shell package does not. An error is only
+// returned if the docker CLI can not be run at all: the callers tell apart the docker CLI failing from the command
+// failing.
+func runDockerAndGetOutputE(t testing.TestingT, args []string, logger *logger.Logger) (*ExecOutput, error) {
+	logger.Logf(t, "Running command docker with args %s", args)
+
+	cmd := exec.Command("docker", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	out := &ExecOutput{}
+	if err := cmd.Run(); err != nil {
+		exitErr, isExitErr := err.(*exec.ExitError)
+		if !isExitErr {
+			return nil, err
+		}
+		out.ExitCode = exitErr.ExitCode()
+	}
+
+	out.Stdout = stdout.String()
+	out.Stderr = stderr.String()
+	for _, line := range strings.Split(out.Stdout+out.Stderr, "\n") {
+		if line != "" {
+			logger.Logf(t, "%s", line)
+		}
+	}
+	return out, nil
+}
+
+// isDockerRunFailure returns true if the output of 'docker run' is the one of the docker CLI failing before the command
+// started, e.g. because the image can not be pulled, rather than the one of the command. The docker CLI then exits
+// with 125, prints nothing to stdout and prints its error prefixed with "docker: ", so that a command that exits with
+// 125 itself is not mistaken for it.
+func isDockerRunFailure(out *ExecOutput) bool {
+	if out.ExitCode != dockerRunFailedExitCode || out.Stdout != "" {
+		return false
+	}
+	for _, line := range strings.Split(out.Stderr, "\n") {
+		if strings.HasPrefix(line, dockerRunErrorPrefix) {
+			return true
+		}
+	}
+	return false
+}
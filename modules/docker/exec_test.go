@@ -0,0 +1,84 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatDockerExecArgs(t *testing.T) {
+	t.Parallel()
+
+	args := formatDockerExecArgs("my-container", []string{"sh", "-c", "id"}, &ExecOptions{
+		User:                 "nobody",
+		WorkingDir:           "/tmp",
+		EnvironmentVariables: []string{"FOO=bar", "BAZ=qux"},
+		Privileged:           true,
+	})
+	assert.Equal(t, []string{"exec", "--user", "nobody", "--workdir", "/tmp", "--env", "FOO=bar", "--env", "BAZ=qux", "--privileged", "my-container", "sh", "-c", "id"}, args)
+
+	assert.Equal(t, []string{"exec", "my-container", "ls"}, formatDockerExecArgs("my-container", []string{"ls"}, &ExecOptions{}))
+}
+
+func TestExec(t *testing.T) {
+	t.Parallel()
+
+	container := StartContainer(t, "alpine:3.19", &ContainerOptions{
+		RunOptions: RunOptions{Command: []string{"sleep", "60"}},
+	})
+
+	out := Exec(t, container.ID, []string{"sh", "-c", "echo $FOO from $(pwd) as $(whoami) && echo oops >&2"}, &ExecOptions{
+		User:                 "nobody",
+		WorkingDir:           "/tmp",
+		EnvironmentVariables: []string{"FOO=hello"},
+	})
+	assert.Equal(t, "hello from /tmp as nobody\n", out.Stdout)
+	assert.Equal(t, "oops\n", out.Stderr)
+	assert.Equal(t, 0, out.ExitCode)
+
+	out, err := ExecE(t, container.ID, []string{"sh", "-c", "echo failing >&2 && exit 3"}, &ExecOptions{})
+	require.Error(t, err)
+	assert.IsType(t, ExecFailed{}, err)
+	assert.Equal(t, 3, out.ExitCode)
+	assert.Equal(t, "failing\n", out.Stderr)
+
+	// Whatever the command prints or exits with is its own, even if it looks like an error of the docker CLI.
+	out, err = ExecE(t, container.ID, []string{"sh", "-c", "echo 'Error response from daemon: from the command' >&2 && exit 125"}, &ExecOptions{})
+	require.Error(t, err)
+	assert.IsType(t, ExecFailed{}, err)
+	assert.Equal(t, 125, out.ExitCode)
+
+	// The docker CLI failing is an error, rather than the exit code of the command.
+	_, err = ExecE(t, "terratest-no-such-container", []string{"true"}, &ExecOptions{})
+	require.Error(t, err)
+	assert.IsType(t, DockerCLIFailed{}, err)
+}
+
+func TestIsDockerRunFailure(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title    string
+		output   ExecOutput
+		expected bool
+	}{
+		{"Success", ExecOutput{Stdout: "ok\n"}, false},
+		{"CommandFailed", ExecOutput{Stderr: "cat: /missing: No such file or directory\n", ExitCode: 1}, false},
+		{"CommandNotFound", ExecOutput{Stderr: "sh: missing: not found\n", ExitCode: 127}, false},
+		{"CommandExitedWith125", ExecOutput{Stdout: "partial\n", Stderr: "docker: failed\n", ExitCode: 125}, false},
+		{"CommandPrintedDaemonError", ExecOutput{Stderr: "Error response from daemon: No such container: missing\n", ExitCode: 125}, false},
+		{"DaemonErrorFromDockerInDocker", ExecOutput{Stderr: "docker: Error response from daemon: pull access denied\n", ExitCode: 1}, false},
+		{"PullFailed", ExecOutput{Stderr: "Unable to find image 'missing:latest' locally\ndocker: Error response from daemon: pull access denied\n", ExitCode: 125}, true},
+		{"InvalidReference", ExecOutput{Stderr: "docker: invalid reference format.\nSee 'docker run --help'.\n", ExitCode: 125}, true},
+	}
+
+	for _, testCase := range testCases {
+		// capture range variable so that it doesn't update when the subtest goroutine swaps.
+		testCase := testCase
+		t.Run(testCase.title, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testCase.expected, isDockerRunFailure(&testCase.output))
+		})
+	}
+}
@@ -53,6 +53,8 @@ func TestGetDockerHostFromEnv(t *testing.T) {
 		},
 	}
 	for _, test := range tests {
+		// capture range variable so that it doesn't update when the subtest goroutine swaps.
+		test := test
 		t.Run(fmt.Sprintf("GetDockerHostFromEnv: %s", test.Input), func(t *testing.T) {
 			t.Parallel()
 
@@ -0,0 +1,46 @@
+package docker
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// ManagedLabel is the label of the networks and volumes created by CreateNetwork and CreateVolume, which SweepNetworks
+// and SweepVolumes remove.
+const ManagedLabel = "io.gruntwork.terratest.managed"
+
+// TestLabel is the label that records the name of the test that created a network or a volume, to find out which test
+// left it behind.
+const TestLabel = "io.gruntwork.terratest.test"
+
+// formatLabelArgs formats the --label arguments for the given labels, along with the labels of the resources managed by
+// terratest, in a stable order.
+func formatLabelArgs(t testing.TestingT, labels map[string]string) []string {
+	args := []string{"--label", ManagedLabel + "=true", "--label", fmt.Sprintf("%s=%s", TestLabel, t.Name())}
+	for _, key := range sortedKeys(labels) {
+		args = append(args, "--label", fmt.Sprintf("%s=%s", key, labels[key]))
+	}
+	return args
+}
+
+// formatLabelFilters formats the --filter arguments to list the resources managed by terratest, which also have all
+// the given labels.
+func formatLabelFilters(labels map[string]string) []string {
+	args := []string{"--filter", "label=" + ManagedLabel + "=true"}
+	for _, key := range sortedKeys(labels) {
+		args = append(args, "--filter", fmt.Sprintf("label=%s=%s", key, labels[key]))
+	}
+	return args
+}
+
+// sortedKeys returns the keys of the given map in order, so that the arguments built from it are stable.
+func sortedKeys(values map[string]string) []string {
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,410 @@
+package docker
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+)
+
+const (
+	// DefaultRegistryImage is the default image of the registry started by StartLocalRegistry with UseContainer set.
+	DefaultRegistryImage = "registry:2"
+
+	// registryContainerPort is the port the registry image listens on.
+	registryContainerPort = 5000
+)
+
+// LocalRegistryOptions defines options that can be passed to StartLocalRegistry.
+type LocalRegistryOptions struct {
+	// If set to true, run the registry in a container of Image rather than in the test process. The in-process
+	// registry is faster and needs no image, but the docker daemon can only reach it if it runs on the same host as the
+	// test, e.g. not with Docker Desktop.
+	UseContainer bool
+
+	// The image of the registry, when UseContainer is set. Empty => DefaultRegistryImage.
+	Image string
+
+	// If set, the registry requires basic auth with these credentials. Use LocalRegistry.Login to log the docker CLI
+	// in.
+	Username string
+	Password string
+
+	// If set to true, the registry is served over TLS with a self-signed certificate for localhost, written to
+	// LocalRegistry.CACertPath. The docker daemon accepts it without configuration, as it does not verify the
+	// certificates of registries on localhost.
+	TLS bool
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// LocalRegistry is an OCI registry started with StartLocalRegistry. It is stopped when the test completes.
+type LocalRegistry struct {
+	// The address of the registry, e.g. localhost:32768, to prefix the names of images with.
+	Address string
+
+	// The path of the PEM encoded certificate of the registry, when served over TLS.
+	CACertPath string
+
+	options *LocalRegistryOptions
+}
+
+// StartLocalRegistry starts an OCI registry, in the test process or in a container, to push and pull images without a
+// real registry. It is stopped when the test completes. This will fail the test if there is an error.
+func StartLocalRegistry(t *testing.T, options *LocalRegistryOptions) *LocalRegistry {
+	localRegistry, err := StartLocalRegistryE(t, options)
+	require.NoError(t, err)
+	return localRegistry
+}
+
+// StartLocalRegistryE starts an OCI registry, in the test process or in a container, to push and pull images without a
+// real registry. It is stopped when the test completes.
+func StartLocalRegistryE(t *testing.T, options *LocalRegistryOptions) (*LocalRegistry, error) {
+	localRegistry := &LocalRegistry{options: options}
+	configDir := t.TempDir()
+	var certificate *tls.Certificate
+	if options.TLS {
+		var err error
+		certificate, err = writeRegistryCertificateE(configDir)
+		if err != nil {
+			return nil, err
+		}
+		localRegistry.CACertPath = filepath.Join(configDir, "registry.crt")
+	}
+
+	var err error
+	if options.UseContainer {
+		localRegistry.Address, err = startRegistryContainerE(t, options, configDir)
+	} else {
+		localRegistry.Address, err = startRegistryServerE(t, options, certificate)
+	}
+	if err != nil {
+		return nil, err
+	}
+	options.Logger.Logf(t, "Started local registry at %s", localRegistry.Address)
+	return localRegistry, nil
+}
+
+// startRegistryServerE serves the registry of go-containerregistry on a random port of localhost, and returns its
+// address.
+func startRegistryServerE(t *testing.T, options *LocalRegistryOptions, certificate *tls.Certificate) (string, error) {
+	var handler http.Handler = registry.New(registry.Logger(log.New(io.Discard, "", 0)))
+	if options.Username != "" {
+		handler = basicAuthHandler(handler, options.Username, options.Password)
+	}
+
+	server := httptest.NewUnstartedServer(handler)
+	if certificate != nil {
+		server.TLS = &tls.Config{Certificates: []tls.Certificate{*certificate}}
+		server.StartTLS()
+	} else {
+		server.Start()
+	}
+	t.Cleanup(server.Close)
+
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		return "", err
+	}
+	return net.JoinHostPort("localhost", port), nil
+}
+
+// basicAuthHandler requires basic auth with the given credentials for all the requests to the given handler, the
+// same way the registry image does with htpasswd.
+func basicAuthHandler(handler http.Handler, username string, password string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestUsername, requestPassword, ok := r.BasicAuth()
+		if !ok || requestUsername != username || requestPassword != password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="Registry Realm"`)
+			http.Error(w, "authentication required", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// startRegistryContainerE runs the registry image with the auth and TLS configuration of the options, stored in
+// configDir, and returns its address.
+func startRegistryContainerE(t *testing.T, options *LocalRegistryOptions, configDir string) (string, error) {
+	image := options.Image
+	if image == "" {
+		image = DefaultRegistryImage
+	}
+
+	env := []string{}
+	if options.Username != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(options.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return "", err
+		}
+		htpasswd := fmt.Sprintf("%s:%s\n", options.Username, hash)
+		if err := os.WriteFile(filepath.Join(configDir, "htpasswd"), []byte(htpasswd), 0644); err != nil {
+			return "", err
+		}
+		env = append(env, "REGISTRY_AUTH=htpasswd", "REGISTRY_AUTH_HTPASSWD_REALM=Registry Realm", "REGISTRY_AUTH_HTPASSWD_PATH=/config/htpasswd")
+	}
+	if options.TLS {
+		env = append(env, "REGISTRY_HTTP_TLS_CERTIFICATE=/config/registry.crt", "REGISTRY_HTTP_TLS_KEY=/config/registry.key")
+	}
+
+	container, err := StartContainerE(t, image, &ContainerOptions{
+		RunOptions: RunOptions{
+			EnvironmentVariables: env,
+			Volumes:              []string{configDir + ":/config:ro"},
+		},
+		PublishPorts: []string{fmt.Sprintf("%d", registryContainerPort)},
+		WaitFor:      []WaitStrategy{WaitForLog("listening on")},
+		Logger:       options.Logger,
+	})
+	if err != nil {
+		return "", err
+	}
+	hostPort, err := container.HostPortE(t, registryContainerPort)
+	if err != nil {
+		return "", err
+	}
+	// The certificate is only valid for localhost.
+	return fmt.Sprintf("localhost:%d", hostPort), nil
+}
+
+// writeRegistryCertificateE generates a self-signed certificate for localhost, and writes it, along with its key, to
+// the given directory as registry.crt and registry.key.
+func writeRegistryCertificateE(dir string) (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	if err := os.WriteFile(filepath.Join(dir, "registry.crt"), certPEM, 0644); err != nil {
+		return nil, err
+	}
+	// The registry image does not run as the user of the test, so the key must be readable by others.
+	if err := os.WriteFile(filepath.Join(dir, "registry.key"), keyPEM, 0644); err != nil {
+		return nil, err
+	}
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// ImageName returns the name of the given repository and tag in the registry, e.g. localhost:32768/app:v1, to pass to
+// Build, Push or Run.
+func (localRegistry *LocalRegistry) ImageName(repository string, tag string) string {
+	return fmt.Sprintf("%s/%s:%s", localRegistry.Address, repository, tag)
+}
+
+// Login runs 'docker login' to log the docker CLI in to the registry, when it requires basic auth. This will fail the
+// test if there is an error.
+func (localRegistry *LocalRegistry) Login(t *testing.T) {
+	require.NoError(t, localRegistry.LoginE(t))
+}
+
+// LoginE runs 'docker login' to log the docker CLI in to the registry, when it requires basic auth. The password is
+// passed on stdin with --password-stdin, so that it does not show up in the process list or in the test output. The
+// credentials are removed with 'docker logout' when the test completes.
+func (localRegistry *LocalRegistry) LoginE(t *testing.T) error {
+	localRegistry.options.Logger.Logf(t, "Logging in to registry %s as %s", localRegistry.Address, localRegistry.options.Username)
+	args := []string{"login", localRegistry.Address, "--username", localRegistry.options.Username, "--password-stdin"}
+	localRegistry.options.Logger.Logf(t, "Running command docker with args %s", args)
+
+	// shell.Command always reads stdin from the test process, so run the command directly to pass the password.
+	cmd := exec.Command("docker", args...)
+	cmd.Stdin = strings.NewReader(localRegistry.options.Password)
+	output, err := cmd.CombinedOutput()
+	localRegistry.options.Logger.Logf(t, "%s", strings.TrimSpace(string(output)))
+	if err != nil {
+		return fmt.Errorf("docker login to %s failed: %w", localRegistry.Address, err)
+	}
+	t.Cleanup(func() {
+		logoutCmd := shell.Command{
+			Command: "docker",
+			Args:    []string{"logout", localRegistry.Address},
+			Logger:  localRegistry.options.Logger,
+		}
+		if err := shell.RunCommandE(t, logoutCmd); err != nil {
+			t.Errorf("Failed to log out of registry %s: %s", localRegistry.Address, err)
+		}
+	})
+	return nil
+}
+
+// ListRepositories returns the repositories of the registry. This will fail the test if there is an error.
+func (localRegistry *LocalRegistry) ListRepositories(t *testing.T) []string {
+	repositories, err := localRegistry.ListRepositoriesE(t)
+	require.NoError(t, err)
+	return repositories
+}
+
+// ListRepositoriesE returns the repositories of the registry.
+func (localRegistry *LocalRegistry) ListRepositoriesE(t *testing.T) ([]string, error) {
+	target, err := name.NewRegistry(localRegistry.Address, localRegistry.nameOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	remoteOptions, err := localRegistry.remoteOptionsE()
+	if err != nil {
+		return nil, err
+	}
+	return remote.Catalog(context.Background(), target, remoteOptions...)
+}
+
+// ListTags returns the tags of the given repository of the registry. This will fail the test if there is an error.
+func (localRegistry *LocalRegistry) ListTags(t *testing.T, repository string) []string {
+	tags, err := localRegistry.ListTagsE(t, repository)
+	require.NoError(t, err)
+	return tags
+}
+
+// ListTagsE returns the tags of the given repository of the registry.
+func (localRegistry *LocalRegistry) ListTagsE(t *testing.T, repository string) ([]string, error) {
+	repo, err := name.NewRepository(localRegistry.Address+"/"+repository, localRegistry.nameOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	remoteOptions, err := localRegistry.remoteOptionsE()
+	if err != nil {
+		return nil, err
+	}
+	return remote.List(repo, remoteOptions...)
+}
+
+// RegistryManifest is a manifest stored in a registry.
+type RegistryManifest struct {
+	MediaType string
+	Digest    string
+	Size      int64
+
+	// The platforms of the images of the manifest, if it is a manifest list of a multi-arch image.
+	Platforms []ImagePlatform
+
+	// The manifest, as stored in the registry.
+	Raw []byte
+}
+
+// IsManifestList returns true if the manifest is a manifest list, i.e. an OCI image index or a docker manifest list.
+func (manifest RegistryManifest) IsManifestList() bool {
+	return len(manifest.Platforms) > 0
+}
+
+// GetManifest returns the manifest of the given reference of the registry, e.g. app:v1 or app@sha256:..., without the
+// address of the registry. This will fail the test if there is an error.
+func (localRegistry *LocalRegistry) GetManifest(t *testing.T, reference string) *RegistryManifest {
+	manifest, err := localRegistry.GetManifestE(t, reference)
+	require.NoError(t, err)
+	return manifest
+}
+
+// GetManifestE returns the manifest of the given reference of the registry, e.g. app:v1 or app@sha256:..., without the
+// address of the registry.
+func (localRegistry *LocalRegistry) GetManifestE(t *testing.T, reference string) (*RegistryManifest, error) {
+	ref, err := name.ParseReference(localRegistry.Address+"/"+reference, localRegistry.nameOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	remoteOptions, err := localRegistry.remoteOptionsE()
+	if err != nil {
+		return nil, err
+	}
+	descriptor, err := remote.Get(ref, remoteOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &RegistryManifest{
+		MediaType: string(descriptor.MediaType),
+		Digest:    descriptor.Digest.String(),
+		Size:      descriptor.Size,
+		Raw:       descriptor.Manifest,
+	}
+	if descriptor.MediaType.IsIndex() {
+		manifest.Platforms, err = parseManifestListE(ref.String(), string(descriptor.Manifest))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+// nameOptions returns the options to parse the names of the registry: name.Insecure, to use plain HTTP, when TLS is
+// off, and none when TLS is on.
+func (localRegistry *LocalRegistry) nameOptions() []name.Option {
+	if localRegistry.options.TLS {
+		return nil
+	}
+	return []name.Option{name.Insecure}
+}
+
+// remoteOptionsE returns the options to call the registry, with its credentials and its certificate.
+func (localRegistry *LocalRegistry) remoteOptionsE() ([]remote.Option, error) {
+	remoteOptions := []remote.Option{}
+	if localRegistry.options.Username != "" {
+		remoteOptions = append(remoteOptions, remote.WithAuth(&authn.Basic{
+			Username: localRegistry.options.Username,
+			Password: localRegistry.options.Password,
+		}))
+	}
+	if localRegistry.CACertPath != "" {
+		certPEM, err := os.ReadFile(localRegistry.CACertPath)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(certPEM)
+		transport := remote.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+		remoteOptions = append(remoteOptions, remote.WithTransport(transport))
+	}
+	return remoteOptions, nil
+}
@@ -0,0 +1,120 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalRegistryInProcess(t *testing.T) {
+	t.Parallel()
+
+	localRegistry := StartLocalRegistry(t, &LocalRegistryOptions{})
+	assert.Empty(t, localRegistry.ListRepositories(t))
+
+	image, err := random.Image(1024, 1)
+	require.NoError(t, err)
+	writeTestImage(t, localRegistry, "team/app:v1", image)
+	writeTestImage(t, localRegistry, "team/app:v2", image)
+
+	assert.Equal(t, []string{"team/app"}, localRegistry.ListRepositories(t))
+	assert.ElementsMatch(t, []string{"v1", "v2"}, localRegistry.ListTags(t, "team/app"))
+
+	digest, err := image.Digest()
+	require.NoError(t, err)
+	manifest := localRegistry.GetManifest(t, "team/app:v1")
+	assert.Equal(t, digest.String(), manifest.Digest)
+	assert.False(t, manifest.IsManifestList())
+	assert.Equal(t, manifest.Digest, localRegistry.GetManifest(t, "team/app@"+digest.String()).Digest)
+
+	_, err = localRegistry.GetManifestE(t, "team/app:v3")
+	assert.Error(t, err)
+}
+
+func TestLocalRegistryInProcessWithAuthAndTLS(t *testing.T) {
+	t.Parallel()
+
+	localRegistry := StartLocalRegistry(t, &LocalRegistryOptions{Username: "terratest", Password: "secret", TLS: true})
+	require.FileExists(t, localRegistry.CACertPath)
+
+	amd64, err := random.Image(1024, 1)
+	require.NoError(t, err)
+	arm64, err := random.Image(1024, 1)
+	require.NoError(t, err)
+	index := mutate.AppendManifests(empty.Index,
+		mutate.IndexAddendum{Add: amd64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}}},
+		mutate.IndexAddendum{Add: arm64, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64"}}},
+	)
+	ref, err := name.ParseReference(localRegistry.ImageName("app", "v1"))
+	require.NoError(t, err)
+	remoteOptions, err := localRegistry.remoteOptionsE()
+	require.NoError(t, err)
+	require.NoError(t, remote.WriteIndex(ref, index, remoteOptions...))
+
+	manifest := localRegistry.GetManifest(t, "app:v1")
+	assert.True(t, manifest.IsManifestList())
+	require.Len(t, manifest.Platforms, 2)
+	assert.Equal(t, "linux/amd64", manifest.Platforms[0].String())
+	assert.Equal(t, "linux/arm64", manifest.Platforms[1].String())
+
+	// Without credentials, the registry denies access.
+	anonymousRegistry := *localRegistry
+	anonymousOptions := *localRegistry.options
+	anonymousOptions.Username = ""
+	anonymousRegistry.options = &anonymousOptions
+	_, err = anonymousRegistry.ListTagsE(t, "app")
+	assert.Error(t, err)
+}
+
+func TestLocalRegistryContainer(t *testing.T) {
+	t.Parallel()
+
+	localRegistry := StartLocalRegistry(t, &LocalRegistryOptions{UseContainer: true, Username: "terratest", Password: "secret"})
+	localRegistry.Login(t)
+
+	tag := localRegistry.ImageName("gruntwork-io/test-image", "v1")
+	Build(t, "../../test/fixtures/docker", &BuildOptions{Tags: []string{tag}, BuildArgs: []string{"text=Hello, World!"}})
+	defer DeleteImage(t, tag, nil)
+	Push(t, nil, tag)
+
+	assert.Equal(t, []string{"gruntwork-io/test-image"}, localRegistry.ListRepositories(t))
+	assert.Equal(t, []string{"v1"}, localRegistry.ListTags(t, "gruntwork-io/test-image"))
+}
+
+func TestLocalRegistryLoginPassesPasswordOnStdin(t *testing.T) {
+	// Not parallel, since PATH is changed to use a fake docker that records its args and stdin.
+	binDir := t.TempDir()
+	callsPath := filepath.Join(binDir, "calls")
+	stdinPath := filepath.Join(binDir, "stdin")
+	fakeDocker := "#!/bin/sh\necho \"$@\" >> " + callsPath + "\nif [ \"$1\" = login ]; then cat > " + stdinPath + "; fi\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "docker"), []byte(fakeDocker), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	localRegistry := StartLocalRegistry(t, &LocalRegistryOptions{Username: "terratest", Password: "s3cr3t"})
+	localRegistry.Login(t)
+
+	calls, err := os.ReadFile(callsPath)
+	require.NoError(t, err)
+	assert.Equal(t, "login "+localRegistry.Address+" --username terratest --password-stdin", strings.TrimSpace(string(calls)))
+	stdin, err := os.ReadFile(stdinPath)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", string(stdin))
+}
+
+// writeTestImage pushes the given image to the registry without the docker CLI.
+func writeTestImage(t *testing.T, localRegistry *LocalRegistry, reference string, image v1.Image) {
+	ref, err := name.ParseReference(localRegistry.Address+"/"+reference, name.Insecure)
+	require.NoError(t, err)
+	require.NoError(t, remote.Write(ref, image, remote.WithAuth(authn.Anonymous)))
+}
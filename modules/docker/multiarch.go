@@ -0,0 +1,261 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/collections"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// ImagePlatform is an image of a manifest list, for a single platform.
+type ImagePlatform struct {
+	OS           string
+	Architecture string
+	Variant      string // e.g. v7 for linux/arm/v7
+	Digest       string // The digest of the manifest of the image for this platform
+}
+
+// String returns the platform in the format of --platform, e.g. linux/amd64 or linux/arm/v7.
+func (platform ImagePlatform) String() string {
+	if platform.Variant != "" {
+		return fmt.Sprintf("%s/%s/%s", platform.OS, platform.Architecture, platform.Variant)
+	}
+	return fmt.Sprintf("%s/%s", platform.OS, platform.Architecture)
+}
+
+// MultiArchOptions defines options that can be passed to VerifyMultiArchImage.
+type MultiArchOptions struct {
+	// The platforms the manifest list must contain, in the format of BuildOptions.Architectures, e.g. linux/amd64.
+	Platforms []string
+
+	// A smoke command to run in a container of the image of every expected platform, e.g. []string{"--version"}.
+	// Platforms other than the one of the docker host are emulated with QEMU, which must be registered with binfmt_misc,
+	// e.g. with 'docker run --privileged --rm tonistiigi/binfmt --install all'. Empty => no command is run.
+	Command []string
+
+	// Override the default ENTRYPOINT of the image for the smoke command.
+	Entrypoint string
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// PlatformResult is the result of the verification of a single platform of a multi-arch image.
+type PlatformResult struct {
+	Platform string
+	Digest   string // Empty if the platform is missing from the manifest list
+	Output   string // The output of the smoke command
+	Err      error  // Why the verification failed, or nil
+}
+
+// Passed returns true if the platform exists, and its smoke command succeeded.
+func (result PlatformResult) Passed() bool {
+	return result.Err == nil
+}
+
+// MultiArchReport is the result of the verification of a multi-arch image.
+type MultiArchReport struct {
+	Image string
+
+	// All the platforms of the manifest list.
+	Platforms []ImagePlatform
+
+	// The results of the expected platforms, in the order of MultiArchOptions.Platforms.
+	Results []PlatformResult
+}
+
+// Passed returns true if all the expected platforms passed.
+func (report MultiArchReport) Passed() bool {
+	return len(report.Failures()) == 0
+}
+
+// Failures returns the results of the expected platforms that failed.
+func (report MultiArchReport) Failures() []PlatformResult {
+	failures := []PlatformResult{}
+	for _, result := range report.Results {
+		if !result.Passed() {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// GetImagePlatforms returns the platforms of the manifest list of the given image, which is read from its registry,
+// e.g. after Build with BuildOptions.Push set. Attestation manifests are left out. This will fail the test if there is
+// an error.
+func GetImagePlatforms(t testing.TestingT, image string, logger *logger.Logger) []ImagePlatform {
+	platforms, err := GetImagePlatformsE(t, image, logger)
+	require.NoError(t, err)
+	return platforms
+}
+
+// GetImagePlatformsE returns the platforms of the manifest list of the given image, which is read from its registry,
+// e.g. after Build with BuildOptions.Push set. Attestation manifests are left out. An ImageNotMultiArch error is
+// returned if the image is not a manifest list.
+func GetImagePlatformsE(t testing.TestingT, image string, logger *logger.Logger) ([]ImagePlatform, error) {
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    []string{"buildx", "imagetools", "inspect", "--raw", image},
+		Logger:  logger,
+	}
+	out, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return parseManifestListE(image, out)
+}
+
+// manifestList is the part of an OCI image index, or of a docker manifest list, used by GetImagePlatforms.
+type manifestList struct {
+	Manifests []struct {
+		Digest   string
+		Platform struct {
+			OS           string `json:"os"`
+			Architecture string `json:"architecture"`
+			Variant      string `json:"variant"`
+		}
+		Annotations map[string]string
+	}
+}
+
+// parseManifestListE returns the platforms of a raw manifest list.
+func parseManifestListE(image string, raw string) ([]ImagePlatform, error) {
+	var list manifestList
+	if err := json.Unmarshal([]byte(raw), &list); err != nil {
+		return nil, err
+	}
+	if len(list.Manifests) == 0 {
+		return nil, ImageNotMultiArch{Image: image}
+	}
+
+	platforms := []ImagePlatform{}
+	for _, manifest := range list.Manifests {
+		// buildx stores provenance and SBOM attestations as manifests of an unknown/unknown platform.
+		if manifest.Annotations["vnd.docker.reference.type"] == "attestation-manifest" || manifest.Platform.OS == "unknown" {
+			continue
+		}
+		platforms = append(platforms, ImagePlatform{
+			OS:           manifest.Platform.OS,
+			Architecture: manifest.Platform.Architecture,
+			Variant:      manifest.Platform.Variant,
+			Digest:       manifest.Digest,
+		})
+	}
+	return platforms, nil
+}
+
+// VerifyMultiArchImage checks that the manifest list of the given image contains all the expected platforms, and runs
+// the smoke command on each of them, reporting the result of every platform. This will fail the test if the image can
+// not be inspected, but not if the verification of a platform fails: use AssertMultiArchImage for that.
+func VerifyMultiArchImage(t testing.TestingT, image string, options *MultiArchOptions) *MultiArchReport {
+	report, err := VerifyMultiArchImageE(t, image, options)
+	require.NoError(t, err)
+	return report
+}
+
+// VerifyMultiArchImageE checks that the manifest list of the given image contains all the expected platforms, and runs
+// the smoke command on each of them, reporting the result of every platform. The command runs in the image of the
+// platform, pinned by digest, so that the right image is tested whatever is cached in the docker daemon. An error is
+// only returned if the image can not be inspected.
+func VerifyMultiArchImageE(t testing.TestingT, image string, options *MultiArchOptions) (*MultiArchReport, error) {
+	platforms, err := GetImagePlatformsE(t, image, options.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MultiArchReport{Image: image, Platforms: platforms}
+	for _, expected := range options.Platforms {
+		result := PlatformResult{Platform: expected}
+		platform, found := findImagePlatform(platforms, expected)
+		switch {
+		case !found:
+			result.Err = PlatformNotFound{Image: image, Platform: expected, Platforms: platforms}
+		case len(options.Command) > 0:
+			result.Digest = platform.Digest
+			runOptions := &RunOptions{
+				Command:      options.Command,
+				Entrypoint:   options.Entrypoint,
+				Remove:       true,
+				OtherOptions: []string{"--platform", platform.String()},
+				Logger:       options.Logger,
+			}
+			result.Output, result.Err = RunE(t, imageRepository(image)+"@"+platform.Digest, runOptions)
+		default:
+			result.Digest = platform.Digest
+		}
+		status := "PASS"
+		if !result.Passed() {
+			status = "FAIL"
+		}
+		options.Logger.Logf(t, "%s platform %s of image %s", status, expected, image)
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+// AssertMultiArchImage checks that the manifest list of the given image contains all the expected platforms, and runs
+// the smoke command on each of them. This will fail the test if any platform fails.
+func AssertMultiArchImage(t testing.TestingT, image string, options *MultiArchOptions) *MultiArchReport {
+	report, err := AssertMultiArchImageE(t, image, options)
+	require.NoError(t, err)
+	return report
+}
+
+// AssertMultiArchImageE checks that the manifest list of the given image contains all the expected platforms, and runs
+// the smoke command on each of them. A MultiArchVerificationFailed error is returned, along with the report, if any
+// platform fails.
+func AssertMultiArchImageE(t testing.TestingT, image string, options *MultiArchOptions) (*MultiArchReport, error) {
+	report, err := VerifyMultiArchImageE(t, image, options)
+	if err != nil {
+		return nil, err
+	}
+	if !report.Passed() {
+		return report, MultiArchVerificationFailed{Image: image, Failures: report.Failures()}
+	}
+	return report, nil
+}
+
+// findImagePlatform returns the platform of the list matching the given one, e.g. linux/arm64. As for --platform, the
+// variant can be left out to match any variant.
+func findImagePlatform(platforms []ImagePlatform, expected string) (ImagePlatform, bool) {
+	for _, platform := range platforms {
+		if platform.String() == expected {
+			return platform, true
+		}
+	}
+	for _, platform := range platforms {
+		if fmt.Sprintf("%s/%s", platform.OS, platform.Architecture) == expected {
+			return platform, true
+		}
+	}
+	return ImagePlatform{}, false
+}
+
+// imageRepository returns the repository of the given image reference, without its tag or digest, e.g.
+// localhost:5000/app for localhost:5000/app:v1.
+func imageRepository(image string) string {
+	if index := strings.Index(image, "@"); index >= 0 {
+		image = image[:index]
+	}
+	// A colon after the last slash separates the tag, while one before it separates the port of the registry.
+	if index := strings.LastIndex(image, ":"); index > strings.LastIndex(image, "/") {
+		image = image[:index]
+	}
+	return image
+}
+
+// platformNames returns the names of the given platforms, e.g. for error messages.
+func platformNames(platforms []ImagePlatform) []string {
+	names := []string{}
+	for _, platform := range platforms {
+		if !collections.ListContains(names, platform.String()) {
+			names = append(names, platform.String())
+		}
+	}
+	return names
+}
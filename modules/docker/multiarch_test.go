@@ -0,0 +1,62 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ociIndexFixture = `{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.index.v1+json",
+  "manifests": [
+    {"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:aaa", "size": 1, "platform": {"architecture": "amd64", "os": "linux"}},
+    {"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:bbb", "size": 1, "platform": {"architecture": "arm", "os": "linux", "variant": "v7"}},
+    {"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:ccc", "size": 1, "platform": {"architecture": "unknown", "os": "unknown"}, "annotations": {"vnd.docker.reference.digest": "sha256:aaa", "vnd.docker.reference.type": "attestation-manifest"}}
+  ]
+}`
+
+func TestParseManifestList(t *testing.T) {
+	t.Parallel()
+
+	platforms, err := parseManifestListE("app:1", ociIndexFixture)
+	require.NoError(t, err)
+	assert.Equal(t, []ImagePlatform{
+		{OS: "linux", Architecture: "amd64", Digest: "sha256:aaa"},
+		{OS: "linux", Architecture: "arm", Variant: "v7", Digest: "sha256:bbb"},
+	}, platforms)
+	assert.Equal(t, "linux/arm/v7", platforms[1].String())
+
+	_, err = parseManifestListE("app:1", `{"schemaVersion": 2, "mediaType": "application/vnd.oci.image.manifest.v1+json", "layers": []}`)
+	assert.Equal(t, ImageNotMultiArch{Image: "app:1"}, err)
+}
+
+func TestFindImagePlatform(t *testing.T) {
+	t.Parallel()
+
+	platforms, err := parseManifestListE("app:1", ociIndexFixture)
+	require.NoError(t, err)
+
+	platform, found := findImagePlatform(platforms, "linux/amd64")
+	assert.True(t, found)
+	assert.Equal(t, "sha256:aaa", platform.Digest)
+	platform, found = findImagePlatform(platforms, "linux/arm")
+	assert.True(t, found)
+	assert.Equal(t, "sha256:bbb", platform.Digest)
+	_, found = findImagePlatform(platforms, "linux/arm64")
+	assert.False(t, found)
+
+	err = PlatformNotFound{Image: "app:1", Platform: "linux/arm64", Platforms: platforms}
+	assert.Equal(t, "platform linux/arm64 not found in the manifest list of image app:1, which has: linux/amd64, linux/arm/v7", err.Error())
+}
+
+func TestImageRepository(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "alpine", imageRepository("alpine"))
+	assert.Equal(t, "alpine", imageRepository("alpine:3.19"))
+	assert.Equal(t, "localhost:5000/team/app", imageRepository("localhost:5000/team/app"))
+	assert.Equal(t, "localhost:5000/team/app", imageRepository("localhost:5000/team/app:v1"))
+	assert.Equal(t, "localhost:5000/app", imageRepository("localhost:5000/app:v1@sha256:aaa"))
+}
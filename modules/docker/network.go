@@ -0,0 +1,296 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	gotesting "testing"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// NetworkOptions defines options that can be passed to the 'docker network create' command.
+type NetworkOptions struct {
+	// The driver of the network. Empty => bridge.
+	Driver string
+
+	// If set to true, the network has no access to the outside world.
+	Internal bool
+
+	// The subnet of the network in CIDR format, e.g. 172.28.0.0/16. Empty => chosen by docker.
+	Subnet string
+
+	// Labels to set on the network, along with ManagedLabel and TestLabel.
+	Labels map[string]string
+
+	// Custom CLI options that will be passed as-is to the 'docker network create' command.
+	OtherOptions []string
+
+	// If set to true, do not remove the network when the test completes.
+	KeepNetwork bool
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// ConnectOptions defines options that can be passed to the 'docker network connect' command.
+type ConnectOptions struct {
+	// Names the container can be reached at on the network, in addition to its name.
+	Aliases []string
+
+	// The IPv4 address of the container on the network. Empty => chosen by docker.
+	IP string
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// NetworkInspect defines the output of InspectNetwork, with the fields returned by 'docker network inspect'.
+type NetworkInspect struct {
+	ID       string
+	Name     string
+	Created  time.Time
+	Driver   string
+	Scope    string
+	Internal bool
+	Labels   map[string]string
+
+	// The subnets of the network, in CIDR format, with their gateway.
+	Subnets []NetworkSubnet
+
+	// The containers connected to the network, by container ID.
+	Containers map[string]NetworkEndpoint
+}
+
+// NetworkSubnet is a subnet of a network.
+type NetworkSubnet struct {
+	Subnet  string
+	Gateway string
+}
+
+// NetworkEndpoint is a container connected to a network.
+type NetworkEndpoint struct {
+	Name        string
+	MacAddress  string
+	IPv4Address string // In CIDR format, e.g. 172.28.0.2/16
+	IPv6Address string
+}
+
+// networkInspectOutput is the output of 'docker network inspect' for a single network.
+type networkInspectOutput struct {
+	ID       string `json:"Id"`
+	Name     string
+	Created  time.Time
+	Driver   string
+	Scope    string
+	Internal bool
+	Labels   map[string]string
+	IPAM     struct {
+		Config []NetworkSubnet
+	}
+	Containers map[string]NetworkEndpoint
+}
+
+// CreateNetwork runs the 'docker network create' command to create a user-defined network with the given name, and
+// returns its ID. The network is removed when the test completes, unless options.KeepNetwork is set. Attach containers
+// to it with --network in RunOptions.OtherOptions, or with ConnectContainer. This will fail the test if there is an
+// error.
+func CreateNetwork(t *gotesting.T, name string, options *NetworkOptions) string {
+	id, err := CreateNetworkE(t, name, options)
+	require.NoError(t, err)
+	return id
+}
+
+// CreateNetworkE runs the 'docker network create' command to create a user-defined network with the given name, and
+// returns its ID. The network is removed when the test completes, unless options.KeepNetwork is set. It is labeled
+// with ManagedLabel, so that SweepNetworks can remove it if the test is interrupted before it completes.
+func CreateNetworkE(t *gotesting.T, name string, options *NetworkOptions) (string, error) {
+	options.Logger.Logf(t, "Creating network %s", name)
+
+	args := []string{"network", "create"}
+	if options.Driver != "" {
+		args = append(args, "--driver", options.Driver)
+	}
+	if options.Internal {
+		args = append(args, "--internal")
+	}
+	if options.Subnet != "" {
+		args = append(args, "--subnet", options.Subnet)
+	}
+	args = append(args, formatLabelArgs(t, options.Labels)...)
+	args = append(args, options.OtherOptions...)
+	args = append(args, name)
+
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    args,
+		Logger:  options.Logger,
+	}
+	out, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	if err != nil {
+		return "", err
+	}
+	if !options.KeepNetwork {
+		t.Cleanup(func() {
+			if err := RemoveNetworkE(t, name, options.Logger); err != nil {
+				t.Errorf("Failed to remove network %s: %s", name, err)
+			}
+		})
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// RemoveNetwork runs the 'docker network rm' command to remove the given network. This will fail the test if there is
+// an error.
+func RemoveNetwork(t testing.TestingT, name string, logger *logger.Logger) {
+	require.NoError(t, RemoveNetworkE(t, name, logger))
+}
+
+// RemoveNetworkE runs the 'docker network rm' command to remove the given network. The containers connected to the
+// network must be removed, or disconnected, first.
+func RemoveNetworkE(t testing.TestingT, name string, logger *logger.Logger) error {
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    []string{"network", "rm", name},
+		Logger:  logger,
+	}
+	return shell.RunCommandE(t, cmd)
+}
+
+// ConnectContainer runs the 'docker network connect' command to connect the given container to the given network.
+// This will fail the test if there is an error.
+func ConnectContainer(t testing.TestingT, network string, container string, options *ConnectOptions) {
+	require.NoError(t, ConnectContainerE(t, network, container, options))
+}
+
+// ConnectContainerE runs the 'docker network connect' command to connect the given container to the given network.
+func ConnectContainerE(t testing.TestingT, network string, container string, options *ConnectOptions) error {
+	args := []string{"network", "connect"}
+	for _, alias := range options.Aliases {
+		args = append(args, "--alias", alias)
+	}
+	if options.IP != "" {
+		args = append(args, "--ip", options.IP)
+	}
+	args = append(args, network, container)
+
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    args,
+		Logger:  options.Logger,
+	}
+	return shell.RunCommandE(t, cmd)
+}
+
+// DisconnectContainer runs the 'docker network disconnect' command to disconnect the given container from the given
+// network. This will fail the test if there is an error.
+func DisconnectContainer(t testing.TestingT, network string, container string, logger *logger.Logger) {
+	require.NoError(t, DisconnectContainerE(t, network, container, logger))
+}
+
+// DisconnectContainerE runs the 'docker network disconnect' command to disconnect the given container from the given
+// network.
+func DisconnectContainerE(t testing.TestingT, network string, container string, logger *logger.Logger) error {
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    []string{"network", "disconnect", network, container},
+		Logger:  logger,
+	}
+	return shell.RunCommandE(t, cmd)
+}
+
+// InspectNetwork runs the 'docker network inspect' command for the given network, and returns its state. This will
+// fail the test if there is an error.
+func InspectNetwork(t testing.TestingT, name string, logger *logger.Logger) *NetworkInspect {
+	network, err := InspectNetworkE(t, name, logger)
+	require.NoError(t, err)
+	return network
+}
+
+// InspectNetworkE runs the 'docker network inspect' command for the given network, and returns its state.
+func InspectNetworkE(t testing.TestingT, name string, logger *logger.Logger) (*NetworkInspect, error) {
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    []string{"network", "inspect", name},
+		Logger:  logger,
+	}
+	out, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return parseNetworkInspectE(name, out)
+}
+
+// parseNetworkInspectE parses the output of 'docker network inspect' for a single network.
+func parseNetworkInspectE(name string, out string) (*NetworkInspect, error) {
+	var networks []networkInspectOutput
+	if err := json.Unmarshal([]byte(out), &networks); err != nil {
+		return nil, err
+	}
+	if len(networks) != 1 {
+		return nil, fmt.Errorf("no network found with name %s", name)
+	}
+
+	network := networks[0]
+	inspect := &NetworkInspect{
+		ID:         network.ID,
+		Name:       network.Name,
+		Created:    network.Created,
+		Driver:     network.Driver,
+		Scope:      network.Scope,
+		Internal:   network.Internal,
+		Labels:     network.Labels,
+		Subnets:    network.IPAM.Config,
+		Containers: network.Containers,
+	}
+	if inspect.Subnets == nil {
+		inspect.Subnets = []NetworkSubnet{}
+	}
+	if inspect.Containers == nil {
+		inspect.Containers = map[string]NetworkEndpoint{}
+	}
+	return inspect, nil
+}
+
+// SweepNetworks removes the networks created by CreateNetwork that have all the given labels, e.g. the ones left
+// behind by interrupted test runs. Pass nil labels to remove all of them. This will fail the test if there is an
+// error.
+func SweepNetworks(t testing.TestingT, labels map[string]string, logger *logger.Logger) []string {
+	removed, err := SweepNetworksE(t, labels, logger)
+	require.NoError(t, err)
+	return removed
+}
+
+// SweepNetworksE removes the networks created by CreateNetwork that have all the given labels, e.g. the ones left
+// behind by interrupted test runs, and returns the names of the networks removed. Pass nil labels to remove all of
+// them. Networks that still have containers connected are not removed, and reported in the returned error.
+func SweepNetworksE(t testing.TestingT, labels map[string]string, logger *logger.Logger) ([]string, error) {
+	args := append([]string{"network", "ls", "--format", "{{.Name}}"}, formatLabelFilters(labels)...)
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    args,
+		Logger:  logger,
+	}
+	out, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []string{}
+	var errorsOccurred = new(multierror.Error)
+	for _, name := range strings.Fields(out) {
+		if err := RemoveNetworkE(t, name, logger); err != nil {
+			errorsOccurred = multierror.Append(errorsOccurred, err)
+			continue
+		}
+		removed = append(removed, name)
+	}
+	logger.Logf(t, "Swept %d networks", len(removed))
+	return removed, errorsOccurred.ErrorOrNil()
+}
@@ -0,0 +1,97 @@
+package docker
+
+import (
+	"net"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateNetworkAndConnectContainers(t *testing.T) {
+	t.Parallel()
+
+	runID := random.UniqueId()
+	network := "network-test-" + runID
+	CreateNetwork(t, network, &NetworkOptions{Labels: map[string]string{"run": runID}})
+
+	server := StartContainer(t, dockerInspectTestImage, &ContainerOptions{})
+	client := StartContainer(t, "alpine:3.19", &ContainerOptions{RunOptions: RunOptions{Command: []string{"sleep", "60"}}})
+	ConnectContainer(t, network, server.ID, &ConnectOptions{Aliases: []string{"web"}})
+	ConnectContainer(t, network, client.ID, &ConnectOptions{})
+
+	inspect := InspectNetwork(t, network, nil)
+	assert.Equal(t, network, inspect.Name)
+	assert.Equal(t, "true", inspect.Labels[ManagedLabel])
+	assert.Equal(t, runID, inspect.Labels["run"])
+	// The subnet is allocated by docker, so that the test does not collide with the networks already on the host.
+	require.Len(t, inspect.Subnets, 1)
+	_, subnet, err := net.ParseCIDR(inspect.Subnets[0].Subnet)
+	require.NoError(t, err)
+	require.Contains(t, inspect.Containers, client.ID)
+	clientIP, _, err := net.ParseCIDR(inspect.Containers[client.ID].IPv4Address)
+	require.NoError(t, err)
+	assert.True(t, subnet.Contains(clientIP), "%s is not in %s", clientIP, subnet)
+
+	out := Run(t, "alpine:3.19", &RunOptions{
+		Command:      []string{"wget", "-q", "-O", "-", "http://web"},
+		Remove:       true,
+		OtherOptions: []string{"--network", network},
+	})
+	assert.Contains(t, out, "Welcome to nginx")
+
+	DisconnectContainer(t, network, client.ID, nil)
+	assert.NotContains(t, InspectNetwork(t, network, nil).Containers, client.ID)
+}
+
+func TestSweepNetworks(t *testing.T) {
+	t.Parallel()
+
+	runID := random.UniqueId()
+	network := "network-sweep-test-" + runID
+	CreateNetwork(t, network, &NetworkOptions{Labels: map[string]string{"run": runID}, KeepNetwork: true})
+
+	removed := SweepNetworks(t, map[string]string{"run": runID}, nil)
+	assert.Equal(t, []string{network}, removed)
+	_, err := InspectNetworkE(t, network, nil)
+	assert.Error(t, err)
+}
+
+func TestParseNetworkInspect(t *testing.T) {
+	t.Parallel()
+
+	out := `[{
+  "Name": "app", "Id": "4f1c", "Created": "2024-03-01T10:00:00.000000000Z", "Scope": "local", "Driver": "bridge",
+  "IPAM": {"Driver": "default", "Options": {}, "Config": [{"Subnet": "172.29.0.0/16", "Gateway": "172.29.0.1"}]},
+  "Internal": true,
+  "Containers": {"9a8b": {"Name": "web", "EndpointID": "e1", "MacAddress": "02:42:ac:1d:00:02", "IPv4Address": "172.29.0.2/16", "IPv6Address": ""}},
+  "Labels": {"io.gruntwork.terratest.managed": "true"}
+}]`
+
+	inspect, err := parseNetworkInspectE("app", out)
+	require.NoError(t, err)
+	assert.Equal(t, "4f1c", inspect.ID)
+	assert.Equal(t, "bridge", inspect.Driver)
+	assert.True(t, inspect.Internal)
+	assert.Equal(t, []NetworkSubnet{{Subnet: "172.29.0.0/16", Gateway: "172.29.0.1"}}, inspect.Subnets)
+	assert.Equal(t, map[string]NetworkEndpoint{"9a8b": {Name: "web", MacAddress: "02:42:ac:1d:00:02", IPv4Address: "172.29.0.2/16"}}, inspect.Containers)
+	assert.Equal(t, "true", inspect.Labels[ManagedLabel])
+
+	_, err = parseNetworkInspectE("missing", "[]")
+	assert.EqualError(t, err, "no network found with name missing")
+}
+
+func TestFormatLabelArgs(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{
+		"--label", ManagedLabel + "=true",
+		"--label", TestLabel + "=TestFormatLabelArgs",
+		"--label", "a=1",
+		"--label", "b=2",
+	}, formatLabelArgs(t, map[string]string{"b": "2", "a": "1"}))
+
+	assert.Equal(t, []string{"--filter", "label=" + ManagedLabel + "=true"}, formatLabelFilters(nil))
+	assert.Equal(t, []string{"--filter", "label=" + ManagedLabel + "=true", "--filter", "label=run=42"}, formatLabelFilters(map[string]string{"run": "42"}))
+}
@@ -0,0 +1,169 @@
+package docker
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// SBOMOptions defines options that can be passed to GenerateSBOM.
+type SBOMOptions struct {
+	// The path to the syft binary. Empty => syft from the PATH.
+	Binary string
+
+	// If set, the SBOM is also written to this path in the syft JSON format, e.g. to archive it along with the test
+	// results, or to scan it later.
+	OutputPath string
+
+	// Custom CLI options that will be passed as-is to syft, e.g. --scope all-layers.
+	OtherOptions []string
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// SBOM is the software bill of materials of an image: the inventory of the packages installed in it.
+type SBOM struct {
+	Image    string
+	Distro   string // e.g. alpine 3.19.1
+	Packages []SBOMPackage
+
+	// The SBOM in the syft JSON format, as generated.
+	Raw string
+}
+
+// SBOMPackage is a package installed in an image.
+type SBOMPackage struct {
+	Name     string
+	Version  string
+	Type     string // e.g. apk, deb, go-module or python
+	PURL     string // The package URL, e.g. pkg:apk/alpine/busybox@1.36.1-r15
+	Licenses []string
+}
+
+// FindPackages returns the packages with the given name, as several versions of the same package can be installed.
+func (sbom SBOM) FindPackages(name string) []SBOMPackage {
+	packages := []SBOMPackage{}
+	for _, pkg := range sbom.Packages {
+		if pkg.Name == name {
+			packages = append(packages, pkg)
+		}
+	}
+	return packages
+}
+
+// HasPackage returns true if a package with the given name is installed.
+func (sbom SBOM) HasPackage(name string) bool {
+	return len(sbom.FindPackages(name)) > 0
+}
+
+// GenerateSBOM runs syft to generate the SBOM of the given image, which is read from the docker daemon, e.g. after
+// Build. This will fail the test if there is an error.
+func GenerateSBOM(t testing.TestingT, image string, options *SBOMOptions) *SBOM {
+	sbom, err := GenerateSBOME(t, image, options)
+	require.NoError(t, err)
+	return sbom
+}
+
+// GenerateSBOME runs syft to generate the SBOM of the given image, which is read from the docker daemon, e.g. after
+// Build. syft does not need network access for this.
+func GenerateSBOME(t testing.TestingT, image string, options *SBOMOptions) (*SBOM, error) {
+	options.Logger.Logf(t, "Generating the SBOM of image %s", image)
+
+	binary := options.Binary
+	if binary == "" {
+		binary = "syft"
+	}
+	args := []string{"docker:" + image, "--output", "syft-json", "--quiet"}
+	args = append(args, options.OtherOptions...)
+	cmd := shell.Command{
+		Command: binary,
+		Args:    args,
+		// The SBOM is too large to be logged usefully.
+		Logger: logger.Discard,
+	}
+	out, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	sbom, err := parseSyftJSONE(out)
+	if err != nil {
+		return nil, err
+	}
+	sbom.Image = image
+	if options.OutputPath != "" {
+		if err := os.WriteFile(options.OutputPath, []byte(out), 0644); err != nil {
+			return nil, err
+		}
+	}
+	options.Logger.Logf(t, "Found %d packages in image %s", len(sbom.Packages), image)
+	return sbom, nil
+}
+
+// syftJSON is the part of the syft JSON format used by GenerateSBOM.
+type syftJSON struct {
+	Artifacts []struct {
+		Name     string
+		Version  string
+		Type     string
+		PURL     string
+		Licenses []syftLicense
+	}
+	Distro struct {
+		Name    string
+		Version string
+	}
+}
+
+// syftLicense is a license in the syft JSON format, which is a plain string up to syft v0.80, and an object since.
+type syftLicense string
+
+func (license *syftLicense) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*license = syftLicense(value)
+		return nil
+	}
+	var object struct {
+		Value string
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+	*license = syftLicense(object.Value)
+	return nil
+}
+
+// parseSyftJSONE parses an SBOM in the syft JSON format.
+func parseSyftJSONE(out string) (*SBOM, error) {
+	var parsed syftJSON
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		return nil, err
+	}
+
+	sbom := &SBOM{
+		Distro:   strings.TrimSpace(parsed.Distro.Name + " " + parsed.Distro.Version),
+		Packages: []SBOMPackage{},
+		Raw:      out,
+	}
+	for _, artifact := range parsed.Artifacts {
+		licenses := []string{}
+		for _, license := range artifact.Licenses {
+			licenses = append(licenses, string(license))
+		}
+		sbom.Packages = append(sbom.Packages, SBOMPackage{
+			Name:     artifact.Name,
+			Version:  artifact.Version,
+			Type:     artifact.Type,
+			PURL:     artifact.PURL,
+			Licenses: licenses,
+		})
+	}
+	return sbom, nil
+}
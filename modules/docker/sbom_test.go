@@ -0,0 +1,44 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSyftJSON(t *testing.T) {
+	t.Parallel()
+
+	out := `{
+  "artifacts": [
+    {"id": "1", "name": "busybox", "version": "1.36.1-r15", "type": "apk", "purl": "pkg:apk/alpine/busybox@1.36.1-r15", "licenses": ["GPL-2.0-only"]},
+    {"id": "2", "name": "golang.org/x/net", "version": "v0.17.0", "type": "go-module", "purl": "pkg:golang/golang.org/x/net@v0.17.0", "licenses": [{"value": "BSD-3-Clause", "spdxExpression": "BSD-3-Clause", "type": "declared"}]},
+    {"id": "3", "name": "busybox", "version": "1.35.0-r0", "type": "apk", "licenses": []}
+  ],
+  "distro": {"name": "alpine", "version": "3.19.1"}
+}`
+
+	sbom, err := parseSyftJSONE(out)
+	require.NoError(t, err)
+	assert.Equal(t, "alpine 3.19.1", sbom.Distro)
+	assert.Equal(t, out, sbom.Raw)
+	require.Len(t, sbom.Packages, 3)
+	assert.Equal(t, SBOMPackage{
+		Name:     "golang.org/x/net",
+		Version:  "v0.17.0",
+		Type:     "go-module",
+		PURL:     "pkg:golang/golang.org/x/net@v0.17.0",
+		Licenses: []string{"BSD-3-Clause"},
+	}, sbom.Packages[1])
+	assert.Equal(t, []string{"GPL-2.0-only"}, sbom.Packages[0].Licenses)
+
+	busybox := sbom.FindPackages("busybox")
+	require.Len(t, busybox, 2)
+	assert.Equal(t, "1.35.0-r0", busybox[1].Version)
+	assert.True(t, sbom.HasPackage("golang.org/x/net"))
+	assert.False(t, sbom.HasPackage("openssl"))
+
+	_, err = parseSyftJSONE("not json")
+	assert.Error(t, err)
+}
@@ -0,0 +1,636 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+// The kinds of checks of a structure test spec, as reported in StructureTestResult.
+const (
+	StructureTestKindFileExistence = "fileExistence"
+	StructureTestKindFileContent   = "fileContent"
+	StructureTestKindCommand       = "command"
+	StructureTestKindMetadata      = "metadata"
+)
+
+// maxSymlinkDepth is the maximum number of symbolic links followed to resolve a path in an image.
+const maxSymlinkDepth = 255
+
+// StructureTestSpec is a declarative set of checks on the contents and metadata of an image, in the spirit of
+// container-structure-test (https://github.com/GoogleContainerTools/container-structure-test). It supports a subset of
+// its YAML schema, so that existing specs can be loaded with LoadStructureTestSpec.
+type StructureTestSpec struct {
+	SchemaVersion      string              `json:"schemaVersion,omitempty"`
+	FileExistenceTests []FileExistenceTest `json:"fileExistenceTests,omitempty"`
+	FileContentTests   []FileContentTest   `json:"fileContentTests,omitempty"`
+	CommandTests       []CommandTest       `json:"commandTests,omitempty"`
+	MetadataTest       *MetadataTest       `json:"metadataTest,omitempty"`
+}
+
+// FileExistenceTest checks that a path exists, or not, in the filesystem of an image. Symbolic links are followed.
+type FileExistenceTest struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+
+	// Whether the path should exist. nil => true.
+	ShouldExist *bool `json:"shouldExist,omitempty"`
+
+	// The expected permissions, in the format of ls -l, e.g. -rwxr-xr-x or drwxr-xr-x. Empty => not checked.
+	Permissions string `json:"permissions,omitempty"`
+
+	// The expected owner of the path. nil => not checked.
+	UID *int `json:"uid,omitempty"`
+	GID *int `json:"gid,omitempty"`
+}
+
+// FileContentTest checks the contents of a file in the filesystem of an image. Symbolic links are followed.
+type FileContentTest struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+
+	// Regular expressions that must all match the contents of the file.
+	ExpectedContents []string `json:"expectedContents,omitempty"`
+
+	// Regular expressions that must not match the contents of the file.
+	ExcludedContents []string `json:"excludedContents,omitempty"`
+}
+
+// CommandTest runs a command in a container of an image, and checks its output and exit code. The command replaces the
+// entrypoint of the image.
+type CommandTest struct {
+	Name    string                  `json:"name"`
+	Command string                  `json:"command"`
+	Args    []string                `json:"args,omitempty"`
+	EnvVars []StructureTestKeyValue `json:"envVars,omitempty"`
+
+	// Regular expressions that must all match stdout.
+	ExpectedOutput []string `json:"expectedOutput,omitempty"`
+
+	// Regular expressions that must not match stdout.
+	ExcludedOutput []string `json:"excludedOutput,omitempty"`
+
+	// Regular expressions that must all match stderr.
+	ExpectedError []string `json:"expectedError,omitempty"`
+
+	// Regular expressions that must not match stderr.
+	ExcludedError []string `json:"excludedError,omitempty"`
+
+	// The expected exit code of the command.
+	ExitCode int `json:"exitCode,omitempty"`
+}
+
+// MetadataTest checks the configuration of an image. Fields left empty are not checked.
+type MetadataTest struct {
+	// Environment variables that must be set to the given values.
+	EnvVars []StructureTestKeyValue `json:"envVars,omitempty"`
+
+	// Labels that must be set to the given values.
+	Labels []StructureTestKeyValue `json:"labels,omitempty"`
+
+	// Ports that must, or must not, be exposed, e.g. 8080 or 8080/udp. The protocol defaults to tcp.
+	ExposedPorts   []string `json:"exposedPorts,omitempty"`
+	UnexposedPorts []string `json:"unexposedPorts,omitempty"`
+
+	// Volumes that must be declared.
+	Volumes []string `json:"volumes,omitempty"`
+
+	// The expected entrypoint and command. nil => not checked, while an empty list checks that there is none.
+	Entrypoint []string `json:"entrypoint"`
+	Cmd        []string `json:"cmd"`
+
+	Workdir string `json:"workdir,omitempty"`
+	User    string `json:"user,omitempty"`
+
+	// The expected number of layers of the image. 0 => not checked.
+	LayerCount int `json:"layerCount,omitempty"`
+}
+
+// StructureTestKeyValue is a key and its value, e.g. an environment variable or a label.
+type StructureTestKeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// StructureTestOptions defines options that can be passed to RunStructureTests.
+type StructureTestOptions struct {
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// StructureTestResult is the result of a single check of a structure test spec.
+type StructureTestResult struct {
+	Kind   string // One of the StructureTestKind constants
+	Name   string
+	Passed bool
+	Errors []string // Why the check failed
+}
+
+// StructureTestReport is the result of running a structure test spec against an image.
+type StructureTestReport struct {
+	Image   string
+	Results []StructureTestResult
+}
+
+// Passed returns true if all the checks passed.
+func (report StructureTestReport) Passed() bool {
+	return len(report.Failures()) == 0
+}
+
+// Failures returns the checks that failed.
+func (report StructureTestReport) Failures() []StructureTestResult {
+	failures := []StructureTestResult{}
+	for _, result := range report.Results {
+		if !result.Passed {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// String returns a summary of the report, listing the reasons of the failed checks.
+func (report StructureTestReport) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%d/%d structure tests passed for image %s", len(report.Results)-len(report.Failures()), len(report.Results), report.Image)
+	for _, result := range report.Failures() {
+		fmt.Fprintf(&builder, "\n  FAIL %s %q", result.Kind, result.Name)
+		for _, err := range result.Errors {
+			fmt.Fprintf(&builder, "\n    - %s", err)
+		}
+	}
+	return builder.String()
+}
+
+// LoadStructureTestSpec loads a structure test spec from the given YAML (or JSON) file. This will fail the test if
+// there is an error.
+func LoadStructureTestSpec(t testing.TestingT, specPath string) *StructureTestSpec {
+	spec, err := LoadStructureTestSpecE(t, specPath)
+	require.NoError(t, err)
+	return spec
+}
+
+// LoadStructureTestSpecE loads a structure test spec from the given YAML (or JSON) file.
+func LoadStructureTestSpecE(t testing.TestingT, specPath string) (*StructureTestSpec, error) {
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		return nil, err
+	}
+	var spec StructureTestSpec
+	if err := yaml.UnmarshalStrict(data, &spec); err != nil {
+		return nil, fmt.Errorf("invalid structure test spec %s: %w", specPath, err)
+	}
+	return &spec, nil
+}
+
+// RunStructureTests runs the checks of the given spec against the given image, which must exist locally, e.g. after
+// Build, and returns the result of every check. This will fail the test if the checks can not be run, but not if they
+// fail: use AssertStructureTests for that.
+func RunStructureTests(t testing.TestingT, image string, spec *StructureTestSpec, options *StructureTestOptions) *StructureTestReport {
+	report, err := RunStructureTestsE(t, image, spec, options)
+	require.NoError(t, err)
+	return report
+}
+
+// RunStructureTestsE runs the checks of the given spec against the given image, which must exist locally, e.g. after
+// Build, and returns the result of every check. File checks run against the exported filesystem of a container of the
+// image, so that they also work for images without a shell, and commands run in their own container with 'docker run'.
+// An error is only returned if the checks can not be run.
+func RunStructureTestsE(t testing.TestingT, image string, spec *StructureTestSpec, options *StructureTestOptions) (*StructureTestReport, error) {
+	options.Logger.Logf(t, "Running structure tests against image %s", image)
+	report := &StructureTestReport{Image: image}
+
+	if len(spec.FileExistenceTests) > 0 || len(spec.FileContentTests) > 0 {
+		results, err := runFileStructureTestsE(t, image, spec, options)
+		if err != nil {
+			return nil, err
+		}
+		report.Results = append(report.Results, results...)
+	}
+	for _, commandTest := range spec.CommandTests {
+		result, err := runCommandStructureTestE(t, image, commandTest, options)
+		if err != nil {
+			return nil, err
+		}
+		report.Results = append(report.Results, result)
+	}
+	if spec.MetadataTest != nil {
+		result, err := runMetadataStructureTestE(t, image, *spec.MetadataTest, options)
+		if err != nil {
+			return nil, err
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	for _, result := range report.Results {
+		status := "PASS"
+		if !result.Passed {
+			status = "FAIL"
+		}
+		options.Logger.Logf(t, "%s %s %q %s", status, result.Kind, result.Name, strings.Join(result.Errors, "; "))
+	}
+	return report, nil
+}
+
+// AssertStructureTests runs the checks of the given spec against the given image, and fails the test if any of them
+// fails.
+func AssertStructureTests(t testing.TestingT, image string, spec *StructureTestSpec, options *StructureTestOptions) *StructureTestReport {
+	report, err := AssertStructureTestsE(t, image, spec, options)
+	require.NoError(t, err)
+	return report
+}
+
+// AssertStructureTestsE runs the checks of the given spec against the given image, and returns a StructureTestsFailed
+// error, along with the report, if any of them fails.
+func AssertStructureTestsE(t testing.TestingT, image string, spec *StructureTestSpec, options *StructureTestOptions) (*StructureTestReport, error) {
+	report, err := RunStructureTestsE(t, image, spec, options)
+	if err != nil {
+		return nil, err
+	}
+	if !report.Passed() {
+		return report, StructureTestsFailed{Report: *report}
+	}
+	return report, nil
+}
+
+// runFileStructureTestsE runs the file existence and file content checks against the exported filesystem of a
+// container created, but never started, from the image.
+func runFileStructureTestsE(t testing.TestingT, image string, spec *StructureTestSpec, options *StructureTestOptions) ([]StructureTestResult, error) {
+	createCmd := shell.Command{
+		Command: "docker",
+		// The entrypoint is never run: it only has to be set for images that have none.
+		Args:   []string{"container", "create", "--entrypoint", "/terratest-structure-test", image},
+		Logger: options.Logger,
+	}
+	id, err := shell.RunCommandAndGetStdOutE(t, createCmd)
+	if err != nil {
+		return nil, err
+	}
+	id = strings.TrimSpace(id)
+	defer func() {
+		removeCmd := shell.Command{Command: "docker", Args: []string{"container", "rm", id}, Logger: options.Logger}
+		if err := shell.RunCommandE(t, removeCmd); err != nil {
+			options.Logger.Logf(t, "Failed to remove container %s: %s", id, err)
+		}
+	}()
+
+	wanted := map[string]bool{}
+	for _, contentTest := range spec.FileContentTests {
+		wanted[path.Clean(contentTest.Path)] = true
+	}
+	headers, contents, err := exportContainerFilesystemE(id, wanted)
+	if err != nil {
+		return nil, err
+	}
+
+	// Content tests of symbolic links need the contents of their targets, which are only known once exported.
+	missing := map[string]bool{}
+	for _, contentTest := range spec.FileContentTests {
+		resolved, exists := resolveImagePath(headers, contentTest.Path, 0)
+		if _, found := contents[resolved]; exists && !found {
+			missing[resolved] = true
+		}
+	}
+	if len(missing) > 0 {
+		_, targetContents, err := exportContainerFilesystemE(id, missing)
+		if err != nil {
+			return nil, err
+		}
+		for target, data := range targetContents {
+			contents[target] = data
+		}
+	}
+
+	results := []StructureTestResult{}
+	for _, existenceTest := range spec.FileExistenceTests {
+		results = append(results, checkFileExistence(headers, existenceTest))
+	}
+	for _, contentTest := range spec.FileContentTests {
+		results = append(results, checkFileContent(headers, contents, contentTest))
+	}
+	return results, nil
+}
+
+// exportContainerFilesystemE runs 'docker container export' and returns the tar header of every path in the
+// filesystem of the container, along with the contents of the wanted regular files. Paths are absolute.
+func exportContainerFilesystemE(id string, wanted map[string]bool) (map[string]*tar.Header, map[string][]byte, error) {
+	cmd := exec.Command("docker", "container", "export", id)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+
+	headers, contents, readErr := readFilesystemArchive(stdout, wanted)
+	if readErr != nil {
+		// Unblock docker, which may still be writing the archive.
+		io.Copy(io.Discard, stdout)
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, nil, fmt.Errorf("docker container export %s failed: %w: %s", id, err, stderr.String())
+	}
+	return headers, contents, readErr
+}
+
+// readFilesystemArchive reads the tar archive of a filesystem, returning the header of every path, and the contents of
+// the wanted regular files.
+func readFilesystemArchive(reader io.Reader, wanted map[string]bool) (map[string]*tar.Header, map[string][]byte, error) {
+	headers := map[string]*tar.Header{}
+	contents := map[string][]byte{}
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return headers, contents, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		name := path.Clean("/" + header.Name)
+		headers[name] = header
+		if wanted[name] && header.Typeflag == tar.TypeReg {
+			data, err := io.ReadAll(archive)
+			if err != nil {
+				return nil, nil, err
+			}
+			contents[name] = data
+		}
+	}
+}
+
+// resolveImagePath follows the symbolic links of the given path, including in its parent directories, and returns the
+// path it resolves to, and whether that path exists.
+func resolveImagePath(headers map[string]*tar.Header, imagePath string, depth int) (string, bool) {
+	imagePath = path.Clean("/" + imagePath)
+	if imagePath == "/" {
+		return imagePath, true
+	}
+	if depth > maxSymlinkDepth {
+		return imagePath, false
+	}
+
+	parent, exists := resolveImagePath(headers, path.Dir(imagePath), depth+1)
+	if !exists {
+		return imagePath, false
+	}
+	current := path.Join(parent, path.Base(imagePath))
+	header, exists := headers[current]
+	if !exists {
+		return current, false
+	}
+	switch header.Typeflag {
+	case tar.TypeSymlink:
+		target := header.Linkname
+		if !path.IsAbs(target) {
+			target = path.Join(parent, target)
+		}
+		return resolveImagePath(headers, target, depth+1)
+	case tar.TypeLink:
+		// The contents of hard links are stored with the first path of the file in the archive.
+		return resolveImagePath(headers, header.Linkname, depth+1)
+	}
+	return current, true
+}
+
+func checkFileExistence(headers map[string]*tar.Header, existenceTest FileExistenceTest) StructureTestResult {
+	result := StructureTestResult{Kind: StructureTestKindFileExistence, Name: existenceTest.Name}
+	shouldExist := existenceTest.ShouldExist == nil || *existenceTest.ShouldExist
+
+	resolved, exists := resolveImagePath(headers, existenceTest.Path, 0)
+	switch {
+	case exists && !shouldExist:
+		result.Errors = append(result.Errors, fmt.Sprintf("%s exists", existenceTest.Path))
+	case !exists && shouldExist:
+		result.Errors = append(result.Errors, fmt.Sprintf("%s does not exist", existenceTest.Path))
+	case exists:
+		// The root directory is not part of the archive, so it has no header.
+		if header, found := headers[resolved]; found {
+			result.Errors = append(result.Errors, checkFileAttributes(header, existenceTest)...)
+		}
+	}
+	result.Passed = len(result.Errors) == 0
+	return result
+}
+
+func checkFileAttributes(header *tar.Header, existenceTest FileExistenceTest) []string {
+	errors := []string{}
+	if existenceTest.Permissions != "" {
+		permissions := header.FileInfo().Mode().String()
+		if permissions != existenceTest.Permissions {
+			errors = append(errors, fmt.Sprintf("%s has permissions %s, expected %s", existenceTest.Path, permissions, existenceTest.Permissions))
+		}
+	}
+	if existenceTest.UID != nil && header.Uid != *existenceTest.UID {
+		errors = append(errors, fmt.Sprintf("%s is owned by uid %d, expected %d", existenceTest.Path, header.Uid, *existenceTest.UID))
+	}
+	if existenceTest.GID != nil && header.Gid != *existenceTest.GID {
+		errors = append(errors, fmt.Sprintf("%s is owned by gid %d, expected %d", existenceTest.Path, header.Gid, *existenceTest.GID))
+	}
+	return errors
+}
+
+func checkFileContent(headers map[string]*tar.Header, contents map[string][]byte, contentTest FileContentTest) StructureTestResult {
+	result := StructureTestResult{Kind: StructureTestKindFileContent, Name: contentTest.Name}
+
+	resolved, exists := resolveImagePath(headers, contentTest.Path, 0)
+	data, found := contents[resolved]
+	switch {
+	case !exists:
+		result.Errors = append(result.Errors, fmt.Sprintf("%s does not exist", contentTest.Path))
+	case !found:
+		result.Errors = append(result.Errors, fmt.Sprintf("%s is not a regular file", contentTest.Path))
+	default:
+		result.Errors = append(result.Errors, checkPatterns("contents of "+contentTest.Path, string(data), contentTest.ExpectedContents, contentTest.ExcludedContents)...)
+	}
+	result.Passed = len(result.Errors) == 0
+	return result
+}
+
+// runCommandStructureTestE runs the command of the check in its own container of the image with 'docker run'.
+func runCommandStructureTestE(t testing.TestingT, image string, commandTest CommandTest, options *StructureTestOptions) (StructureTestResult, error) {
+	result := StructureTestResult{Kind: StructureTestKindCommand, Name: commandTest.Name}
+
+	args := []string{"run", "--rm", "--entrypoint", commandTest.Command}
+	for _, envVar := range commandTest.EnvVars {
+		args = append(args, "--env", fmt.Sprintf("%s=%s", envVar.Key, envVar.Value))
+	}
+	args = append(args, image)
+	args = append(args, commandTest.Args...)
+
+	out, err := runDockerAndGetOutputE(t, args, options.Logger)
+	if err != nil {
+		return result, err
+	}
+	if isDockerRunFailure(out) {
+		return result, DockerCLIFailed{Args: args, ExitCode: out.ExitCode, Stderr: strings.TrimSpace(out.Stderr)}
+	}
+
+	if out.ExitCode != commandTest.ExitCode {
+		result.Errors = append(result.Errors, fmt.Sprintf("exited with code %d, expected %d. Stderr: %s", out.ExitCode, commandTest.ExitCode, strings.TrimSpace(out.Stderr)))
+	}
+	result.Errors = append(result.Errors, checkPatterns("stdout", out.Stdout, commandTest.ExpectedOutput, commandTest.ExcludedOutput)...)
+	result.Errors = append(result.Errors, checkPatterns("stderr", out.Stderr, commandTest.ExpectedError, commandTest.ExcludedError)...)
+	result.Passed = len(result.Errors) == 0
+	return result, nil
+}
+
+// checkPatterns checks that all the expected regular expressions, and none of the excluded ones, match the given
+// output.
+func checkPatterns(description string, output string, expected []string, excluded []string) []string {
+	errors := []string{}
+	for _, pattern := range expected {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("invalid regular expression %q: %s", pattern, err))
+		} else if !regex.MatchString(output) {
+			errors = append(errors, fmt.Sprintf("%s does not match %q: %q", description, pattern, output))
+		}
+	}
+	for _, pattern := range excluded {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("invalid regular expression %q: %s", pattern, err))
+		} else if regex.MatchString(output) {
+			errors = append(errors, fmt.Sprintf("%s matches excluded %q: %q", description, pattern, output))
+		}
+	}
+	return errors
+}
+
+// imageInspectOutput is the part of the output of 'docker image inspect' used by the metadata checks.
+type imageInspectOutput struct {
+	Config struct {
+		Env          []string
+		Labels       map[string]string
+		ExposedPorts map[string]struct{}
+		Volumes      map[string]struct{}
+		Entrypoint   []string
+		Cmd          []string
+		WorkingDir   string
+		User         string
+	}
+	RootFS struct {
+		Layers []string
+	}
+}
+
+// runMetadataStructureTestE checks the configuration of the image, as reported by 'docker image inspect'.
+func runMetadataStructureTestE(t testing.TestingT, image string, metadataTest MetadataTest, options *StructureTestOptions) (StructureTestResult, error) {
+	result := StructureTestResult{Kind: StructureTestKindMetadata, Name: "metadata"}
+
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    []string{"image", "inspect", image},
+		Logger:  options.Logger,
+	}
+	out, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	if err != nil {
+		return result, err
+	}
+	var inspected []imageInspectOutput
+	if err := json.Unmarshal([]byte(out), &inspected); err != nil {
+		return result, err
+	}
+	if len(inspected) != 1 {
+		return result, fmt.Errorf("no image found with name %s", image)
+	}
+
+	result.Errors = checkImageMetadata(inspected[0], metadataTest)
+	result.Passed = len(result.Errors) == 0
+	return result, nil
+}
+
+func checkImageMetadata(inspected imageInspectOutput, metadataTest MetadataTest) []string {
+	config := inspected.Config
+	errors := []string{}
+
+	env := map[string]string{}
+	for _, envVar := range config.Env {
+		key, value, _ := strings.Cut(envVar, "=")
+		env[key] = value
+	}
+	for _, envVar := range metadataTest.EnvVars {
+		if value, isSet := env[envVar.Key]; !isSet {
+			errors = append(errors, fmt.Sprintf("environment variable %s is not set", envVar.Key))
+		} else if value != envVar.Value {
+			errors = append(errors, fmt.Sprintf("environment variable %s is %q, expected %q", envVar.Key, value, envVar.Value))
+		}
+	}
+	for _, label := range metadataTest.Labels {
+		if value, isSet := config.Labels[label.Key]; !isSet {
+			errors = append(errors, fmt.Sprintf("label %s is not set", label.Key))
+		} else if value != label.Value {
+			errors = append(errors, fmt.Sprintf("label %s is %q, expected %q", label.Key, value, label.Value))
+		}
+	}
+
+	for _, port := range metadataTest.ExposedPorts {
+		if !isPortExposed(config.ExposedPorts, port) {
+			errors = append(errors, fmt.Sprintf("port %s is not exposed", port))
+		}
+	}
+	for _, port := range metadataTest.UnexposedPorts {
+		if isPortExposed(config.ExposedPorts, port) {
+			errors = append(errors, fmt.Sprintf("port %s is exposed", port))
+		}
+	}
+	for _, volume := range metadataTest.Volumes {
+		if _, declared := config.Volumes[volume]; !declared {
+			errors = append(errors, fmt.Sprintf("volume %s is not declared", volume))
+		}
+	}
+
+	if metadataTest.Entrypoint != nil && !stringSlicesEqual(config.Entrypoint, metadataTest.Entrypoint) {
+		errors = append(errors, fmt.Sprintf("entrypoint is %q, expected %q", config.Entrypoint, metadataTest.Entrypoint))
+	}
+	if metadataTest.Cmd != nil && !stringSlicesEqual(config.Cmd, metadataTest.Cmd) {
+		errors = append(errors, fmt.Sprintf("cmd is %q, expected %q", config.Cmd, metadataTest.Cmd))
+	}
+	if metadataTest.Workdir != "" && config.WorkingDir != metadataTest.Workdir {
+		errors = append(errors, fmt.Sprintf("workdir is %q, expected %q", config.WorkingDir, metadataTest.Workdir))
+	}
+	if metadataTest.User != "" && config.User != metadataTest.User {
+		errors = append(errors, fmt.Sprintf("user is %q, expected %q", config.User, metadataTest.User))
+	}
+	if metadataTest.LayerCount != 0 && len(inspected.RootFS.Layers) != metadataTest.LayerCount {
+		errors = append(errors, fmt.Sprintf("image has %d layers, expected %d", len(inspected.RootFS.Layers), metadataTest.LayerCount))
+	}
+	return errors
+}
+
+// isPortExposed returns true if the given port, e.g. 8080 or 8080/udp, is exposed. The protocol defaults to tcp.
+func isPortExposed(exposedPorts map[string]struct{}, port string) bool {
+	if !strings.Contains(port, "/") {
+		port = port + "/tcp"
+	}
+	_, exposed := exposedPorts[port]
+	return exposed
+}
+
+func stringSlicesEqual(actual []string, expected []string) bool {
+	if len(actual) != len(expected) {
+		return false
+	}
+	for i := range actual {
+		if actual[i] != expected[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,164 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const structureTestFixture = "../../test/fixtures/docker-structure-test"
+
+func TestRunStructureTests(t *testing.T) {
+	t.Parallel()
+
+	tag := "gruntwork-io/test-structure-image:" + random.UniqueId()
+	Build(t, structureTestFixture, &BuildOptions{Tags: []string{tag}})
+	defer DeleteImage(t, tag, nil)
+
+	spec := LoadStructureTestSpec(t, structureTestFixture+"/structure-test.yaml")
+	report := AssertStructureTests(t, tag, spec, &StructureTestOptions{})
+	assert.Len(t, report.Results, 7)
+
+	spec.MetadataTest.User = "root"
+	spec.CommandTests[0].ExpectedOutput = []string{"production"}
+	report, err := AssertStructureTestsE(t, tag, spec, &StructureTestOptions{})
+	require.Error(t, err)
+	require.IsType(t, StructureTestsFailed{}, err)
+	failures := report.Failures()
+	require.Len(t, failures, 2)
+	assert.Equal(t, StructureTestKindCommand, failures[0].Kind)
+	assert.Equal(t, StructureTestKindMetadata, failures[1].Kind)
+	assert.Equal(t, []string{`user is "1000", expected "root"`}, failures[1].Errors)
+}
+
+func TestLoadStructureTestSpec(t *testing.T) {
+	t.Parallel()
+
+	spec := LoadStructureTestSpec(t, structureTestFixture+"/structure-test.yaml")
+	require.Len(t, spec.FileExistenceTests, 3)
+	assert.Nil(t, spec.FileExistenceTests[0].ShouldExist)
+	assert.Equal(t, 0, *spec.FileExistenceTests[0].UID)
+	assert.False(t, *spec.FileExistenceTests[2].ShouldExist)
+	require.Len(t, spec.CommandTests, 2)
+	assert.Equal(t, []StructureTestKeyValue{{Key: "EXTRA", Value: "extra"}}, spec.CommandTests[0].EnvVars)
+	assert.Equal(t, 1, spec.CommandTests[1].ExitCode)
+	assert.Equal(t, []string{"cat"}, spec.MetadataTest.Entrypoint)
+	assert.Equal(t, "1000", spec.MetadataTest.User)
+}
+
+func TestFileStructureChecks(t *testing.T) {
+	t.Parallel()
+
+	var archive bytes.Buffer
+	writer := tar.NewWriter(&archive)
+	entries := []struct {
+		header   tar.Header
+		contents string
+	}{
+		{tar.Header{Name: "usr/", Typeflag: tar.TypeDir, Mode: 0755}, ""},
+		{tar.Header{Name: "usr/bin/", Typeflag: tar.TypeDir, Mode: 0755}, ""},
+		{tar.Header{Name: "usr/bin/app", Typeflag: tar.TypeReg, Mode: 0750, Uid: 1000}, "version 1.2.3"},
+		{tar.Header{Name: "bin", Typeflag: tar.TypeSymlink, Linkname: "usr/bin"}, ""},
+		{tar.Header{Name: "usr/bin/app-link", Typeflag: tar.TypeSymlink, Linkname: "./app"}, ""},
+		{tar.Header{Name: "usr/bin/app-hardlink", Typeflag: tar.TypeLink, Linkname: "usr/bin/app"}, ""},
+		{tar.Header{Name: "loop", Typeflag: tar.TypeSymlink, Linkname: "/loop"}, ""},
+	}
+	for _, entry := range entries {
+		entry.header.Size = int64(len(entry.contents))
+		require.NoError(t, writer.WriteHeader(&entry.header))
+		_, err := writer.Write([]byte(entry.contents))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	headers, contents, err := readFilesystemArchive(&archive, map[string]bool{"/usr/bin/app": true})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"/usr/bin/app": []byte("version 1.2.3")}, contents)
+
+	resolved, exists := resolveImagePath(headers, "/bin/app-link", 0)
+	assert.True(t, exists)
+	assert.Equal(t, "/usr/bin/app", resolved)
+	resolved, exists = resolveImagePath(headers, "/usr/bin/app-hardlink", 0)
+	assert.True(t, exists)
+	assert.Equal(t, "/usr/bin/app", resolved)
+	_, exists = resolveImagePath(headers, "/loop", 0)
+	assert.False(t, exists)
+
+	uid := 1000
+	gid := 0
+	result := checkFileExistence(headers, FileExistenceTest{Name: "app", Path: "/bin/app", Permissions: "-rwxr-x---", UID: &uid, GID: &gid})
+	assert.True(t, result.Passed, result.Errors)
+
+	shouldExist := false
+	result = checkFileExistence(headers, FileExistenceTest{Name: "app", Path: "/bin/app", ShouldExist: &shouldExist, Permissions: "-rw-r--r--"})
+	assert.Equal(t, []string{"/bin/app exists"}, result.Errors)
+
+	result = checkFileExistence(headers, FileExistenceTest{Name: "app", Path: "/bin/app", Permissions: "-rw-r--r--"})
+	assert.Equal(t, []string{"/bin/app has permissions -rwxr-x---, expected -rw-r--r--"}, result.Errors)
+
+	result = checkFileContent(headers, contents, FileContentTest{Name: "version", Path: "/bin/app-link", ExpectedContents: []string{`version \d+`}, ExcludedContents: []string{"beta"}})
+	assert.True(t, result.Passed, result.Errors)
+
+	result = checkFileContent(headers, contents, FileContentTest{Name: "dir", Path: "/usr/bin"})
+	assert.Equal(t, []string{"/usr/bin is not a regular file"}, result.Errors)
+
+	result = checkFileContent(headers, contents, FileContentTest{Name: "missing", Path: "/usr/bin/missing"})
+	assert.Equal(t, []string{"/usr/bin/missing does not exist"}, result.Errors)
+}
+
+func TestCheckImageMetadata(t *testing.T) {
+	t.Parallel()
+
+	var inspected imageInspectOutput
+	inspected.Config.Env = []string{"PATH=/usr/bin", "APP_ENV=test"}
+	inspected.Config.Labels = map[string]string{"team": "platform"}
+	inspected.Config.ExposedPorts = map[string]struct{}{"8080/tcp": {}, "53/udp": {}}
+	inspected.Config.Entrypoint = []string{"/app"}
+	inspected.RootFS.Layers = []string{"sha256:a", "sha256:b"}
+
+	errors := checkImageMetadata(inspected, MetadataTest{
+		EnvVars:      []StructureTestKeyValue{{Key: "APP_ENV", Value: "test"}},
+		Labels:       []StructureTestKeyValue{{Key: "team", Value: "platform"}},
+		ExposedPorts: []string{"8080", "53/udp"},
+		Entrypoint:   []string{"/app"},
+		Cmd:          []string{},
+		LayerCount:   2,
+	})
+	assert.Empty(t, errors)
+
+	errors = checkImageMetadata(inspected, MetadataTest{
+		EnvVars:        []StructureTestKeyValue{{Key: "APP_ENV", Value: "prod"}, {Key: "DEBUG", Value: "1"}},
+		UnexposedPorts: []string{"8080"},
+		Volumes:        []string{"/data"},
+		Cmd:            []string{"serve"},
+		User:           "1000",
+		LayerCount:     3,
+	})
+	assert.Equal(t, []string{
+		`environment variable APP_ENV is "test", expected "prod"`,
+		"environment variable DEBUG is not set",
+		"port 8080 is exposed",
+		"volume /data is not declared",
+		`cmd is [], expected ["serve"]`,
+		`user is "", expected "1000"`,
+		"image has 2 layers, expected 3",
+	}, errors)
+}
+
+func TestStructureTestReportString(t *testing.T) {
+	t.Parallel()
+
+	report := StructureTestReport{
+		Image: "app:1",
+		Results: []StructureTestResult{
+			{Kind: StructureTestKindFileExistence, Name: "config", Passed: true},
+			{Kind: StructureTestKindCommand, Name: "version", Errors: []string{"exited with code 1, expected 0"}},
+		},
+	}
+	assert.False(t, report.Passed())
+	assert.Equal(t, "1/2 structure tests passed for image app:1\n  FAIL command \"version\"\n    - exited with code 1, expected 0", report.String())
+}
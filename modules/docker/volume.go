@@ -0,0 +1,173 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	gotesting "testing"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// VolumeOptions defines options that can be passed to the 'docker volume create' command.
+type VolumeOptions struct {
+	// The driver of the volume. Empty => local.
+	Driver string
+
+	// Options of the driver, e.g. type=tmpfs and device=tmpfs for the local driver.
+	DriverOptions map[string]string
+
+	// Labels to set on the volume, along with ManagedLabel and TestLabel.
+	Labels map[string]string
+
+	// If set to true, do not remove the volume when the test completes.
+	KeepVolume bool
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// VolumeInspect defines the output of InspectVolume, with the fields returned by 'docker volume inspect'.
+type VolumeInspect struct {
+	Name       string
+	CreatedAt  time.Time
+	Driver     string
+	Mountpoint string
+	Scope      string
+	Labels     map[string]string
+	Options    map[string]string
+}
+
+// CreateVolume runs the 'docker volume create' command to create a named volume, and returns its name. If name is
+// empty, docker generates one. The volume is removed when the test completes, unless options.KeepVolume is set. Mount
+// it with "NAME:/path" in RunOptions.Volumes. This will fail the test if there is an error.
+func CreateVolume(t *gotesting.T, name string, options *VolumeOptions) string {
+	volume, err := CreateVolumeE(t, name, options)
+	require.NoError(t, err)
+	return volume
+}
+
+// CreateVolumeE runs the 'docker volume create' command to create a named volume, and returns its name. If name is
+// empty, docker generates one. The volume is removed when the test completes, unless options.KeepVolume is set. It is
+// labeled with ManagedLabel, so that SweepVolumes can remove it if the test is interrupted before it completes.
+func CreateVolumeE(t *gotesting.T, name string, options *VolumeOptions) (string, error) {
+	args := []string{"volume", "create"}
+	if options.Driver != "" {
+		args = append(args, "--driver", options.Driver)
+	}
+	for _, key := range sortedKeys(options.DriverOptions) {
+		args = append(args, "--opt", fmt.Sprintf("%s=%s", key, options.DriverOptions[key]))
+	}
+	args = append(args, formatLabelArgs(t, options.Labels)...)
+	if name != "" {
+		args = append(args, name)
+	}
+
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    args,
+		Logger:  options.Logger,
+	}
+	out, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	if err != nil {
+		return "", err
+	}
+	volume := strings.TrimSpace(out)
+	if !options.KeepVolume {
+		t.Cleanup(func() {
+			if err := RemoveVolumeE(t, volume, options.Logger); err != nil {
+				t.Errorf("Failed to remove volume %s: %s", volume, err)
+			}
+		})
+	}
+	return volume, nil
+}
+
+// RemoveVolume runs the 'docker volume rm' command to remove the given volume. This will fail the test if there is an
+// error.
+func RemoveVolume(t testing.TestingT, name string, logger *logger.Logger) {
+	require.NoError(t, RemoveVolumeE(t, name, logger))
+}
+
+// RemoveVolumeE runs the 'docker volume rm' command to remove the given volume. The containers using the volume must
+// be removed first.
+func RemoveVolumeE(t testing.TestingT, name string, logger *logger.Logger) error {
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    []string{"volume", "rm", name},
+		Logger:  logger,
+	}
+	return shell.RunCommandE(t, cmd)
+}
+
+// InspectVolume runs the 'docker volume inspect' command for the given volume, and returns its state. This will fail
+// the test if there is an error.
+func InspectVolume(t testing.TestingT, name string, logger *logger.Logger) *VolumeInspect {
+	volume, err := InspectVolumeE(t, name, logger)
+	require.NoError(t, err)
+	return volume
+}
+
+// InspectVolumeE runs the 'docker volume inspect' command for the given volume, and returns its state.
+func InspectVolumeE(t testing.TestingT, name string, logger *logger.Logger) (*VolumeInspect, error) {
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    []string{"volume", "inspect", name},
+		Logger:  logger,
+	}
+	out, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var volumes []VolumeInspect
+	if err := json.Unmarshal([]byte(out), &volumes); err != nil {
+		return nil, err
+	}
+	if len(volumes) != 1 {
+		return nil, fmt.Errorf("no volume found with name %s", name)
+	}
+	return &volumes[0], nil
+}
+
+// SweepVolumes removes the volumes created by CreateVolume that have all the given labels, e.g. the ones left behind
+// by interrupted test runs. Pass nil labels to remove all of them. This will fail the test if there is an error.
+func SweepVolumes(t testing.TestingT, labels map[string]string, logger *logger.Logger) []string {
+	removed, err := SweepVolumesE(t, labels, logger)
+	require.NoError(t, err)
+	return removed
+}
+
+// SweepVolumesE removes the volumes created by CreateVolume that have all the given labels, e.g. the ones left behind
+// by interrupted test runs, and returns the names of the volumes removed. Pass nil labels to remove all of them.
+// Volumes that are still in use are not removed, and reported in the returned error.
+func SweepVolumesE(t testing.TestingT, labels map[string]string, logger *logger.Logger) ([]string, error) {
+	args := append([]string{"volume", "ls", "--quiet"}, formatLabelFilters(labels)...)
+	cmd := shell.Command{
+		Command: "docker",
+		Args:    args,
+		Logger:  logger,
+	}
+	out, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []string{}
+	var errorsOccurred = new(multierror.Error)
+	for _, name := range strings.Fields(out) {
+		if err := RemoveVolumeE(t, name, logger); err != nil {
+			errorsOccurred = multierror.Append(errorsOccurred, err)
+			continue
+		}
+		removed = append(removed, name)
+	}
+	logger.Logf(t, "Swept %d volumes", len(removed))
+	return removed, errorsOccurred.ErrorOrNil()
+}
@@ -0,0 +1,44 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateVolume(t *testing.T) {
+	t.Parallel()
+
+	runID := random.UniqueId()
+	volume := CreateVolume(t, "", &VolumeOptions{Labels: map[string]string{"run": runID}})
+	assert.NotEmpty(t, volume)
+
+	inspect := InspectVolume(t, volume, nil)
+	assert.Equal(t, volume, inspect.Name)
+	assert.Equal(t, "local", inspect.Driver)
+	assert.Equal(t, "true", inspect.Labels[ManagedLabel])
+	assert.Equal(t, t.Name(), inspect.Labels[TestLabel])
+
+	// Data written by a container is kept for the next one.
+	Run(t, "alpine:3.19", &RunOptions{Command: []string{"sh", "-c", "echo persisted > /data/file"}, Volumes: []string{volume + ":/data"}, Remove: true})
+	out := Run(t, "alpine:3.19", &RunOptions{Command: []string{"cat", "/data/file"}, Volumes: []string{volume + ":/data"}, Remove: true})
+	assert.Equal(t, "persisted", out)
+}
+
+func TestSweepVolumes(t *testing.T) {
+	t.Parallel()
+
+	runID := random.UniqueId()
+	volume := CreateVolume(t, "volume-sweep-test-"+runID, &VolumeOptions{
+		DriverOptions: map[string]string{"type": "tmpfs", "device": "tmpfs"},
+		Labels:        map[string]string{"run": runID},
+		KeepVolume:    true,
+	})
+	assert.Equal(t, map[string]string{"type": "tmpfs", "device": "tmpfs"}, InspectVolume(t, volume, nil).Options)
+
+	removed := SweepVolumes(t, map[string]string{"run": runID}, nil)
+	assert.Equal(t, []string{volume}, removed)
+	_, err := InspectVolumeE(t, volume, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,339 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/collections"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/require"
+)
+
+// The vulnerability scanners supported by ScanImageVulnerabilities.
+const (
+	ScannerTrivy = "trivy"
+	ScannerGrype = "grype"
+)
+
+// Severity is the severity of a vulnerability. Severities are ordered, from SeverityUnknown to SeverityCritical.
+type Severity int
+
+const (
+	SeverityUnknown Severity = iota
+	SeverityNegligible
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+var severityNames = map[Severity]string{
+	SeverityUnknown:    "UNKNOWN",
+	SeverityNegligible: "NEGLIGIBLE",
+	SeverityLow:        "LOW",
+	SeverityMedium:     "MEDIUM",
+	SeverityHigh:       "HIGH",
+	SeverityCritical:   "CRITICAL",
+}
+
+func (severity Severity) String() string {
+	return severityNames[severity]
+}
+
+// ParseSeverity returns the severity with the given name, as reported by trivy or grype, e.g. HIGH or High. Unknown
+// names are SeverityUnknown.
+func ParseSeverity(name string) Severity {
+	for severity, severityName := range severityNames {
+		if strings.EqualFold(name, severityName) {
+			return severity
+		}
+	}
+	return SeverityUnknown
+}
+
+// VulnerabilityScanOptions defines options that can be passed to ScanImageVulnerabilities.
+type VulnerabilityScanOptions struct {
+	// The scanner to use: ScannerTrivy or ScannerGrype. Empty => ScannerTrivy.
+	Scanner string
+
+	// The path to the binary of the scanner. Empty => the scanner from the PATH.
+	Binary string
+
+	// The directory of the vulnerability database of the scanner, e.g. downloaded beforehand with
+	// 'trivy image --download-db-only --cache-dir DIR' or 'GRYPE_DB_CACHE_DIR=DIR grype db update'. The database is not
+	// updated during the scan, so that the scan works offline and its results are reproducible. Empty => the default
+	// directory of the scanner, which must already contain the database.
+	DBDir string
+
+	// The minimum severity that fails AssertNoVulnerabilities, e.g. a pointer to SeverityUnknown to fail on every
+	// vulnerability. nil => SeverityHigh.
+	FailOnSeverity *Severity
+
+	// The IDs of the vulnerabilities to ignore, e.g. CVE-2023-1234 when it does not affect the image.
+	AllowList []string
+
+	// If set to true, ignore the vulnerabilities that have no fix yet.
+	IgnoreUnfixed bool
+
+	// Custom CLI options that will be passed as-is to the scanner.
+	OtherOptions []string
+
+	// Set a logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// Vulnerability is a vulnerability found in a package of an image.
+type Vulnerability struct {
+	ID               string // e.g. CVE-2023-1234
+	Package          string
+	InstalledVersion string
+	FixedVersion     string // Empty if there is no fix yet
+	Severity         Severity
+	Title            string
+	URL              string
+}
+
+func (vulnerability Vulnerability) String() string {
+	fix := "no fix"
+	if vulnerability.FixedVersion != "" {
+		fix = "fixed in " + vulnerability.FixedVersion
+	}
+	return fmt.Sprintf("%s %s in %s %s (%s)", vulnerability.Severity, vulnerability.ID, vulnerability.Package, vulnerability.InstalledVersion, fix)
+}
+
+// VulnerabilityReport is the result of scanning an image for vulnerabilities.
+type VulnerabilityReport struct {
+	Image   string
+	Scanner string
+
+	// The vulnerabilities found, from the most to the least severe, except the ignored ones.
+	Vulnerabilities []Vulnerability
+
+	// The vulnerabilities ignored because of VulnerabilityScanOptions.AllowList or IgnoreUnfixed.
+	Ignored []Vulnerability
+}
+
+// AtOrAbove returns the vulnerabilities found with the given severity or a higher one.
+func (report VulnerabilityReport) AtOrAbove(severity Severity) []Vulnerability {
+	vulnerabilities := []Vulnerability{}
+	for _, vulnerability := range report.Vulnerabilities {
+		if vulnerability.Severity >= severity {
+			vulnerabilities = append(vulnerabilities, vulnerability)
+		}
+	}
+	return vulnerabilities
+}
+
+// CountBySeverity returns the number of vulnerabilities found for each severity.
+func (report VulnerabilityReport) CountBySeverity() map[Severity]int {
+	counts := map[Severity]int{}
+	for _, vulnerability := range report.Vulnerabilities {
+		counts[vulnerability.Severity]++
+	}
+	return counts
+}
+
+// ScanImageVulnerabilities scans the given image for known vulnerabilities with trivy or grype, against a local
+// vulnerability database. This will fail the test if the scan can not be run, but not if vulnerabilities are found: use
+// AssertNoVulnerabilities for that.
+func ScanImageVulnerabilities(t testing.TestingT, image string, options *VulnerabilityScanOptions) *VulnerabilityReport {
+	report, err := ScanImageVulnerabilitiesE(t, image, options)
+	require.NoError(t, err)
+	return report
+}
+
+// ScanImageVulnerabilitiesE scans the given image for known vulnerabilities with trivy or grype, against a local
+// vulnerability database.
+func ScanImageVulnerabilitiesE(t testing.TestingT, image string, options *VulnerabilityScanOptions) (*VulnerabilityReport, error) {
+	scanner := options.Scanner
+	if scanner == "" {
+		scanner = ScannerTrivy
+	}
+	options.Logger.Logf(t, "Scanning image %s for vulnerabilities with %s", image, scanner)
+
+	binary := options.Binary
+	if binary == "" {
+		binary = scanner
+	}
+	cmd := shell.Command{
+		Command: binary,
+		Env:     map[string]string{},
+		// The report is too large to be logged usefully: a summary is logged instead.
+		Logger: logger.Discard,
+	}
+	var parse func(string) ([]Vulnerability, error)
+	switch scanner {
+	case ScannerTrivy:
+		cmd.Args = []string{"image", "--format", "json", "--quiet", "--skip-db-update", "--skip-java-db-update", "--offline-scan"}
+		if options.DBDir != "" {
+			cmd.Args = append(cmd.Args, "--cache-dir", options.DBDir)
+		}
+		parse = parseTrivyJSONE
+	case ScannerGrype:
+		cmd.Args = []string{"--output", "json", "--quiet"}
+		cmd.Env["GRYPE_DB_AUTO_UPDATE"] = "false"
+		cmd.Env["GRYPE_CHECK_FOR_APP_UPDATE"] = "false"
+		if options.DBDir != "" {
+			cmd.Env["GRYPE_DB_CACHE_DIR"] = options.DBDir
+		}
+		parse = parseGrypeJSONE
+		// grype reads images from any source by default: make sure it is the one of the docker daemon.
+		image = "docker:" + image
+	default:
+		return nil, UnsupportedScanner{Scanner: scanner}
+	}
+	cmd.Args = append(cmd.Args, options.OtherOptions...)
+	cmd.Args = append(cmd.Args, image)
+
+	out, err := shell.RunCommandAndGetStdOutE(t, cmd)
+	if err != nil {
+		return nil, err
+	}
+	vulnerabilities, err := parse(out)
+	if err != nil {
+		return nil, err
+	}
+
+	report := filterVulnerabilities(vulnerabilities, options)
+	report.Image = strings.TrimPrefix(image, "docker:")
+	report.Scanner = scanner
+	options.Logger.Logf(t, "Found %d vulnerabilities in image %s (%d ignored): %v", len(report.Vulnerabilities), report.Image, len(report.Ignored), report.CountBySeverity())
+	return report, nil
+}
+
+// AssertNoVulnerabilities scans the given image for known vulnerabilities, and fails the test if any is found with
+// options.FailOnSeverity or a higher severity, e.g. "no HIGH or CRITICAL CVEs".
+func AssertNoVulnerabilities(t testing.TestingT, image string, options *VulnerabilityScanOptions) *VulnerabilityReport {
+	report, err := AssertNoVulnerabilitiesE(t, image, options)
+	require.NoError(t, err)
+	return report
+}
+
+// AssertNoVulnerabilitiesE scans the given image for known vulnerabilities, and returns a VulnerabilitiesFound error,
+// along with the report, if any is found with options.FailOnSeverity or a higher severity.
+func AssertNoVulnerabilitiesE(t testing.TestingT, image string, options *VulnerabilityScanOptions) (*VulnerabilityReport, error) {
+	report, err := ScanImageVulnerabilitiesE(t, image, options)
+	if err != nil {
+		return nil, err
+	}
+	threshold := failOnSeverity(options)
+	if failing := report.AtOrAbove(threshold); len(failing) > 0 {
+		return report, VulnerabilitiesFound{Image: report.Image, Threshold: threshold, Vulnerabilities: failing}
+	}
+	return report, nil
+}
+
+// failOnSeverity returns the minimum severity that fails AssertNoVulnerabilities.
+func failOnSeverity(options *VulnerabilityScanOptions) Severity {
+	if options.FailOnSeverity == nil {
+		return SeverityHigh
+	}
+	return *options.FailOnSeverity
+}
+
+// filterVulnerabilities sorts the vulnerabilities from the most to the least severe, and sets aside the ones ignored by
+// the options. Scanners report a vulnerability once per package it affects, so duplicates are removed.
+func filterVulnerabilities(vulnerabilities []Vulnerability, options *VulnerabilityScanOptions) *VulnerabilityReport {
+	report := &VulnerabilityReport{Vulnerabilities: []Vulnerability{}, Ignored: []Vulnerability{}}
+	seen := map[Vulnerability]bool{}
+	for _, vulnerability := range vulnerabilities {
+		if seen[vulnerability] {
+			continue
+		}
+		seen[vulnerability] = true
+		if collections.ListContains(options.AllowList, vulnerability.ID) || (options.IgnoreUnfixed && vulnerability.FixedVersion == "") {
+			report.Ignored = append(report.Ignored, vulnerability)
+		} else {
+			report.Vulnerabilities = append(report.Vulnerabilities, vulnerability)
+		}
+	}
+	sort.SliceStable(report.Vulnerabilities, func(i, j int) bool {
+		if report.Vulnerabilities[i].Severity != report.Vulnerabilities[j].Severity {
+			return report.Vulnerabilities[i].Severity > report.Vulnerabilities[j].Severity
+		}
+		return report.Vulnerabilities[i].ID < report.Vulnerabilities[j].ID
+	})
+	return report
+}
+
+// trivyJSON is the part of the trivy JSON format used by ScanImageVulnerabilities.
+type trivyJSON struct {
+	Results []struct {
+		Vulnerabilities []struct {
+			VulnerabilityID  string
+			PkgName          string
+			InstalledVersion string
+			FixedVersion     string
+			Severity         string
+			Title            string
+			PrimaryURL       string
+		}
+	}
+}
+
+// parseTrivyJSONE parses the vulnerabilities of a trivy JSON report.
+func parseTrivyJSONE(out string) ([]Vulnerability, error) {
+	var parsed trivyJSON
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		return nil, err
+	}
+	vulnerabilities := []Vulnerability{}
+	for _, result := range parsed.Results {
+		for _, found := range result.Vulnerabilities {
+			vulnerabilities = append(vulnerabilities, Vulnerability{
+				ID:               found.VulnerabilityID,
+				Package:          found.PkgName,
+				InstalledVersion: found.InstalledVersion,
+				FixedVersion:     found.FixedVersion,
+				Severity:         ParseSeverity(found.Severity),
+				Title:            found.Title,
+				URL:              found.PrimaryURL,
+			})
+		}
+	}
+	return vulnerabilities, nil
+}
+
+// grypeJSON is the part of the grype JSON format used by ScanImageVulnerabilities.
+type grypeJSON struct {
+	Matches []struct {
+		Vulnerability struct {
+			ID          string
+			Severity    string
+			Description string
+			DataSource  string
+			Fix         struct {
+				Versions []string
+			}
+		}
+		Artifact struct {
+			Name    string
+			Version string
+		}
+	}
+}
+
+// parseGrypeJSONE parses the vulnerabilities of a grype JSON report.
+func parseGrypeJSONE(out string) ([]Vulnerability, error) {
+	var parsed grypeJSON
+	if err := json.Unmarshal([]byte(out), &parsed); err != nil {
+		return nil, err
+	}
+	vulnerabilities := []Vulnerability{}
+	for _, match := range parsed.Matches {
+		vulnerabilities = append(vulnerabilities, Vulnerability{
+			ID:               match.Vulnerability.ID,
+			Package:          match.Artifact.Name,
+			InstalledVersion: match.Artifact.Version,
+			FixedVersion:     strings.Join(match.Vulnerability.Fix.Versions, ", "),
+			Severity:         ParseSeverity(match.Vulnerability.Severity),
+			Title:            match.Vulnerability.Description,
+			URL:              match.Vulnerability.DataSource,
+		})
+	}
+	return vulnerabilities, nil
+}
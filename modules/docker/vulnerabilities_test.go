@@ -0,0 +1,135 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const trivyReportFixture = `{
+  "SchemaVersion": 2,
+  "ArtifactName": "app:1",
+  "Results": [
+    {
+      "Target": "app:1 (alpine 3.19.1)",
+      "Vulnerabilities": [
+        {"VulnerabilityID": "CVE-2024-0001", "PkgName": "openssl", "InstalledVersion": "3.1.4-r1", "FixedVersion": "3.1.4-r5", "Severity": "HIGH", "Title": "openssl: bad things", "PrimaryURL": "https://avd.aquasec.com/nvd/cve-2024-0001"},
+        {"VulnerabilityID": "CVE-2024-0002", "PkgName": "busybox", "InstalledVersion": "1.36.1-r15", "Severity": "LOW"}
+      ]
+    },
+    {
+      "Target": "app/go.sum",
+      "Vulnerabilities": [
+        {"VulnerabilityID": "CVE-2024-0003", "PkgName": "golang.org/x/net", "InstalledVersion": "v0.17.0", "FixedVersion": "0.23.0", "Severity": "CRITICAL"}
+      ]
+    },
+    {"Target": "app/package-lock.json"}
+  ]
+}`
+
+const grypeReportFixture = `{
+  "matches": [
+    {
+      "vulnerability": {"id": "CVE-2024-0001", "severity": "High", "description": "bad things", "dataSource": "https://nvd.nist.gov/vuln/detail/CVE-2024-0001", "fix": {"versions": ["3.1.4-r5"], "state": "fixed"}},
+      "artifact": {"name": "openssl", "version": "3.1.4-r1", "type": "apk"}
+    },
+    {
+      "vulnerability": {"id": "CVE-2024-0004", "severity": "Negligible", "fix": {"versions": [], "state": "not-fixed"}},
+      "artifact": {"name": "zlib", "version": "1.3-r2", "type": "apk"}
+    }
+  ]
+}`
+
+func TestParseTrivyJSON(t *testing.T) {
+	t.Parallel()
+
+	vulnerabilities, err := parseTrivyJSONE(trivyReportFixture)
+	require.NoError(t, err)
+	require.Len(t, vulnerabilities, 3)
+	assert.Equal(t, Vulnerability{
+		ID:               "CVE-2024-0001",
+		Package:          "openssl",
+		InstalledVersion: "3.1.4-r1",
+		FixedVersion:     "3.1.4-r5",
+		Severity:         SeverityHigh,
+		Title:            "openssl: bad things",
+		URL:              "https://avd.aquasec.com/nvd/cve-2024-0001",
+	}, vulnerabilities[0])
+	assert.Equal(t, SeverityCritical, vulnerabilities[2].Severity)
+}
+
+func TestParseGrypeJSON(t *testing.T) {
+	t.Parallel()
+
+	vulnerabilities, err := parseGrypeJSONE(grypeReportFixture)
+	require.NoError(t, err)
+	assert.Equal(t, []Vulnerability{
+		{
+			ID:               "CVE-2024-0001",
+			Package:          "openssl",
+			InstalledVersion: "3.1.4-r1",
+			FixedVersion:     "3.1.4-r5",
+			Severity:         SeverityHigh,
+			Title:            "bad things",
+			URL:              "https://nvd.nist.gov/vuln/detail/CVE-2024-0001",
+		},
+		{
+			ID:               "CVE-2024-0004",
+			Package:          "zlib",
+			InstalledVersion: "1.3-r2",
+			Severity:         SeverityNegligible,
+		},
+	}, vulnerabilities)
+}
+
+func TestFilterVulnerabilities(t *testing.T) {
+	t.Parallel()
+
+	vulnerabilities, err := parseTrivyJSONE(trivyReportFixture)
+	require.NoError(t, err)
+	// Scanners may report the same vulnerability of the same package several times.
+	vulnerabilities = append(vulnerabilities, vulnerabilities[0])
+
+	report := filterVulnerabilities(vulnerabilities, &VulnerabilityScanOptions{})
+	require.Len(t, report.Vulnerabilities, 3)
+	assert.Equal(t, "CVE-2024-0003", report.Vulnerabilities[0].ID)
+	assert.Equal(t, "CVE-2024-0001", report.Vulnerabilities[1].ID)
+	assert.Equal(t, "CVE-2024-0002", report.Vulnerabilities[2].ID)
+	assert.Len(t, report.AtOrAbove(SeverityHigh), 2)
+	assert.Len(t, report.AtOrAbove(SeverityCritical), 1)
+	assert.Equal(t, map[Severity]int{SeverityCritical: 1, SeverityHigh: 1, SeverityLow: 1}, report.CountBySeverity())
+
+	report = filterVulnerabilities(vulnerabilities, &VulnerabilityScanOptions{AllowList: []string{"CVE-2024-0003"}, IgnoreUnfixed: true})
+	require.Len(t, report.Vulnerabilities, 1)
+	assert.Equal(t, "CVE-2024-0001", report.Vulnerabilities[0].ID)
+	require.Len(t, report.Ignored, 2)
+	assert.Empty(t, report.AtOrAbove(SeverityCritical))
+}
+
+func TestSeverity(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, SeverityCritical, ParseSeverity("Critical"))
+	assert.Equal(t, SeverityHigh, ParseSeverity("HIGH"))
+	assert.Equal(t, SeverityNegligible, ParseSeverity("negligible"))
+	assert.Equal(t, SeverityUnknown, ParseSeverity("whatever"))
+	assert.Equal(t, "MEDIUM", SeverityMedium.String())
+	assert.True(t, SeverityCritical > SeverityHigh)
+
+	assert.Equal(t, SeverityHigh, failOnSeverity(&VulnerabilityScanOptions{}))
+	unknown := SeverityUnknown
+	assert.Equal(t, SeverityUnknown, failOnSeverity(&VulnerabilityScanOptions{FailOnSeverity: &unknown}))
+
+	err := VulnerabilitiesFound{
+		Image:     "app:1",
+		Threshold: SeverityHigh,
+		Vulnerabilities: []Vulnerability{
+			{ID: "CVE-2024-0001", Package: "openssl", InstalledVersion: "3.1.4-r1", FixedVersion: "3.1.4-r5", Severity: SeverityHigh},
+			{ID: "CVE-2024-0003", Package: "zlib", InstalledVersion: "1.3-r2", Severity: SeverityCritical},
+		},
+	}
+	assert.Equal(t, "found 2 vulnerabilities with severity HIGH or higher in image app:1:\n"+
+		"  - HIGH CVE-2024-0001 in openssl 3.1.4-r1 (fixed in 3.1.4-r5)\n"+
+		"  - CRITICAL CVE-2024-0003 in zlib 1.3-r2 (no fix)", err.Error())
+}
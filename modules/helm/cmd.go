@@ -31,6 +31,19 @@ func getNamespaceArgs(options *Options) []string {
 	return []string{}
 }
 
+// getCapabilitiesArgs returns the args to set the kubernetes version and api versions available to the templates when
+// rendering them.
+func getCapabilitiesArgs(options *Options) []string {
+	args := []string{}
+	if options.KubeVersion != "" {
+		args = append(args, "--kube-version", options.KubeVersion)
+	}
+	for _, apiVersion := range options.APIVersions {
+		args = append(args, "--api-versions", apiVersion)
+	}
+	return args
+}
+
 // getValuesArgsE computes the args to pass in for setting values
 func getValuesArgsE(t testing.TestingT, options *Options, args ...string) ([]string, error) {
 	args = append(args, formatSetValuesAsArgs(options.SetValues, "--set")...)
@@ -2,6 +2,11 @@ package helm
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/gruntwork-io/go-commons/collections"
+
+	"github.com/gruntwork-io/terratest/modules/k8s"
 )
 
 // ValuesFileNotFoundError is returned when a provided values file input is not found on the host path.
@@ -40,3 +45,114 @@ type ChartNotFoundError struct {
 func (err ChartNotFoundError) Error() string {
 	return fmt.Sprintf("Could not chart path %s", err.Path)
 }
+
+// SnapshotMismatch is returned when the rendered objects of a release don't match their snapshots.
+type SnapshotMismatch struct {
+	ReleaseName string
+	Report      *SnapshotReport
+}
+
+func (err SnapshotMismatch) Error() string {
+	return fmt.Sprintf("Snapshot mismatch for release %s: %s", err.ReleaseName, err.Report)
+}
+
+// DuplicateSnapshotObject is returned when two rendered objects would be stored in the same snapshot file.
+type DuplicateSnapshotObject struct {
+	Name string
+}
+
+func (err DuplicateSnapshotObject) Error() string {
+	return fmt.Sprintf("More than one rendered object maps to the snapshot %s", err.Name)
+}
+
+// RenderedObjectNotFound is returned when no rendered object matches a filter. See k8s.RenderedObjectNotFound.
+type RenderedObjectNotFound = k8s.RenderedObjectNotFound
+
+// MultipleRenderedObjectsFound is returned when more than one rendered object matches a filter that should select a
+// single object. See k8s.MultipleRenderedObjectsFound.
+type MultipleRenderedObjectsFound = k8s.MultipleRenderedObjectsFound
+
+// ChartLintFailed is returned when linting a chart reports errors.
+type ChartLintFailed struct {
+	Results []LintResult
+}
+
+func (err ChartLintFailed) Error() string {
+	lines := []string{}
+	for _, result := range err.Results {
+		for _, finding := range result.Errors() {
+			lines = append(lines, fmt.Sprintf("%s (values %q): %s", result.ChartDir, result.ValuesFile, finding))
+		}
+	}
+	return fmt.Sprintf("Chart lint failed:\n%s", strings.Join(lines, "\n"))
+}
+
+// ValuesSchemaValidationFailed is returned when some values files don't match the values schema of a chart.
+type ValuesSchemaValidationFailed struct {
+	ChartDir string
+	Results  []ValuesSchemaResult
+}
+
+func (err ValuesSchemaValidationFailed) Error() string {
+	lines := []string{}
+	for _, result := range err.Results {
+		for _, violation := range result.Errors {
+			lines = append(lines, fmt.Sprintf("%s: %s", result.ValuesFile, violation))
+		}
+	}
+	return fmt.Sprintf("Values do not match the schema of chart %s:\n%s", err.ChartDir, strings.Join(lines, "\n"))
+}
+
+// HelmTestsFailed is returned when some `helm test` hooks of a release fail. The error message includes the logs of
+// the failed hooks.
+type HelmTestsFailed struct {
+	ReleaseName string
+	Results     []HelmTestResult
+}
+
+func (err HelmTestsFailed) Error() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "helm test failed for release %s:", err.ReleaseName)
+	for _, result := range err.Results {
+		if result.Passed {
+			continue
+		}
+		fmt.Fprintf(&builder, "\n%s %s: %s", result.Kind, result.Name, result.Phase)
+		if result.LogsError != nil {
+			fmt.Fprintf(&builder, " (logs unavailable: %s)", result.LogsError)
+		}
+		for _, key := range collections.Keys(result.Logs) {
+			fmt.Fprintf(&builder, "\n--- logs of %s ---\n%s", key, strings.TrimRight(result.Logs[key], "\n"))
+		}
+	}
+	return builder.String()
+}
+
+// TestHookPodsNotFound is returned when the pods of a test hook can not be found, typically because they were removed
+// by a hook-delete-policy.
+type TestHookPodsNotFound struct {
+	HookName string
+}
+
+func (err TestHookPodsNotFound) Error() string {
+	return fmt.Sprintf("No pods found for test hook %s", err.HookName)
+}
+
+// InvalidOCIReference is returned when a reference to an OCI registry does not start with oci://.
+type InvalidOCIReference struct {
+	Reference string
+}
+
+func (err InvalidOCIReference) Error() string {
+	return fmt.Sprintf("%s is not an OCI reference: it must start with %s", err.Reference, ociReferencePrefix)
+}
+
+// UnexpectedHelmOutput is returned when the output of a helm command can not be parsed.
+type UnexpectedHelmOutput struct {
+	Command string
+	Output  string
+}
+
+func (err UnexpectedHelmOutput) Error() string {
+	return fmt.Sprintf("Could not parse the output of helm %s:\n%s", err.Command, err.Output)
+}
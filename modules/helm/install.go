@@ -16,10 +16,12 @@ func Install(t testing.TestingT, options *Options, chart string, releaseName str
 	require.NoError(t, InstallE(t, options, chart, releaseName))
 }
 
-// InstallE will install the selected helm chart with the provided options under the given release name.
+// InstallE will install the selected helm chart with the provided options under the given release name. The chart can
+// be a local path, a chart of a repository (REPO/CHART) or a chart in an OCI registry (oci://HOST/PATH/CHART). Use
+// Options.Version to select the version of remote charts.
 func InstallE(t testing.TestingT, options *Options, chart string, releaseName string) error {
 	// If the chart refers to a path, convert to absolute path. Otherwise, pass straight through as it may be a remote
-	// chart.
+	// chart, such as REPO/CHART or an oci:// reference.
 	if files.FileExists(chart) {
 		absChartDir, err := filepath.Abs(chart)
 		if err != nil {
@@ -0,0 +1,219 @@
+package helm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/lint/support"
+
+	"github.com/gruntwork-io/terratest/modules/files"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// LintSeverity is the severity of a lint finding.
+type LintSeverity string
+
+const (
+	LintSeverityUnknown LintSeverity = "UNKNOWN"
+	LintSeverityInfo    LintSeverity = "INFO"
+	LintSeverityWarning LintSeverity = "WARNING"
+	LintSeverityError   LintSeverity = "ERROR"
+)
+
+// LintFinding is a single message reported by `helm lint`.
+type LintFinding struct {
+	Severity LintSeverity
+	Path     string // The file of the chart the finding is about, e.g. templates/ or values.yaml.
+	Message  string
+}
+
+// String returns the finding in the same format as `helm lint`.
+func (finding LintFinding) String() string {
+	return fmt.Sprintf("[%s] %s: %s", finding.Severity, finding.Path, finding.Message)
+}
+
+// LintResult holds the findings of linting a chart with a single values file.
+type LintResult struct {
+	ChartDir   string
+	ValuesFile string // The values file used for linting, or empty if the chart was linted with its default values.
+	Findings   []LintFinding
+}
+
+// Errors returns the findings with an ERROR severity.
+func (result LintResult) Errors() []LintFinding {
+	return result.withSeverity(LintSeverityError)
+}
+
+// Warnings returns the findings with a WARNING severity.
+func (result LintResult) Warnings() []LintFinding {
+	return result.withSeverity(LintSeverityWarning)
+}
+
+func (result LintResult) withSeverity(severity LintSeverity) []LintFinding {
+	findings := []LintFinding{}
+	for _, finding := range result.Findings {
+		if finding.Severity == severity {
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// Lint runs `helm lint` on each of the given charts, once for every values file in Options.ValuesFiles (or once with
+// the default values of the chart if there are none), and returns the findings. The set values of the options are
+// applied on top of each values file. This will fail the test if any chart can not be linted or if there is any
+// finding with an ERROR severity.
+func Lint(t testing.TestingT, options *Options, chartDirs ...string) []LintResult {
+	results, err := LintE(t, options, chartDirs...)
+	require.NoError(t, err)
+	return results
+}
+
+// LintE runs `helm lint` on each of the given charts, once for every values file in Options.ValuesFiles (or once with
+// the default values of the chart if there are none), and returns the findings. The set values of the options are
+// applied on top of each values file. Linting is done in-process with the Helm Go SDK, so it does not require helm to
+// be installed. Along with the results, this returns a ChartLintFailed error if there is any finding with an ERROR
+// severity.
+func LintE(t testing.TestingT, options *Options, chartDirs ...string) ([]LintResult, error) {
+	settings := cli.New()
+	valuesFiles := options.ValuesFiles
+	if len(valuesFiles) == 0 {
+		valuesFiles = []string{""}
+	}
+
+	results := []LintResult{}
+	for _, chartDir := range chartDirs {
+		if !files.FileExists(chartDir) {
+			return results, errors.WithStackTrace(ChartNotFoundError{chartDir})
+		}
+		for _, valuesFile := range valuesFiles {
+			valuesOptions := *options
+			valuesOptions.ValuesFiles = []string{}
+			if valuesFile != "" {
+				valuesOptions.ValuesFiles = []string{valuesFile}
+			}
+			vals, err := mergeValuesInProcessE(t, &valuesOptions, settings)
+			if err != nil {
+				return results, err
+			}
+
+			lint := action.NewLint()
+			lint.Namespace = "default"
+			if options.KubectlOptions != nil && options.KubectlOptions.Namespace != "" {
+				lint.Namespace = options.KubectlOptions.Namespace
+			}
+			lintResult := lint.Run([]string{chartDir}, vals)
+			if lintResult.TotalChartsLinted == 0 && len(lintResult.Errors) > 0 {
+				return results, errors.WithStackTrace(lintResult.Errors[0])
+			}
+
+			result := LintResult{ChartDir: chartDir, ValuesFile: valuesFile, Findings: []LintFinding{}}
+			for _, message := range lintResult.Messages {
+				result.Findings = append(result.Findings, LintFinding{
+					Severity: lintSeverity(message.Severity),
+					Path:     message.Path,
+					Message:  message.Err.Error(),
+				})
+			}
+			getLogger(options).Logf(t, "Linted chart %s with values %q: %d findings", chartDir, valuesFile, len(result.Findings))
+			results = append(results, result)
+		}
+	}
+
+	for _, result := range results {
+		if len(result.Errors()) > 0 {
+			return results, ChartLintFailed{Results: results}
+		}
+	}
+	return results, nil
+}
+
+// lintSeverity converts the severity of a helm lint message.
+func lintSeverity(severity int) LintSeverity {
+	switch severity {
+	case support.InfoSev:
+		return LintSeverityInfo
+	case support.WarningSev:
+		return LintSeverityWarning
+	case support.ErrorSev:
+		return LintSeverityError
+	default:
+		return LintSeverityUnknown
+	}
+}
+
+// ValuesSchemaResult is the result of validating a values file against the values.schema.json of a chart.
+type ValuesSchemaResult struct {
+	ValuesFile string
+	Errors     []string // The schema violations, empty if the values are valid.
+}
+
+// Valid returns true if the values file matches the schema.
+func (result ValuesSchemaResult) Valid() bool {
+	return len(result.Errors) == 0
+}
+
+// ValidateValuesAgainstSchema validates each values file, merged with the default values of the chart, against the
+// values.schema.json of the chart and of its enabled subcharts. This will fail the test if any values file is invalid.
+func ValidateValuesAgainstSchema(t testing.TestingT, chartDir string, valuesFiles ...string) []ValuesSchemaResult {
+	results, err := ValidateValuesAgainstSchemaE(t, chartDir, valuesFiles...)
+	require.NoError(t, err)
+	return results
+}
+
+// ValidateValuesAgainstSchemaE validates each values file, merged with the default values of the chart, against the
+// values.schema.json of the chart and of its enabled subcharts, the same way `helm install` does. Along with the
+// result for every values file, this returns a ValuesSchemaValidationFailed error if any values file is invalid, which
+// makes it easy to check that known bad values files are rejected.
+func ValidateValuesAgainstSchemaE(t testing.TestingT, chartDir string, valuesFiles ...string) ([]ValuesSchemaResult, error) {
+	if !files.FileExists(chartDir) {
+		return nil, errors.WithStackTrace(ChartNotFoundError{chartDir})
+	}
+
+	results := []ValuesSchemaResult{}
+	for _, valuesFile := range valuesFiles {
+		if !files.FileExists(valuesFile) {
+			return results, errors.WithStackTrace(ValuesFileNotFoundError{valuesFile})
+		}
+		// The chart is loaded for each values file, since processing the dependencies removes disabled subcharts.
+		loadedChart, err := loader.Load(chartDir)
+		if err != nil {
+			return results, errors.WithStackTrace(err)
+		}
+		vals, err := chartutil.ReadValuesFile(valuesFile)
+		if err != nil {
+			return results, errors.WithStackTrace(err)
+		}
+		if err := chartutil.ProcessDependenciesWithMerge(loadedChart, vals); err != nil {
+			return results, errors.WithStackTrace(err)
+		}
+		merged, err := chartutil.CoalesceValues(loadedChart, vals)
+		if err != nil {
+			return results, errors.WithStackTrace(err)
+		}
+
+		result := ValuesSchemaResult{ValuesFile: valuesFile, Errors: []string{}}
+		if err := chartutil.ValidateAgainstSchema(loadedChart, merged); err != nil {
+			for _, line := range strings.Split(err.Error(), "\n") {
+				line = strings.TrimSpace(line)
+				if line != "" {
+					result.Errors = append(result.Errors, line)
+				}
+			}
+		}
+		results = append(results, result)
+	}
+
+	for _, result := range results {
+		if !result.Valid() {
+			return results, ValuesSchemaValidationFailed{ChartDir: chartDir, Results: results}
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,101 @@
+package helm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+)
+
+const lintTestSchema = `{
+  "$schema": "https://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["replicas"],
+  "properties": {
+    "replicas": {"type": "integer", "minimum": 1},
+    "image": {"type": "string"}
+  }
+}
+`
+
+// writeLintTestFile writes the given content to a file in dir and returns its path.
+func writeLintTestFile(t *testing.T, dir string, name string, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLintBasicExample(t *testing.T) {
+	t.Parallel()
+
+	valuesDir := t.TempDir()
+	validValues := writeLintTestFile(t, valuesDir, "valid.yaml", "containerImageRepo: nginx\ncontainerImageTag: 1.15.8\n")
+	missingValues := writeLintTestFile(t, valuesDir, "missing.yaml", "containerImageRepo: nginx\n")
+
+	// helm lint does not report missing required values as errors.
+	options := &Options{ValuesFiles: []string{validValues, missingValues}, Logger: logger.Discard}
+	results := Lint(t, options, "../../examples/helm-basic-example")
+	require.Len(t, results, 2)
+	assert.Equal(t, validValues, results[0].ValuesFile)
+	assert.Empty(t, results[0].Errors())
+	assert.Empty(t, results[1].Errors())
+}
+
+func TestLintReportsErrorsPerValuesFile(t *testing.T) {
+	t.Parallel()
+
+	chartDir := t.TempDir()
+	writeLintTestFile(t, chartDir, "Chart.yaml", "apiVersion: v2\nname: lint\nversion: 0.1.0\n")
+	writeLintTestFile(t, chartDir, "values.yaml", "name: lint\n")
+	writeLintTestFile(t, chartDir, "templates/configmap.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Values.name }}\n")
+
+	valuesDir := t.TempDir()
+	validValues := writeLintTestFile(t, valuesDir, "valid.yaml", "name: valid\n")
+	invalidValues := writeLintTestFile(t, valuesDir, "invalid.yaml", "name: '[unclosed'\n")
+
+	options := &Options{ValuesFiles: []string{validValues, invalidValues}, Logger: logger.Discard}
+	results, err := LintE(t, options, chartDir)
+	require.Error(t, err)
+	assert.IsType(t, ChartLintFailed{}, err)
+	assert.Contains(t, err.Error(), "invalid.yaml")
+	require.Len(t, results, 2)
+	assert.Empty(t, results[0].Errors())
+	require.Len(t, results[1].Errors(), 1)
+	assert.Equal(t, "templates/configmap.yaml", results[1].Errors()[0].Path)
+}
+
+func TestValidateValuesAgainstSchema(t *testing.T) {
+	t.Parallel()
+
+	chartDir := t.TempDir()
+	writeLintTestFile(t, chartDir, "Chart.yaml", "apiVersion: v2\nname: schema\nversion: 0.1.0\n")
+	writeLintTestFile(t, chartDir, "values.yaml", "image: nginx\n")
+	writeLintTestFile(t, chartDir, "values.schema.json", lintTestSchema)
+	writeLintTestFile(t, chartDir, "templates/configmap.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: schema\n")
+
+	valuesDir := t.TempDir()
+	valid := writeLintTestFile(t, valuesDir, "valid.yaml", "replicas: 2\n")
+	missing := writeLintTestFile(t, valuesDir, "missing.yaml", "image: busybox\n")
+	wrongType := writeLintTestFile(t, valuesDir, "wrong-type.yaml", "replicas: two\n")
+
+	results := ValidateValuesAgainstSchema(t, chartDir, valid)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Valid())
+
+	results, err := ValidateValuesAgainstSchemaE(t, chartDir, valid, missing, wrongType)
+	require.Error(t, err)
+	assert.IsType(t, ValuesSchemaValidationFailed{}, err)
+	require.Len(t, results, 3)
+	assert.True(t, results[0].Valid())
+	assert.False(t, results[1].Valid())
+	assert.Contains(t, results[1].Errors[len(results[1].Errors)-1], "replicas")
+	assert.False(t, results[2].Valid())
+
+	_, err = ValidateValuesAgainstSchemaE(t, chartDir, filepath.Join(valuesDir, "nonexistent.yaml"))
+	require.Error(t, err)
+}
@@ -0,0 +1,201 @@
+package helm
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/files"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// ociReferencePrefix is the scheme of references to charts stored in OCI registries.
+const ociReferencePrefix = "oci://"
+
+var (
+	packagedChartRegex = regexp.MustCompile(`Successfully packaged chart and saved it to: (.+)`)
+	pushedChartRegex   = regexp.MustCompile(`Pushed: (\S+):(\S+)`)
+	pushedDigestRegex  = regexp.MustCompile(`Digest: (\S+)`)
+)
+
+// PushedChart is a chart pushed to an OCI registry.
+type PushedChart struct {
+	Ref     string // The reference of the chart, without the version, e.g. oci://localhost:5000/charts/mychart
+	Version string
+	Digest  string // The digest of the chart manifest, e.g. sha256:...
+}
+
+// RegistryLogin runs `helm registry login` to authenticate to the given OCI registry host (e.g. localhost:5000). Use
+// Options.ExtraArgs["registryLogin"] to pass extra arguments, such as --insecure or --ca-file. This will fail the test
+// if there is an error.
+func RegistryLogin(t testing.TestingT, options *Options, host string, username string, password string) {
+	require.NoError(t, RegistryLoginE(t, options, host, username, password))
+}
+
+// RegistryLoginE runs `helm registry login` to authenticate to the given OCI registry host (e.g. localhost:5000). Use
+// Options.ExtraArgs["registryLogin"] to pass extra arguments, such as --insecure or --ca-file. The password is passed
+// on stdin with --password-stdin, so that it does not show up in the process list or in the test output. Set
+// HELM_REGISTRY_CONFIG in Options.EnvVars to store the credentials outside of the helm configuration of the user.
+func RegistryLoginE(t testing.TestingT, options *Options, host string, username string, password string) error {
+	getLogger(options).Logf(t, "Logging in to registry %s as %s", host, username)
+
+	args := []string{"login", host, "--username", username, "--password-stdin"}
+	args = append(args, options.ExtraArgs["registryLogin"]...)
+	helmCmd := prepareHelmCommand(t, options, "registry", args...)
+	getLogger(options).Logf(t, "Running command %s with args %s", helmCmd.Command, helmCmd.Args)
+
+	// shell.Command always reads stdin from the test process, so run the command directly to pass the password.
+	cmd := exec.Command(helmCmd.Command, helmCmd.Args...)
+	cmd.Dir = helmCmd.WorkingDir
+	cmd.Env = os.Environ()
+	for key, value := range helmCmd.Env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
+	}
+	cmd.Stdin = strings.NewReader(password)
+	output, err := cmd.CombinedOutput()
+	getLogger(options).Logf(t, "%s", strings.TrimSpace(string(output)))
+	if err != nil {
+		return errors.WithStackTrace(fmt.Errorf("helm registry login to %s failed: %w", host, err))
+	}
+	return nil
+}
+
+// RegistryLogout runs `helm registry logout` to remove the credentials of the given OCI registry host. This will fail
+// the test if there is an error.
+func RegistryLogout(t testing.TestingT, options *Options, host string) {
+	require.NoError(t, RegistryLogoutE(t, options, host))
+}
+
+// RegistryLogoutE runs `helm registry logout` to remove the credentials of the given OCI registry host.
+func RegistryLogoutE(t testing.TestingT, options *Options, host string) error {
+	_, err := RunHelmCommandAndGetOutputE(t, options, "registry", "logout", host)
+	return err
+}
+
+// PackageChart runs `helm package` to package the given chart into an archive in destinationDir, and returns the path
+// to the archive. Options.Version overrides the version of the chart, and dependencies are updated first if
+// Options.BuildDependencies is set. Use Options.ExtraArgs["package"] to pass extra arguments, such as --app-version.
+// This will fail the test if there is an error.
+func PackageChart(t testing.TestingT, options *Options, chartDir string, destinationDir string) string {
+	chartPackage, err := PackageChartE(t, options, chartDir, destinationDir)
+	require.NoError(t, err)
+	return chartPackage
+}
+
+// PackageChartE runs `helm package` to package the given chart into an archive in destinationDir, and returns the path
+// to the archive. Options.Version overrides the version of the chart, and dependencies are updated first if
+// Options.BuildDependencies is set. Use Options.ExtraArgs["package"] to pass extra arguments, such as --app-version.
+func PackageChartE(t testing.TestingT, options *Options, chartDir string, destinationDir string) (string, error) {
+	if !files.FileExists(chartDir) {
+		return "", errors.WithStackTrace(ChartNotFoundError{chartDir})
+	}
+	absChartDir, err := filepath.Abs(chartDir)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	args := []string{absChartDir, "--destination", destinationDir}
+	if options.Version != "" {
+		args = append(args, "--version", options.Version)
+	}
+	if options.BuildDependencies {
+		args = append(args, "--dependency-update")
+	}
+	args = append(args, options.ExtraArgs["package"]...)
+	output, err := RunHelmCommandAndGetStdOutE(t, options, "package", args...)
+	if err != nil {
+		return "", err
+	}
+	match := packagedChartRegex.FindStringSubmatch(output)
+	if match == nil {
+		return "", errors.WithStackTrace(UnexpectedHelmOutput{Command: "package", Output: output})
+	}
+	return strings.TrimSpace(match[1]), nil
+}
+
+// PushChart runs `helm push` to push the given chart archive to an OCI registry, e.g. oci://localhost:5000/charts,
+// and returns the reference of the pushed chart. Use Options.ExtraArgs["push"] to pass extra arguments, such as
+// --plain-http or --insecure-skip-tls-verify. This will fail the test if there is an error.
+func PushChart(t testing.TestingT, options *Options, chartPackage string, registryURL string) *PushedChart {
+	pushed, err := PushChartE(t, options, chartPackage, registryURL)
+	require.NoError(t, err)
+	return pushed
+}
+
+// PushChartE runs `helm push` to push the given chart archive to an OCI registry, e.g. oci://localhost:5000/charts,
+// and returns the reference of the pushed chart. Use Options.ExtraArgs["push"] to pass extra arguments, such as
+// --plain-http or --insecure-skip-tls-verify. The returned reference can be passed to Install, Upgrade or
+// RenderOCITemplate, along with the version in Options.Version.
+func PushChartE(t testing.TestingT, options *Options, chartPackage string, registryURL string) (*PushedChart, error) {
+	if !strings.HasPrefix(registryURL, ociReferencePrefix) {
+		return nil, errors.WithStackTrace(InvalidOCIReference{registryURL})
+	}
+	if !files.FileExists(chartPackage) {
+		return nil, errors.WithStackTrace(ChartNotFoundError{chartPackage})
+	}
+
+	args := append([]string{chartPackage, registryURL}, options.ExtraArgs["push"]...)
+	// helm reports the pushed reference on stderr.
+	output, err := RunHelmCommandAndGetOutputE(t, options, "push", args...)
+	if err != nil {
+		return nil, err
+	}
+	return parsePushOutputE(output)
+}
+
+// parsePushOutputE extracts the reference and digest of the pushed chart from the output of `helm push`.
+func parsePushOutputE(output string) (*PushedChart, error) {
+	refMatch := pushedChartRegex.FindStringSubmatch(output)
+	digestMatch := pushedDigestRegex.FindStringSubmatch(output)
+	if refMatch == nil || digestMatch == nil {
+		return nil, errors.WithStackTrace(UnexpectedHelmOutput{Command: "push", Output: output})
+	}
+	return &PushedChart{
+		Ref:     ociReferencePrefix + refMatch[1],
+		Version: refMatch[2],
+		Digest:  digestMatch[1],
+	}, nil
+}
+
+// RenderOCITemplate runs `helm template` to render a chart stored in an OCI registry, e.g.
+// oci://localhost:5000/charts/mychart, at the version in Options.Version (or the latest version if empty), and
+// returns stdout. If you pass in templateFiles, this will only render those templates. This function will fail the
+// test if there is an error rendering the template.
+func RenderOCITemplate(t testing.TestingT, options *Options, chartRef string, releaseName string, templateFiles []string, extraHelmArgs ...string) string {
+	out, err := RenderOCITemplateE(t, options, chartRef, releaseName, templateFiles, extraHelmArgs...)
+	require.NoError(t, err)
+	return out
+}
+
+// RenderOCITemplateE runs `helm template` to render a chart stored in an OCI registry, e.g.
+// oci://localhost:5000/charts/mychart, at the version in Options.Version (or the latest version if empty), and
+// returns stdout. If you pass in templateFiles, this will only render those templates. Pass --plain-http in
+// extraHelmArgs for registries that are not served over TLS.
+func RenderOCITemplateE(t testing.TestingT, options *Options, chartRef string, releaseName string, templateFiles []string, extraHelmArgs ...string) (string, error) {
+	if !strings.HasPrefix(chartRef, ociReferencePrefix) {
+		return "", errors.WithStackTrace(InvalidOCIReference{chartRef})
+	}
+
+	args := []string{}
+	args, err := getValuesArgsE(t, options, args...)
+	if err != nil {
+		return "", err
+	}
+	args = append(args, getCapabilitiesArgs(options)...)
+	if options.Version != "" {
+		args = append(args, "--version", options.Version)
+	}
+	for _, templateFile := range templateFiles {
+		// As for remote charts, the templates are not checked locally: helm fails if they do not exist.
+		args = append(args, "--show-only", templateFile)
+	}
+	args = append(args, extraHelmArgs...)
+	args = append(args, releaseName, chartRef)
+	return RunHelmCommandAndGetStdOutE(t, options, "template", args...)
+}
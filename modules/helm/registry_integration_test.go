@@ -0,0 +1,92 @@
+//go:build kubeall || helm
+// +build kubeall helm
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests, and further differentiate helm
+// tests. This is done because minikube is heavy and can interfere with docker related tests in terratest. Similarly,
+// helm can overload the minikube system and thus interfere with the other kubernetes tests. To avoid overloading the
+// system, we run the kubernetes tests and helm tests separately from the others.
+
+package helm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	appsv1 "k8s.io/api/apps/v1"
+
+	"github.com/gruntwork-io/terratest/modules/docker"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/retry"
+)
+
+// Test that a chart can be packaged, pushed to an authenticated OCI registry, and then rendered and installed from it.
+func TestPushAndInstallFromOCIRegistry(t *testing.T) {
+	t.Parallel()
+
+	// Start a registry with basic auth on a random local port.
+	username, password := "terratest", random.UniqueId()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	require.NoError(t, err)
+	authDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(authDir, "htpasswd"), []byte(fmt.Sprintf("%s:%s\n", username, hash)), 0644))
+	port := 5000 + random.Random(0, 999)
+	containerID := docker.RunAndGetID(t, "registry:2", &docker.RunOptions{
+		Detach:  true,
+		Remove:  true,
+		Volumes: []string{fmt.Sprintf("%s:/auth", authDir)},
+		EnvironmentVariables: []string{
+			"REGISTRY_AUTH=htpasswd",
+			"REGISTRY_AUTH_HTPASSWD_REALM=terratest",
+			"REGISTRY_AUTH_HTPASSWD_PATH=/auth/htpasswd",
+		},
+		OtherOptions: []string{"-p", fmt.Sprintf("%d:5000", port)},
+	})
+	defer docker.Stop(t, []string{containerID}, &docker.StopOptions{})
+	host := fmt.Sprintf("localhost:%d", port)
+
+	// Keep the credentials out of the helm configuration of the user.
+	options := &Options{
+		EnvVars: map[string]string{"HELM_REGISTRY_CONFIG": filepath.Join(t.TempDir(), "config.json")},
+		ExtraArgs: map[string][]string{
+			"registryLogin": {"--insecure"},
+			"push":          {"--plain-http"},
+		},
+		Version: "0.1.0-" + strings.ToLower(random.UniqueId()),
+	}
+	retry.DoWithRetry(t, "Log in to registry", 10, time.Second, func() (string, error) {
+		return "", RegistryLoginE(t, options, host, username, password)
+	})
+	defer RegistryLogout(t, options, host)
+
+	chartPackage := PackageChart(t, options, "../../examples/helm-basic-example", t.TempDir())
+	pushed := PushChart(t, options, chartPackage, fmt.Sprintf("oci://%s/charts", host))
+	assert.Equal(t, fmt.Sprintf("oci://%s/charts/helm-basic-example", host), pushed.Ref)
+	assert.Equal(t, options.Version, pushed.Version)
+	assert.True(t, strings.HasPrefix(pushed.Digest, "sha256:"))
+
+	options.SetValues = map[string]string{"containerImageRepo": "nginx", "containerImageTag": "1.15.8"}
+	output := RenderOCITemplate(t, options, pushed.Ref, "oci", []string{"templates/deployment.yaml"}, "--plain-http")
+	var deployment appsv1.Deployment
+	UnmarshalK8SYaml(t, output, &deployment)
+	assert.Equal(t, "nginx:1.15.8", deployment.Spec.Template.Spec.Containers[0].Image)
+
+	namespaceName := fmt.Sprintf("oci-install-%s", strings.ToLower(random.UniqueId()))
+	kubectlOptions := k8s.NewKubectlOptions("", "", namespaceName)
+	k8s.CreateNamespace(t, kubectlOptions, namespaceName)
+	defer k8s.DeleteNamespace(t, kubectlOptions, namespaceName)
+
+	options.KubectlOptions = kubectlOptions
+	options.ExtraArgs["install"] = []string{"--plain-http"}
+	releaseName := fmt.Sprintf("oci-install-%s", strings.ToLower(random.UniqueId()))
+	defer Delete(t, options, releaseName, true)
+	Install(t, options, pushed.Ref, releaseName)
+	assert.Equal(t, options.Version, GetReleaseStatus(t, options, releaseName).ChartVersion)
+}
@@ -0,0 +1,57 @@
+package helm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePushOutput(t *testing.T) {
+	t.Parallel()
+
+	output := "Pushed: localhost:5000/charts/helm-basic-example:0.0.1\nDigest: sha256:2d4f1c0a9e\n"
+	pushed, err := parsePushOutputE(output)
+	require.NoError(t, err)
+	assert.Equal(t, "oci://localhost:5000/charts/helm-basic-example", pushed.Ref)
+	assert.Equal(t, "0.0.1", pushed.Version)
+	assert.Equal(t, "sha256:2d4f1c0a9e", pushed.Digest)
+
+	_, err = parsePushOutputE("Error: unexpected status from HEAD request")
+	require.Error(t, err)
+	assert.IsType(t, UnexpectedHelmOutput{}, errors.Unwrap(err))
+}
+
+func TestRenderOCITemplateRejectsNonOCIReferences(t *testing.T) {
+	t.Parallel()
+
+	_, err := RenderOCITemplateE(t, &Options{}, "https://charts.example.com/mychart", "test", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not an OCI reference")
+
+	_, err = PushChartE(t, &Options{}, "mychart-0.1.0.tgz", "localhost:5000/charts")
+	require.Error(t, err)
+}
+
+func TestRegistryLoginPassesPasswordOnStdin(t *testing.T) {
+	// Not parallel, since PATH is changed to use a fake helm that records its args and stdin.
+	binDir := t.TempDir()
+	argsPath := filepath.Join(binDir, "args")
+	stdinPath := filepath.Join(binDir, "stdin")
+	fakeHelm := "#!/bin/sh\necho \"$@\" > " + argsPath + "\ncat > " + stdinPath + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "helm"), []byte(fakeHelm), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	RegistryLogin(t, &Options{}, "localhost:5000", "terratest", "s3cr3t")
+
+	args, err := os.ReadFile(argsPath)
+	require.NoError(t, err)
+	assert.Equal(t, "registry login localhost:5000 --username terratest --password-stdin", strings.TrimSpace(string(args)))
+	stdin, err := os.ReadFile(stdinPath)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", string(stdin))
+}
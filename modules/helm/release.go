@@ -0,0 +1,238 @@
+package helm
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/require"
+	helmtime "helm.sh/helm/v3/pkg/time"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// ReleaseStatus is the state of a release, as reported by `helm status`.
+type ReleaseStatus struct {
+	Name          string
+	Namespace     string
+	Revision      int
+	Status        string // One of deployed, failed, superseded, uninstalled, pending-install, pending-upgrade, pending-rollback...
+	Description   string
+	ChartName     string
+	ChartVersion  string
+	AppVersion    string
+	Notes         string
+	FirstDeployed time.Time
+	LastDeployed  time.Time
+	Config        map[string]interface{} // The values supplied by the user for this revision.
+	Manifest      string                 // The manifest of the objects deployed by this revision.
+	Hooks         []ReleaseHook
+}
+
+// ReleaseHook is a hook of a release, such as a test, along with the outcome of its last run.
+type ReleaseHook struct {
+	Name          string
+	Kind          string
+	Path          string   // The template of the hook, e.g. CHART/templates/tests/test-connection.yaml
+	Events        []string // The events that run the hook, e.g. test or pre-install.
+	Phase         string   // The phase of the last run: Unknown, Running, Succeeded or Failed.
+	LastStarted   time.Time
+	LastCompleted time.Time
+}
+
+// ReleaseRevision is an entry of the history of a release, as reported by `helm history`.
+type ReleaseRevision struct {
+	Revision    int
+	Updated     time.Time
+	Status      string
+	Chart       string // The chart name and version, e.g. nginx-13.2.24
+	AppVersion  string
+	Description string
+}
+
+// helmHistoryEntry is an entry of the output of `helm history -o json`. Times are decoded with the helm time type,
+// which supports the empty strings helm outputs for unset times.
+type helmHistoryEntry struct {
+	Revision    int           `json:"revision"`
+	Updated     helmtime.Time `json:"updated"`
+	Status      string        `json:"status"`
+	Chart       string        `json:"chart"`
+	AppVersion  string        `json:"app_version"`
+	Description string        `json:"description"`
+}
+
+// helmStatusOutput is the subset of the output of `helm status -o json` that is exposed in ReleaseStatus.
+type helmStatusOutput struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Info      struct {
+		FirstDeployed helmtime.Time `json:"first_deployed"`
+		LastDeployed  helmtime.Time `json:"last_deployed"`
+		Description   string        `json:"description"`
+		Status        string        `json:"status"`
+		Notes         string        `json:"notes"`
+	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name       string `json:"name"`
+			Version    string `json:"version"`
+			AppVersion string `json:"appVersion"`
+		} `json:"metadata"`
+	} `json:"chart"`
+	Config   map[string]interface{} `json:"config"`
+	Manifest string                 `json:"manifest"`
+	Hooks    []struct {
+		Name    string   `json:"name"`
+		Kind    string   `json:"kind"`
+		Path    string   `json:"path"`
+		Events  []string `json:"events"`
+		LastRun struct {
+			StartedAt   helmtime.Time `json:"started_at"`
+			CompletedAt helmtime.Time `json:"completed_at"`
+			Phase       string        `json:"phase"`
+		} `json:"last_run"`
+	} `json:"hooks"`
+}
+
+// GetReleaseStatus runs `helm status` for the given release and returns its status. Use Options.ExtraArgs["status"]
+// to pass extra arguments, such as --revision. This will fail the test if there is an error.
+func GetReleaseStatus(t testing.TestingT, options *Options, releaseName string) *ReleaseStatus {
+	status, err := GetReleaseStatusE(t, options, releaseName)
+	require.NoError(t, err)
+	return status
+}
+
+// GetReleaseStatusE runs `helm status` for the given release and returns its status. Use Options.ExtraArgs["status"]
+// to pass extra arguments, such as --revision.
+func GetReleaseStatusE(t testing.TestingT, options *Options, releaseName string) (*ReleaseStatus, error) {
+	var output helmStatusOutput
+	if err := runHelmJSONCommandE(t, options, "status", []string{releaseName}, &output); err != nil {
+		return nil, err
+	}
+	config := output.Config
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+	hooks := []ReleaseHook{}
+	for _, hook := range output.Hooks {
+		hooks = append(hooks, ReleaseHook{
+			Name:          hook.Name,
+			Kind:          hook.Kind,
+			Path:          hook.Path,
+			Events:        hook.Events,
+			Phase:         hook.LastRun.Phase,
+			LastStarted:   hook.LastRun.StartedAt.Time,
+			LastCompleted: hook.LastRun.CompletedAt.Time,
+		})
+	}
+	return &ReleaseStatus{
+		Name:          output.Name,
+		Namespace:     output.Namespace,
+		Revision:      output.Version,
+		Status:        output.Info.Status,
+		Description:   output.Info.Description,
+		ChartName:     output.Chart.Metadata.Name,
+		ChartVersion:  output.Chart.Metadata.Version,
+		AppVersion:    output.Chart.Metadata.AppVersion,
+		Notes:         output.Info.Notes,
+		FirstDeployed: output.Info.FirstDeployed.Time,
+		LastDeployed:  output.Info.LastDeployed.Time,
+		Config:        config,
+		Manifest:      output.Manifest,
+		Hooks:         hooks,
+	}, nil
+}
+
+// GetReleaseHistory runs `helm history` for the given release and returns its revisions, oldest first. This will
+// fail the test if there is an error.
+func GetReleaseHistory(t testing.TestingT, options *Options, releaseName string) []ReleaseRevision {
+	history, err := GetReleaseHistoryE(t, options, releaseName)
+	require.NoError(t, err)
+	return history
+}
+
+// GetReleaseHistoryE runs `helm history` for the given release and returns its revisions, oldest first.
+func GetReleaseHistoryE(t testing.TestingT, options *Options, releaseName string) ([]ReleaseRevision, error) {
+	entries := []helmHistoryEntry{}
+	if err := runHelmJSONCommandE(t, options, "history", []string{releaseName}, &entries); err != nil {
+		return nil, err
+	}
+	history := []ReleaseRevision{}
+	for _, entry := range entries {
+		history = append(history, ReleaseRevision{
+			Revision:    entry.Revision,
+			Updated:     entry.Updated.Time,
+			Status:      entry.Status,
+			Chart:       entry.Chart,
+			AppVersion:  entry.AppVersion,
+			Description: entry.Description,
+		})
+	}
+	return history, nil
+}
+
+// GetReleaseValues runs `helm get values` for the given release and returns the values supplied by the user, or all
+// the computed values (including the chart defaults) if allValues is true. Use Options.ExtraArgs["get"] to pass extra
+// arguments, such as --revision. This will fail the test if there is an error.
+func GetReleaseValues(t testing.TestingT, options *Options, releaseName string, allValues bool) map[string]interface{} {
+	values, err := GetReleaseValuesE(t, options, releaseName, allValues)
+	require.NoError(t, err)
+	return values
+}
+
+// GetReleaseValuesE runs `helm get values` for the given release and returns the values supplied by the user, or all
+// the computed values (including the chart defaults) if allValues is true. Use Options.ExtraArgs["get"] to pass extra
+// arguments, such as --revision.
+func GetReleaseValuesE(t testing.TestingT, options *Options, releaseName string, allValues bool) (map[string]interface{}, error) {
+	args := []string{"values", releaseName}
+	if allValues {
+		args = append(args, "--all")
+	}
+	values := map[string]interface{}{}
+	if err := runHelmJSONCommandE(t, options, "get", args, &values); err != nil {
+		return nil, err
+	}
+	// helm outputs null when no values were supplied.
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	return values, nil
+}
+
+// GetReleaseManifest runs `helm get manifest` for the given release and returns the objects it deployed, indexed the
+// same way as rendered templates (see RenderResult). Use Options.ExtraArgs["get"] to pass extra arguments, such as
+// --revision. This will fail the test if there is an error.
+func GetReleaseManifest(t testing.TestingT, options *Options, releaseName string) *RenderResult {
+	manifest, err := GetReleaseManifestE(t, options, releaseName)
+	require.NoError(t, err)
+	return manifest
+}
+
+// GetReleaseManifestE runs `helm get manifest` for the given release and returns the objects it deployed, indexed the
+// same way as rendered templates (see RenderResult). Use Options.ExtraArgs["get"] to pass extra arguments, such as
+// --revision.
+func GetReleaseManifestE(t testing.TestingT, options *Options, releaseName string) (*RenderResult, error) {
+	args := append([]string{"manifest", releaseName}, options.ExtraArgs["get"]...)
+	output, err := RunHelmCommandAndGetStdOutE(t, options, "get", args...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRenderResultE(t, output)
+}
+
+// runHelmJSONCommandE runs the given helm command with the extra args of the options and `-o json`, and decodes the
+// output into the given destination.
+func runHelmJSONCommandE(t testing.TestingT, options *Options, cmd string, args []string, destination interface{}) error {
+	args = append(args, options.ExtraArgs[cmd]...)
+	args = append(args, "--output", "json")
+	output, err := RunHelmCommandAndGetStdOutE(t, options, cmd, args...)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), destination); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+//go:build kubeall || helm
+// +build kubeall helm
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests, and further differentiate helm
+// tests. This is done because minikube is heavy and can interfere with docker related tests in terratest. Similarly,
+// helm can overload the minikube system and thus interfere with the other kubernetes tests. To avoid overloading the
+// system, we run the kubernetes tests and helm tests separately from the others.
+
+package helm
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+)
+
+// Test that we can inspect the status, history, values and manifest of a release across an upgrade and a rollback.
+func TestReleaseInspection(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs("../../examples/helm-basic-example")
+	require.NoError(t, err)
+
+	namespaceName := fmt.Sprintf("release-inspection-%s", strings.ToLower(random.UniqueId()))
+	kubectlOptions := k8s.NewKubectlOptions("", "", namespaceName)
+	k8s.CreateNamespace(t, kubectlOptions, namespaceName)
+	defer k8s.DeleteNamespace(t, kubectlOptions, namespaceName)
+
+	options := &Options{
+		KubectlOptions: kubectlOptions,
+		SetValues: map[string]string{
+			"containerImageRepo": "nginx",
+			"containerImageTag":  "1.15.8",
+		},
+	}
+	releaseName := fmt.Sprintf("release-inspection-%s", strings.ToLower(random.UniqueId()))
+	defer Delete(t, options, releaseName, true)
+	Install(t, options, helmChartPath, releaseName)
+
+	status := GetReleaseStatus(t, options, releaseName)
+	assert.Equal(t, releaseName, status.Name)
+	assert.Equal(t, namespaceName, status.Namespace)
+	assert.Equal(t, 1, status.Revision)
+	assert.Equal(t, "deployed", status.Status)
+	assert.Equal(t, "helm-basic-example", status.ChartName)
+	assert.Equal(t, "0.0.1", status.ChartVersion)
+
+	options.SetValues["containerImageTag"] = "1.15.9"
+	Upgrade(t, options, helmChartPath, releaseName)
+	assert.Equal(t, 2, GetReleaseStatus(t, options, releaseName).Revision)
+	assert.Equal(t, "1.15.9", GetReleaseValues(t, options, releaseName, false)["containerImageTag"])
+
+	manifest := GetReleaseManifest(t, options, releaseName)
+	deployment := Get[appsv1.Deployment](t, manifest, RenderedObjectFilter{})
+	assert.Equal(t, "nginx:1.15.9", deployment.Spec.Template.Spec.Containers[0].Image)
+
+	Rollback(t, options, releaseName, "1")
+	history := GetReleaseHistory(t, options, releaseName)
+	require.Len(t, history, 3)
+	assert.Equal(t, "superseded", history[0].Status)
+	assert.Equal(t, "helm-basic-example-0.0.1", history[2].Chart)
+	assert.Equal(t, "deployed", history[2].Status)
+	assert.Equal(t, 3, history[2].Revision)
+	assert.Equal(t, "1.15.8", GetReleaseValues(t, options, releaseName, false)["containerImageTag"])
+
+	// The values of a previous revision can still be inspected.
+	options.ExtraArgs = map[string][]string{"get": {"--revision", "2"}}
+	assert.Equal(t, "1.15.9", GetReleaseValues(t, options, releaseName, true)["containerImageTag"])
+}
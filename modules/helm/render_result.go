@@ -0,0 +1,86 @@
+package helm
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// manifestSeparatorRegex splits rendered output into the manifests of each template, the same way helm does.
+var manifestSeparatorRegex = regexp.MustCompile("(?:^|\\s*\n)---\\s*")
+
+// RenderedObject is a single object rendered by a chart, along with the template it came from. See k8s.RenderedObject.
+type RenderedObject = k8s.RenderedObject
+
+// RenderedObjectFilter selects rendered objects. See k8s.RenderedObjectFilter.
+type RenderedObjectFilter = k8s.RenderedObjectFilter
+
+// RenderResult holds all the objects rendered by a chart, in the order they were rendered. It is the same type as the
+// objects of a kustomization (see k8s.KustomizeResult), so that both are looked up the same way. See k8s.RenderResult.
+type RenderResult = k8s.RenderResult
+
+// Get returns the single rendered object selected by the filter, decoded into the given type, such as
+// appsv1.Deployment. When the filter doesn't set a kind, the group, version and kind are inferred from the type for
+// the built-in Kubernetes types. This will fail the test if no object or more than one object matches. For example:
+//
+//	deployment := helm.Get[appsv1.Deployment](t, result, helm.RenderedObjectFilter{Name: "my-release-nginx"})
+func Get[T any](t testing.TestingT, result *RenderResult, filter RenderedObjectFilter) T {
+	out, err := GetE[T](result, filter)
+	require.NoError(t, err)
+	return out
+}
+
+// GetE returns the single rendered object selected by the filter, decoded into the given type, such as
+// appsv1.Deployment. When the filter doesn't set a kind, the group, version and kind are inferred from the type for
+// the built-in Kubernetes types. This returns a RenderedObjectNotFound error if no object matches, and a
+// MultipleRenderedObjectsFound error if more than one object matches.
+func GetE[T any](result *RenderResult, filter RenderedObjectFilter) (T, error) {
+	return k8s.GetRenderedObjectE[T](result, filter)
+}
+
+// RenderTemplateResult runs `helm template` (see RenderTemplate) and parses the output into a RenderResult. This
+// function will fail the test if there is an error rendering the template or parsing the output.
+func RenderTemplateResult(t testing.TestingT, options *Options, chartDir string, releaseName string, templateFiles []string, extraHelmArgs ...string) *RenderResult {
+	result, err := RenderTemplateResultE(t, options, chartDir, releaseName, templateFiles, extraHelmArgs...)
+	require.NoError(t, err)
+	return result
+}
+
+// RenderTemplateResultE runs `helm template` (see RenderTemplateE) and parses the output into a RenderResult.
+func RenderTemplateResultE(t testing.TestingT, options *Options, chartDir string, releaseName string, templateFiles []string, extraHelmArgs ...string) (*RenderResult, error) {
+	output, err := RenderTemplateE(t, options, chartDir, releaseName, templateFiles, extraHelmArgs...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRenderResultE(t, output)
+}
+
+// ParseRenderResult parses the output of `helm template`, RenderTemplate or RenderTemplateInProcess into a
+// RenderResult. This function will fail the test if there is an error parsing the output.
+func ParseRenderResult(t testing.TestingT, output string) *RenderResult {
+	result, err := ParseRenderResultE(t, output)
+	require.NoError(t, err)
+	return result
+}
+
+// ParseRenderResultE parses the output of `helm template`, RenderTemplate or RenderTemplateInProcess into a
+// RenderResult, recording the template each object was rendered from.
+func ParseRenderResultE(t testing.TestingT, output string) (*RenderResult, error) {
+	result := &RenderResult{Objects: []RenderedObject{}}
+	for _, manifest := range manifestSeparatorRegex.Split(output, -1) {
+		sourceTemplate := ""
+		if submatch := manifestSourceRegex.FindStringSubmatch(manifest); len(submatch) > 0 {
+			sourceTemplate = strings.TrimSpace(submatch[1])
+		}
+		objects, err := k8s.ParseManifestsE(t, manifest)
+		if err != nil {
+			return nil, err
+		}
+		result.Objects = append(result.Objects, k8s.NewRenderResult(objects, sourceTemplate).Objects...)
+	}
+	return result, nil
+}
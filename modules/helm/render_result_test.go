@@ -0,0 +1,84 @@
+package helm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const renderResultTestOutput = `---
+# Source: example/templates/configmaps.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: first
+  namespace: default
+---
+# Source: example/templates/configmaps.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: second
+  namespace: other
+---
+# Source: example/charts/sub/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: sub
+  namespace: default
+spec:
+  replicas: 3
+---
+# Source: example/templates/empty.yaml
+`
+
+func TestParseRenderResultIndexesObjects(t *testing.T) {
+	t.Parallel()
+
+	result := ParseRenderResult(t, renderResultTestOutput)
+	require.Len(t, result.Objects, 3)
+	assert.Equal(t, "templates/configmaps.yaml", result.Objects[0].SourceTemplate)
+	assert.Equal(t, "charts/sub/templates/deployment.yaml", result.Objects[2].SourceTemplate)
+	assert.Equal(t, "apps", result.Objects[2].GroupVersionKind.Group)
+
+	assert.Len(t, result.FindAll(RenderedObjectFilter{Kind: "ConfigMap"}), 2)
+	assert.Len(t, result.FindAll(RenderedObjectFilter{Namespace: "default"}), 2)
+	assert.Len(t, result.FindAll(RenderedObjectFilter{SourceTemplate: "charts/*/templates/*"}), 1)
+	assert.Empty(t, result.FindAll(RenderedObjectFilter{Kind: "Secret"}))
+
+	// The kind is inferred from the type.
+	deployment := Get[appsv1.Deployment](t, result, RenderedObjectFilter{})
+	assert.Equal(t, "sub", deployment.Name)
+	assert.Equal(t, int32(3), *deployment.Spec.Replicas)
+	configMap := Get[corev1.ConfigMap](t, result, RenderedObjectFilter{Namespace: "other"})
+	assert.Equal(t, "second", configMap.Name)
+}
+
+func TestGetRenderedObjectErrors(t *testing.T) {
+	t.Parallel()
+
+	result := ParseRenderResult(t, renderResultTestOutput)
+
+	_, err := GetE[corev1.ConfigMap](result, RenderedObjectFilter{})
+	require.Error(t, err)
+	assert.IsType(t, MultipleRenderedObjectsFound{}, err)
+	assert.Contains(t, err.Error(), "ConfigMap default/first (from templates/configmaps.yaml)")
+
+	_, err = GetE[corev1.Secret](result, RenderedObjectFilter{})
+	require.Error(t, err)
+	assert.IsType(t, RenderedObjectNotFound{}, err)
+	assert.Contains(t, err.Error(), "kind=Secret")
+}
+
+func TestParseRenderResultFromInProcessRender(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{SetValues: map[string]string{"containerImageRepo": "nginx", "containerImageTag": "1.15.8"}}
+	result := ParseRenderResult(t, RenderTemplateInProcess(t, options, "../../examples/helm-basic-example", "test", nil))
+	service := Get[corev1.Service](t, result, RenderedObjectFilter{SourceTemplate: "templates/service.yaml"})
+	assert.Equal(t, "test-helm-basic-example", service.Name)
+}
@@ -0,0 +1,403 @@
+package helm
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/gonvenience/ytbx"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/homeport/dyff/pkg/dyff"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// UpdateSnapshotsEnvVar is the environment variable that makes AssertMatchesSnapshot update the snapshots instead of
+// comparing against them when it is set to a true value (e.g. UPDATE_SNAPSHOTS=1 go test ./...).
+const UpdateSnapshotsEnvVar = "UPDATE_SNAPSHOTS"
+
+// snapshotIgnoredValue replaces the values of ignored fields in the snapshots, so that the snapshots still record that
+// the field is set.
+const snapshotIgnoredValue = "<ignored>"
+
+// unsafeSnapshotFileNameCharsRegex matches the characters replaced in the names of snapshot files. The _ is kept out,
+// since it separates the parts of the names.
+var unsafeSnapshotFileNameCharsRegex = regexp.MustCompile("[^a-z0-9.-]")
+
+// SnapshotIgnorePath is a field of the rendered objects that is normalized before snapshots are written or compared,
+// because it changes on every render (e.g. checksums, generated secrets) or on every release (e.g. chart versions).
+type SnapshotIgnorePath struct {
+	// Only normalize the field in objects of this kind. Empty means all kinds.
+	Kind string
+
+	// A JSON pointer (RFC 6901) to the field, such as /metadata/labels/helm.sh~1chart. Each segment is a glob pattern
+	// (see path.Match), so /spec/template/metadata/annotations/checksum~1* matches every checksum annotation, and
+	// /spec/containers/*/image matches the image of every container.
+	Path string
+}
+
+// DefaultSnapshotIgnorePaths are the fields normalized when Options.SnapshotIgnorePaths is nil: chart and app version
+// labels, checksum annotations on pod templates, and Secret data, which is often randomly generated and should not be
+// committed to a snapshot anyway.
+var DefaultSnapshotIgnorePaths = []SnapshotIgnorePath{
+	{Path: "/metadata/labels/helm.sh~1chart"},
+	{Path: "/metadata/labels/chart"},
+	{Path: "/metadata/labels/app.kubernetes.io~1version"},
+	{Path: "/spec/template/metadata/labels/helm.sh~1chart"},
+	{Path: "/spec/template/metadata/labels/chart"},
+	{Path: "/spec/template/metadata/labels/app.kubernetes.io~1version"},
+	{Path: "/spec/template/metadata/annotations/checksum~1*"},
+	{Path: "/spec/jobTemplate/spec/template/metadata/annotations/checksum~1*"},
+	{Kind: "Secret", Path: "/data/*"},
+	{Kind: "Secret", Path: "/stringData/*"},
+}
+
+// SnapshotReport is the result of comparing rendered objects against their snapshots. Objects are identified by the
+// name of their snapshot file.
+type SnapshotReport struct {
+	Added   []string          // Objects that were rendered but have no snapshot.
+	Removed []string          // Objects that have a snapshot but were not rendered.
+	Changed map[string]string // Objects that differ from their snapshot, with a human readable dyff report.
+}
+
+// HasDiffs returns true if any object was added, removed or changed compared to the snapshots.
+func (report *SnapshotReport) HasDiffs() bool {
+	return len(report.Added) > 0 || len(report.Removed) > 0 || len(report.Changed) > 0
+}
+
+// String returns a human readable summary of the differences, including the dyff report of every changed object.
+func (report *SnapshotReport) String() string {
+	if !report.HasDiffs() {
+		return "All rendered objects match their snapshot"
+	}
+	var builder strings.Builder
+	fmt.Fprintf(
+		&builder,
+		"Rendered objects do not match their snapshot: %d added, %d removed, %d changed. Run the tests with %s=1 to update the snapshots.\n",
+		len(report.Added),
+		len(report.Removed),
+		len(report.Changed),
+		UpdateSnapshotsEnvVar,
+	)
+	for _, name := range report.Added {
+		fmt.Fprintf(&builder, "\nAdded: %s\n", name)
+	}
+	for _, name := range report.Removed {
+		fmt.Fprintf(&builder, "\nRemoved: %s\n", name)
+	}
+	changed := make([]string, 0, len(report.Changed))
+	for name := range report.Changed {
+		changed = append(changed, name)
+	}
+	sort.Strings(changed)
+	for _, name := range changed {
+		fmt.Fprintf(&builder, "\nChanged: %s\n%s", name, report.Changed[name])
+	}
+	return builder.String()
+}
+
+// AssertMatchesSnapshot compares every object rendered by the chart with its snapshot, and fails the test with a dyff
+// report of the differences if they don't match. When the UPDATE_SNAPSHOTS environment variable is set, the snapshots
+// are updated instead.
+func AssertMatchesSnapshot(t testing.TestingT, options *Options, yamlData string, releaseName string) {
+	require.NoError(t, AssertMatchesSnapshotE(t, options, yamlData, releaseName))
+}
+
+// AssertMatchesSnapshotE compares every object rendered by the chart with its snapshot, and returns a SnapshotMismatch
+// error with a dyff report of the differences if they don't match. When the UPDATE_SNAPSHOTS environment variable is
+// set, the snapshots are updated instead.
+func AssertMatchesSnapshotE(t testing.TestingT, options *Options, yamlData string, releaseName string) error {
+	if shouldUpdateSnapshots() {
+		return UpdateObjectSnapshotsE(t, options, yamlData, releaseName)
+	}
+	report, err := DiffAgainstObjectSnapshotsE(t, options, yamlData, releaseName)
+	if err != nil {
+		return err
+	}
+	if report.HasDiffs() {
+		return SnapshotMismatch{ReleaseName: releaseName, Report: report}
+	}
+	return nil
+}
+
+// UpdateObjectSnapshots writes one snapshot file per rendered object in the SNAPSHOT_PATH/RELEASE_NAME directory,
+// after normalizing the fields in Options.SnapshotIgnorePaths, and removes the snapshots of objects that are no longer
+// rendered. This will fail the test if there is an error.
+func UpdateObjectSnapshots(t testing.TestingT, options *Options, yamlData string, releaseName string) {
+	require.NoError(t, UpdateObjectSnapshotsE(t, options, yamlData, releaseName))
+}
+
+// UpdateObjectSnapshotsE writes one snapshot file per rendered object in the SNAPSHOT_PATH/RELEASE_NAME directory,
+// after normalizing the fields in Options.SnapshotIgnorePaths, and removes the snapshots of objects that are no longer
+// rendered.
+func UpdateObjectSnapshotsE(t testing.TestingT, options *Options, yamlData string, releaseName string) error {
+	rendered, err := renderSnapshotsE(t, options, yamlData)
+	if err != nil {
+		return err
+	}
+	dir := objectSnapshotDir(options, releaseName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	existing, err := loadSnapshotNamesE(dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range existing {
+		if _, isRendered := rendered[name]; !isRendered {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				return errors.WithStackTrace(err)
+			}
+		}
+	}
+	for name, content := range rendered {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			return errors.WithStackTrace(err)
+		}
+	}
+	getLogger(options).Logf(t, "Wrote %d object snapshots for release %s into %s", len(rendered), releaseName, dir)
+	return nil
+}
+
+// DiffAgainstObjectSnapshots compares every rendered object, after normalizing the fields in
+// Options.SnapshotIgnorePaths, with the snapshots written by UpdateObjectSnapshots. This will fail the test if there is
+// an error, but not if there are differences.
+func DiffAgainstObjectSnapshots(t testing.TestingT, options *Options, yamlData string, releaseName string) *SnapshotReport {
+	report, err := DiffAgainstObjectSnapshotsE(t, options, yamlData, releaseName)
+	require.NoError(t, err)
+	return report
+}
+
+// DiffAgainstObjectSnapshotsE compares every rendered object, after normalizing the fields in
+// Options.SnapshotIgnorePaths, with the snapshots written by UpdateObjectSnapshots.
+func DiffAgainstObjectSnapshotsE(t testing.TestingT, options *Options, yamlData string, releaseName string) (*SnapshotReport, error) {
+	rendered, err := renderSnapshotsE(t, options, yamlData)
+	if err != nil {
+		return nil, err
+	}
+	dir := objectSnapshotDir(options, releaseName)
+	existing, err := loadSnapshotNamesE(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SnapshotReport{Added: []string{}, Removed: []string{}, Changed: map[string]string{}}
+	for _, name := range existing {
+		if _, isRendered := rendered[name]; !isRendered {
+			report.Removed = append(report.Removed, name)
+		}
+	}
+
+	names := make([]string, 0, len(rendered))
+	for name := range rendered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		snapshotPath := filepath.Join(dir, name)
+		snapshot, err := os.ReadFile(snapshotPath)
+		if os.IsNotExist(err) {
+			report.Added = append(report.Added, name)
+			continue
+		}
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		if bytes.Equal(snapshot, rendered[name]) {
+			continue
+		}
+		diff, err := dyffReportE(snapshotPath, snapshot, rendered[name])
+		if err != nil {
+			return nil, err
+		}
+		if diff != "" {
+			report.Changed[name] = diff
+		}
+	}
+	return report, nil
+}
+
+// shouldUpdateSnapshots returns true if the UPDATE_SNAPSHOTS environment variable is set to a true value.
+func shouldUpdateSnapshots() bool {
+	update, err := strconv.ParseBool(os.Getenv(UpdateSnapshotsEnvVar))
+	return err == nil && update
+}
+
+// objectSnapshotDir returns the directory where the object snapshots of the release are stored.
+func objectSnapshotDir(options *Options, releaseName string) string {
+	return filepath.Join(getSnapshotDir(options), releaseName)
+}
+
+// getSnapshotDir returns the directory where snapshots are stored.
+func getSnapshotDir(options *Options) string {
+	if options.SnapshotPath != "" {
+		return options.SnapshotPath
+	}
+	return "__snapshot__"
+}
+
+// getLogger returns the logger configured in the options, or the default one.
+func getLogger(options *Options) *logger.Logger {
+	if options.Logger != nil {
+		return options.Logger
+	}
+	return logger.Default
+}
+
+// loadSnapshotNamesE returns the names of the snapshot files in the given directory, which may not exist yet.
+func loadSnapshotNamesE(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == ".yaml" {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// renderSnapshotsE splits the rendered manifests into objects, normalizes them and returns their snapshot content keyed
+// by snapshot file name.
+func renderSnapshotsE(t testing.TestingT, options *Options, yamlData string) (map[string][]byte, error) {
+	objects, err := k8s.ParseManifestsE(t, yamlData)
+	if err != nil {
+		return nil, err
+	}
+	ignorePaths := options.SnapshotIgnorePaths
+	if ignorePaths == nil {
+		ignorePaths = DefaultSnapshotIgnorePaths
+	}
+
+	snapshots := map[string][]byte{}
+	for _, object := range objects {
+		name := snapshotFileName(object)
+		if _, isDuplicate := snapshots[name]; isDuplicate {
+			return nil, errors.WithStackTrace(DuplicateSnapshotObject{Name: name})
+		}
+		normalizeSnapshotObject(object, ignorePaths)
+		content, err := yaml.Marshal(object.Object)
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		snapshots[name] = content
+	}
+	return snapshots, nil
+}
+
+// snapshotFileName returns the name of the snapshot file of the object: [NAMESPACE_]KIND[.GROUP]_NAME.yaml in lower
+// case, e.g. default_deployment.apps_web.yaml, so that objects of kinds with the same name in different groups do not
+// share a snapshot. Characters that are not allowed in file names on every OS, such as the : of RBAC names like
+// system:aggregate-to-view, are replaced with -.
+func snapshotFileName(object *unstructured.Unstructured) string {
+	kind := object.GetKind()
+	if group := object.GroupVersionKind().Group; group != "" {
+		kind = kind + "." + group
+	}
+	parts := []string{kind, object.GetName()}
+	if object.GetNamespace() != "" {
+		parts = append([]string{object.GetNamespace()}, parts...)
+	}
+	for i, part := range parts {
+		parts[i] = unsafeSnapshotFileNameCharsRegex.ReplaceAllString(strings.ToLower(part), "-")
+	}
+	return strings.Join(parts, "_") + ".yaml"
+}
+
+// normalizeSnapshotObject replaces the value of every field matching the ignore paths with a placeholder.
+func normalizeSnapshotObject(object *unstructured.Unstructured, ignorePaths []SnapshotIgnorePath) {
+	for _, ignorePath := range ignorePaths {
+		if ignorePath.Kind != "" && ignorePath.Kind != object.GetKind() {
+			continue
+		}
+		normalizeSnapshotField(object.Object, parseJSONPointer(ignorePath.Path))
+	}
+}
+
+// parseJSONPointer splits a JSON pointer into its unescaped segments.
+func parseJSONPointer(pointer string) []string {
+	segments := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+	}
+	return segments
+}
+
+// normalizeSnapshotField walks the value along the given segments, and replaces every value at the end of the path
+// with a placeholder.
+func normalizeSnapshotField(value interface{}, segments []string) {
+	if len(segments) == 0 {
+		return
+	}
+	pattern, rest := segments[0], segments[1:]
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, child := range typed {
+			if matched, _ := path.Match(pattern, key); !matched {
+				continue
+			}
+			if len(rest) == 0 {
+				typed[key] = snapshotIgnoredValue
+			} else {
+				normalizeSnapshotField(child, rest)
+			}
+		}
+	case []interface{}:
+		for idx, child := range typed {
+			if matched, _ := path.Match(pattern, strconv.Itoa(idx)); !matched {
+				continue
+			}
+			if len(rest) == 0 {
+				typed[idx] = snapshotIgnoredValue
+			} else {
+				normalizeSnapshotField(child, rest)
+			}
+		}
+	}
+}
+
+// dyffReportE compares the snapshot with the rendered object using dyff, and returns a human readable report of the
+// differences, or an empty string if they are semantically equal.
+func dyffReportE(snapshotPath string, snapshot []byte, rendered []byte) (string, error) {
+	fromDocuments, err := ytbx.LoadDocuments(snapshot)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	toDocuments, err := ytbx.LoadDocuments(rendered)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	from := ytbx.InputFile{Location: snapshotPath, Documents: fromDocuments}
+	to := ytbx.InputFile{Location: "rendered", Documents: toDocuments}
+
+	report, err := dyff.CompareInputFiles(from, to, dyff.KubernetesEntityDetection(false))
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	if len(report.Diffs) == 0 {
+		return "", nil
+	}
+	var out bytes.Buffer
+	reportWriter := &dyff.HumanReport{Report: report, OmitHeader: true}
+	if err := reportWriter.WriteReport(&out); err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,155 @@
+package helm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/logger"
+)
+
+const snapshotTestManifests = `---
+# Source: example/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: example
+  namespace: default
+  labels:
+    helm.sh/chart: example-%s
+spec:
+  template:
+    metadata:
+      annotations:
+        checksum/config: %s
+    spec:
+      containers:
+        - name: app
+          image: nginx:%s
+---
+# Source: example/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: example
+  namespace: default
+data:
+  password: %s
+`
+
+func renderSnapshotTestManifests(chartVersion string, checksum string, imageTag string, password string) string {
+	return fmt.Sprintf(snapshotTestManifests, chartVersion, checksum, imageTag, password)
+}
+
+func TestObjectSnapshotsIgnoreVolatileFields(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{SnapshotPath: t.TempDir(), Logger: logger.Discard}
+	UpdateObjectSnapshots(t, options, renderSnapshotTestManifests("0.1.0", "abc", "1.25", "c2VjcmV0"), "example")
+
+	snapshots, err := os.ReadDir(filepath.Join(options.SnapshotPath, "example"))
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, "default_deployment.apps_example.yaml", snapshots[0].Name())
+	assert.Equal(t, "default_secret_example.yaml", snapshots[1].Name())
+	secret, err := os.ReadFile(filepath.Join(options.SnapshotPath, "example", "default_secret_example.yaml"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(secret), "c2VjcmV0")
+
+	report := DiffAgainstObjectSnapshots(t, options, renderSnapshotTestManifests("0.2.0", "def", "1.25", "b3RoZXI="), "example")
+	assert.False(t, report.HasDiffs(), report.String())
+	require.NoError(t, AssertMatchesSnapshotE(t, options, renderSnapshotTestManifests("0.2.0", "def", "1.25", "b3RoZXI="), "example"))
+}
+
+func TestObjectSnapshotsReportChanges(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{SnapshotPath: t.TempDir(), Logger: logger.Discard}
+	UpdateObjectSnapshots(t, options, renderSnapshotTestManifests("0.1.0", "abc", "1.25", "c2VjcmV0"), "example")
+
+	// Remove the secret and add a config map, while changing the image of the deployment.
+	manifests := renderSnapshotTestManifests("0.1.0", "abc", "1.26", "c2VjcmV0")
+	manifests = manifests[:strings.Index(manifests, "---\n# Source: example/templates/secret.yaml")] + `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: example
+  namespace: default
+`
+	report := DiffAgainstObjectSnapshots(t, options, manifests, "example")
+	assert.Equal(t, []string{"default_configmap_example.yaml"}, report.Added)
+	assert.Equal(t, []string{"default_secret_example.yaml"}, report.Removed)
+	require.Contains(t, report.Changed, "default_deployment.apps_example.yaml")
+	assert.Contains(t, report.Changed["default_deployment.apps_example.yaml"], "nginx:1.26")
+
+	err := AssertMatchesSnapshotE(t, options, manifests, "example")
+	require.Error(t, err)
+	assert.IsType(t, SnapshotMismatch{}, err)
+	assert.Contains(t, err.Error(), UpdateSnapshotsEnvVar)
+
+	// Updating removes the stale snapshot, after which the manifests match.
+	UpdateObjectSnapshots(t, options, manifests, "example")
+	assert.False(t, DiffAgainstObjectSnapshots(t, options, manifests, "example").HasDiffs())
+}
+
+func TestObjectSnapshotsCustomIgnorePaths(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		SnapshotPath:        t.TempDir(),
+		Logger:              logger.Discard,
+		SnapshotIgnorePaths: []SnapshotIgnorePath{{Kind: "Deployment", Path: "/spec/template/spec/containers/*/image"}},
+	}
+	UpdateObjectSnapshots(t, options, renderSnapshotTestManifests("0.1.0", "abc", "1.25", "c2VjcmV0"), "example")
+
+	// The image is ignored, but the defaults no longer apply so the chart version label is compared.
+	assert.False(t, DiffAgainstObjectSnapshots(t, options, renderSnapshotTestManifests("0.1.0", "abc", "1.26", "c2VjcmV0"), "example").HasDiffs())
+	report := DiffAgainstObjectSnapshots(t, options, renderSnapshotTestManifests("0.2.0", "abc", "1.25", "c2VjcmV0"), "example")
+	assert.Contains(t, report.Changed, "default_deployment.apps_example.yaml")
+}
+
+func TestParseJSONPointer(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"metadata", "labels", "helm.sh/chart"}, parseJSONPointer("/metadata/labels/helm.sh~1chart"))
+	assert.Equal(t, []string{"data", "a~b"}, parseJSONPointer("/data/a~0b"))
+}
+
+func TestSnapshotFileName(t *testing.T) {
+	t.Parallel()
+
+	objects := k8s.ParseManifests(t, `---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: system:aggregate-to-view
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: default
+---
+apiVersion: networking.istio.io/v1beta1
+kind: Gateway
+metadata:
+  name: web
+  namespace: default
+---
+apiVersion: gateway.networking.k8s.io/v1
+kind: Gateway
+metadata:
+  name: web
+  namespace: default
+`)
+	assert.Equal(t, "clusterrole.rbac.authorization.k8s.io_system-aggregate-to-view.yaml", snapshotFileName(objects[0]))
+	assert.Equal(t, "default_service_web.yaml", snapshotFileName(objects[1]))
+	assert.Equal(t, "default_gateway.networking.istio.io_web.yaml", snapshotFileName(objects[2]))
+	assert.Equal(t, "default_gateway.gateway.networking.k8s.io_web.yaml", snapshotFileName(objects[3]))
+}
@@ -56,6 +56,7 @@ func RenderTemplateE(t testing.TestingT, options *Options, chartDir string, rele
 	if err != nil {
 		return "", err
 	}
+	args = append(args, getCapabilitiesArgs(options)...)
 	for _, templateFile := range templateFiles {
 		// validate this is a valid template file
 		absTemplateFile := filepath.Join(absChartDir, templateFile)
@@ -99,6 +100,7 @@ func RenderRemoteTemplateE(t testing.TestingT, options *Options, chartURL string
 	if err != nil {
 		return "", err
 	}
+	args = append(args, getCapabilitiesArgs(options)...)
 	for _, templateFile := range templateFiles {
 		// As the helm command fails if a non valid template is given as input
 		// we do not check if the template file exists or not as we do for local charts
@@ -145,6 +147,7 @@ func UnmarshalK8SYamlE(t testing.TestingT, yamlData string, destinationObj inter
 // see https://github.com/gruntwork-io/terratest/issues/1377
 // A snapshot is used to compare the current manifests of a chart with the previous manifests.
 // A global diff is run against the two snapshosts and the number of differences is returned.
+// See AssertMatchesSnapshot for snapshots stored per object, with volatile fields normalized.
 func UpdateSnapshot(t testing.TestingT, options *Options, yamlData string, releaseName string) {
 	require.NoError(t, UpdateSnapshotE(t, options, yamlData, releaseName))
 }
@@ -157,15 +160,10 @@ func UpdateSnapshot(t testing.TestingT, options *Options, yamlData string, relea
 // It will failed the test if there is an error while writing the manifests' snapshot in the file system
 func UpdateSnapshotE(t testing.TestingT, options *Options, yamlData string, releaseName string) error {
 
-	var snapshotDir = "__snapshot__"
-	if options.SnapshotPath != "" {
-		snapshotDir = options.SnapshotPath
-	}
+	snapshotDir := getSnapshotDir(options)
 	// Create a directory if not exists
-	if !files.FileExists(snapshotDir) {
-		if err := os.Mkdir(snapshotDir, 0755); err != nil {
-			return errors.WithStackTrace(err)
-		}
+	if err := os.MkdirAll(snapshotDir, 0755); err != nil {
+		return errors.WithStackTrace(err)
 	}
 
 	filename := filepath.Join(snapshotDir, releaseName+".yaml")
@@ -192,6 +190,7 @@ func UpdateSnapshotE(t testing.TestingT, options *Options, yamlData string, rele
 // see https://github.com/gruntwork-io/terratest/issues/1377
 // It returns the number of difference between the two manifests or -1 in case of error
 // It will fail the test if there is an error while reading or writing the two manifests in the file system
+// See AssertMatchesSnapshot for snapshots stored per object, with volatile fields normalized.
 func DiffAgainstSnapshot(t testing.TestingT, options *Options, yamlData string, releaseName string) int {
 	numberOfDiffs, err := DiffAgainstSnapshotE(t, options, yamlData, releaseName)
 	require.NoError(t, err)
@@ -204,35 +203,19 @@ func DiffAgainstSnapshot(t testing.TestingT, options *Options, yamlData string,
 // It returns the number of difference between the manifests or -1 in case of error
 func DiffAgainstSnapshotE(t testing.TestingT, options *Options, yamlData string, releaseName string) (int, error) {
 
-	var snapshotDir = "__snapshot__"
-	if options.SnapshotPath != "" {
-		snapshotDir = options.SnapshotPath
-	}
-
 	// load the yaml snapshot file
-	snapshot := filepath.Join(snapshotDir, releaseName+".yaml")
+	snapshot := filepath.Join(getSnapshotDir(options), releaseName+".yaml")
 	from, err := ytbx.LoadFile(snapshot)
 	if err != nil {
 		return -1, errors.WithStackTrace(err)
 	}
 
-	// write the current manifest into a file as `dyff` does not support string input
-	currentManifests := releaseName + ".yaml"
-	file, err := os.Create(currentManifests)
-	if err != nil {
-		return -1, errors.WithStackTrace(err)
-	}
-
-	if _, err = file.WriteString(yamlData); err != nil {
-		return -1, errors.WithStackTrace(err)
-	}
-	defer file.Close()
-	defer os.Remove(currentManifests)
-
-	to, err := ytbx.LoadFile(currentManifests)
+	// load the current manifests from memory
+	currentDocuments, err := ytbx.LoadDocuments([]byte(yamlData))
 	if err != nil {
 		return -1, errors.WithStackTrace(err)
 	}
+	to := ytbx.InputFile{Location: releaseName + ".yaml", Documents: currentDocuments}
 
 	// compare the two manifests using `dyff`
 	compOpt := dyff.KubernetesEntityDetection(false)
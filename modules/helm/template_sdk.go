@@ -0,0 +1,225 @@
+package helm
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/releaseutil"
+
+	"github.com/gruntwork-io/terratest/modules/files"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// manifestSourceRegex extracts the template path, relative to the chart, from the `# Source:` comment helm adds to
+// every rendered manifest.
+var manifestSourceRegex = regexp.MustCompile("# Source: [^/]+/(.+)")
+
+// RenderTemplateInProcess renders the chart in chartDir with the Helm Go SDK instead of the helm binary, and returns
+// the same output as `helm template`. If you pass in templateFiles, this will only render those templates. This is much
+// faster than RenderTemplate when rendering a chart with many value combinations, and does not require helm to be
+// installed. This function will fail the test if there is an error rendering the template.
+func RenderTemplateInProcess(t testing.TestingT, options *Options, chartDir string, releaseName string, templateFiles []string) string {
+	out, err := RenderTemplateInProcessE(t, options, chartDir, releaseName, templateFiles)
+	require.NoError(t, err)
+	return out
+}
+
+// RenderTemplateInProcessE renders the chart in chartDir with the Helm Go SDK instead of the helm binary, and returns
+// the same output as `helm template`. If you pass in templateFiles, this will only render those templates. Subcharts
+// are loaded from the charts/ directory of the chart, which is populated first when Options.BuildDependencies is set,
+// and Options.KubeVersion and Options.APIVersions set the capabilities available to the templates.
+func RenderTemplateInProcessE(t testing.TestingT, options *Options, chartDir string, releaseName string, templateFiles []string) (string, error) {
+	rel, err := renderReleaseInProcessE(t, options, chartDir, releaseName)
+	if err != nil {
+		return "", err
+	}
+
+	var manifests bytes.Buffer
+	fmt.Fprintln(&manifests, strings.TrimSpace(rel.Manifest))
+	for _, hook := range rel.Hooks {
+		fmt.Fprintf(&manifests, "---\n# Source: %s\n%s\n", hook.Path, hook.Manifest)
+	}
+	if len(templateFiles) == 0 {
+		return manifests.String(), nil
+	}
+	return filterManifestsByTemplateE(manifests.String(), chartDir, templateFiles)
+}
+
+// RenderTemplateObjectsInProcess renders the chart in chartDir with the Helm Go SDK (see RenderTemplateInProcess), and
+// returns the rendered objects, along with the template each one came from. Use Get or
+// RenderedObject.TypedObjectE to decode them into their type, e.g. *appsv1.Deployment. This function will fail the
+// test if there is an error rendering the template.
+func RenderTemplateObjectsInProcess(t testing.TestingT, options *Options, chartDir string, releaseName string, templateFiles []string) *RenderResult {
+	result, err := RenderTemplateObjectsInProcessE(t, options, chartDir, releaseName, templateFiles)
+	require.NoError(t, err)
+	return result
+}
+
+// RenderTemplateObjectsInProcessE renders the chart in chartDir with the Helm Go SDK (see RenderTemplateInProcessE),
+// and returns the rendered objects, along with the template each one came from. Use GetE or
+// RenderedObject.TypedObjectE to decode them into their type, e.g. *appsv1.Deployment.
+func RenderTemplateObjectsInProcessE(t testing.TestingT, options *Options, chartDir string, releaseName string, templateFiles []string) (*RenderResult, error) {
+	output, err := RenderTemplateInProcessE(t, options, chartDir, releaseName, templateFiles)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRenderResultE(t, output)
+}
+
+// renderReleaseInProcessE loads the chart and the values, and renders the release the same way `helm template` does:
+// a client only dry run install, which never contacts the cluster.
+func renderReleaseInProcessE(t testing.TestingT, options *Options, chartDir string, releaseName string) (*release.Release, error) {
+	if !files.FileExists(chartDir) {
+		return nil, errors.WithStackTrace(ChartNotFoundError{chartDir})
+	}
+	settings := cli.New()
+
+	if options.BuildDependencies {
+		if err := buildDependenciesInProcessE(settings, chartDir); err != nil {
+			return nil, err
+		}
+	}
+	loadedChart, err := loader.Load(chartDir)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	if dependencies := loadedChart.Metadata.Dependencies; dependencies != nil {
+		if err := action.CheckDependencies(loadedChart, dependencies); err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+	}
+
+	vals, err := mergeValuesInProcessE(t, options, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	install := action.NewInstall(&action.Configuration{Log: func(string, ...interface{}) {}})
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	install.ReleaseName = releaseName
+	install.Namespace = "default"
+	if options.KubectlOptions != nil && options.KubectlOptions.Namespace != "" {
+		install.Namespace = options.KubectlOptions.Namespace
+	}
+	if options.KubeVersion != "" {
+		kubeVersion, err := chartutil.ParseKubeVersion(options.KubeVersion)
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		install.KubeVersion = kubeVersion
+	}
+	install.APIVersions = chartutil.VersionSet(options.APIVersions)
+
+	rel, err := install.Run(loadedChart, vals)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return rel, nil
+}
+
+// buildDependenciesInProcessE is the equivalent of `helm dependency build` for the chart in chartDir.
+func buildDependenciesInProcessE(settings *cli.EnvSettings, chartDir string) error {
+	registryClient, err := registry.NewClient(registry.ClientOptCredentialsFile(settings.RegistryConfig))
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	manager := &downloader.Manager{
+		Out:              io.Discard,
+		ChartPath:        chartDir,
+		SkipUpdate:       true,
+		Getters:          getter.All(settings),
+		RegistryClient:   registryClient,
+		RepositoryConfig: settings.RepositoryConfig,
+		RepositoryCache:  settings.RepositoryCache,
+	}
+	return errors.WithStackTrace(manager.Build())
+}
+
+// mergeValuesInProcessE merges the values files and set values of the options, in the same order of precedence as the
+// helm command line.
+func mergeValuesInProcessE(t testing.TestingT, options *Options, settings *cli.EnvSettings) (map[string]interface{}, error) {
+	valuesFilesArgs, err := formatValuesFilesAsArgsE(t, options.ValuesFiles)
+	if err != nil {
+		return nil, err
+	}
+	setFilesArgs, err := formatSetFilesAsArgsE(t, options.SetFiles)
+	if err != nil {
+		return nil, err
+	}
+	valueOptions := values.Options{
+		ValueFiles:   argValues(valuesFilesArgs),
+		Values:       argValues(formatSetValuesAsArgs(options.SetValues, "--set")),
+		StringValues: argValues(formatSetValuesAsArgs(options.SetStrValues, "--set-string")),
+		JSONValues:   argValues(formatSetValuesAsArgs(options.SetJsonValues, "--set-json")),
+		FileValues:   argValues(setFilesArgs),
+	}
+	vals, err := valueOptions.MergeValues(getter.All(settings))
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return vals, nil
+}
+
+// argValues returns the values of a list of command line args of the form FLAG VALUE.
+func argValues(args []string) []string {
+	argValues := []string{}
+	for i := 1; i < len(args); i += 2 {
+		argValues = append(argValues, args[i])
+	}
+	return argValues
+}
+
+// filterManifestsByTemplateE only keeps the manifests rendered from the given templates, the same way as the
+// --show-only flag of `helm template`.
+func filterManifestsByTemplateE(manifests string, chartDir string, templateFiles []string) (string, error) {
+	splitManifests := releaseutil.SplitManifests(manifests)
+	manifestKeys := make([]string, 0, len(splitManifests))
+	for key := range splitManifests {
+		manifestKeys = append(manifestKeys, key)
+	}
+	sort.Sort(releaseutil.BySplitManifestsOrder(manifestKeys))
+
+	var out strings.Builder
+	for _, templateFile := range templateFiles {
+		templateFile = filepath.ToSlash(templateFile)
+		found := false
+		for _, key := range manifestKeys {
+			manifest := splitManifests[key]
+			submatch := manifestSourceRegex.FindStringSubmatch(manifest)
+			if len(submatch) == 0 {
+				continue
+			}
+			if matched, _ := filepath.Match(templateFile, submatch[1]); !matched {
+				continue
+			}
+			fmt.Fprintf(&out, "---\n%s\n", manifest)
+			found = true
+		}
+		if !found {
+			absChartDir, err := filepath.Abs(chartDir)
+			if err != nil {
+				return "", errors.WithStackTrace(err)
+			}
+			return "", errors.WithStackTrace(TemplateFileNotFoundError{Path: templateFile, ChartDir: absChartDir})
+		}
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,132 @@
+package helm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/gruntwork-io/terratest/modules/files"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+)
+
+func TestRenderTemplateInProcessBasicExample(t *testing.T) {
+	t.Parallel()
+
+	options := &Options{
+		SetValues: map[string]string{
+			"containerImageRepo": "nginx",
+			"containerImageTag":  "1.15.8",
+		},
+		KubectlOptions: k8s.NewKubectlOptions("", "", "render-ns"),
+	}
+
+	output := RenderTemplateInProcess(t, options, "../../examples/helm-basic-example", "test", []string{"templates/deployment.yaml"})
+	var deployment appsv1.Deployment
+	UnmarshalK8SYaml(t, output, &deployment)
+	assert.Equal(t, "test-helm-basic-example", deployment.Name)
+	assert.Equal(t, "render-ns", deployment.Namespace)
+	assert.Equal(t, "nginx:1.15.8", deployment.Spec.Template.Spec.Containers[0].Image)
+
+	result := RenderTemplateObjectsInProcess(t, options, "../../examples/helm-basic-example", "test", nil)
+	assert.Len(t, result.Objects, 2)
+	renderedDeployment := Get[appsv1.Deployment](t, result, RenderedObjectFilter{Name: "test-helm-basic-example"})
+	assert.Equal(t, "nginx:1.15.8", renderedDeployment.Spec.Template.Spec.Containers[0].Image)
+	service, err := result.FindOneE(RenderedObjectFilter{Kind: "Service"})
+	require.NoError(t, err)
+	assert.Equal(t, "templates/service.yaml", service.SourceTemplate)
+	typedService, err := service.TypedObjectE()
+	require.NoError(t, err)
+	assert.IsType(t, &corev1.Service{}, typedService)
+}
+
+func TestRenderTemplateInProcessErrors(t *testing.T) {
+	t.Parallel()
+
+	// The basic example requires the container image values.
+	_, err := RenderTemplateInProcessE(t, &Options{}, "../../examples/helm-basic-example", "test", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "containerImageRepo is required")
+
+	options := &Options{SetValues: map[string]string{"containerImageRepo": "nginx", "containerImageTag": "1.15.8"}}
+	_, err = RenderTemplateInProcessE(t, options, "../../examples/helm-basic-example", "test", []string{"templates/missing.yaml"})
+	require.Error(t, err)
+
+	_, err = RenderTemplateInProcessE(t, options, "../../examples/missing-chart", "test", nil)
+	require.Error(t, err)
+}
+
+func TestRenderTemplateInProcessBuildsDependencies(t *testing.T) {
+	t.Parallel()
+
+	// Copy both charts next to each other, since the dependency is referenced with a relative file:// url and building
+	// it writes into the charts/ directory.
+	examplesDir := t.TempDir()
+	for _, chart := range []string{"helm-basic-example", "helm-dependency-example"} {
+		require.NoError(t, os.Mkdir(filepath.Join(examplesDir, chart), 0755))
+		require.NoError(t, files.CopyFolderContents(filepath.Join("../../examples", chart), filepath.Join(examplesDir, chart)))
+	}
+	chartDir := filepath.Join(examplesDir, "helm-dependency-example")
+
+	options := &Options{
+		SetValues: map[string]string{
+			"containerImageRepo":       "nginx",
+			"containerImageTag":        "1.15.8",
+			"basic.enabled":            "true",
+			"basic.containerImageRepo": "nginx",
+			"basic.containerImageTag":  "1.15.9",
+		},
+	}
+	_, err := RenderTemplateInProcessE(t, options, chartDir, "test", nil)
+	require.Error(t, err)
+
+	options.BuildDependencies = true
+	result := RenderTemplateObjectsInProcess(t, options, chartDir, "test", []string{"charts/basic/templates/deployment.yaml"})
+	require.Len(t, result.Objects, 1)
+	deployment := Get[appsv1.Deployment](t, result, RenderedObjectFilter{Name: "test-basic"})
+	assert.Equal(t, "nginx:1.15.9", deployment.Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestRenderTemplateInProcessCapabilities(t *testing.T) {
+	t.Parallel()
+
+	chartDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(chartDir, "templates"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: capabilities\nversion: 0.1.0\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(chartDir, "templates", "configmap.yaml"), []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: capabilities
+data:
+  kubeVersion: {{ .Capabilities.KubeVersion.Version | quote }}
+  hasExample: {{ .Capabilities.APIVersions.Has "example.com/v1" | quote }}
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(chartDir, "templates", "widget.yaml"), []byte(`apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: capabilities
+spec:
+  size: large
+`), 0644))
+
+	options := &Options{KubeVersion: "v1.27.3", APIVersions: []string{"example.com/v1"}}
+	result := RenderTemplateObjectsInProcess(t, options, chartDir, "test", nil)
+	data := Get[corev1.ConfigMap](t, result, RenderedObjectFilter{Name: "capabilities"}).Data
+	assert.Equal(t, "v1.27.3", data["kubeVersion"])
+	assert.Equal(t, "true", data["hasExample"])
+
+	// Custom resources are not in the client-go scheme, so they are left unstructured.
+	widget, err := result.FindOneE(RenderedObjectFilter{Kind: "Widget"})
+	require.NoError(t, err)
+	typedWidget, err := widget.TypedObjectE()
+	require.NoError(t, err)
+	require.IsType(t, &unstructured.Unstructured{}, typedWidget)
+	size, _, err := unstructured.NestedString(typedWidget.(*unstructured.Unstructured).Object, "spec", "size")
+	require.NoError(t, err)
+	assert.Equal(t, "large", size)
+}
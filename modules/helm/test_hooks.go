@@ -0,0 +1,130 @@
+package helm
+
+import (
+	"fmt"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// HelmTestResult is the outcome of a single `helm test` hook of a release.
+type HelmTestResult struct {
+	Name   string
+	Kind   string // Pod or Job
+	Phase  string // The phase of the hook run reported by helm: Succeeded, Failed, Running or Unknown.
+	Passed bool
+	// The logs of each container of the pods run by the hook, keyed by POD/CONTAINER. Logs can not be fetched for pods
+	// that were deleted by a hook-delete-policy, in which case LogsError is set.
+	Logs      map[string]string
+	LogsError error
+}
+
+// RunHelmTests runs `helm test` for the given release, and returns the outcome of every test hook along with the
+// logs of its pods. Use Options.ExtraArgs["test"] to pass extra arguments, such as --timeout or --filter. This will
+// fail the test, with the logs of the failed hooks attached, if any test hook fails.
+func RunHelmTests(t testing.TestingT, options *Options, releaseName string) []HelmTestResult {
+	results, err := RunHelmTestsE(t, options, releaseName)
+	require.NoError(t, err)
+	return results
+}
+
+// RunHelmTestsE runs `helm test` for the given release, and returns the outcome of every test hook along with the
+// logs of its pods. Use Options.ExtraArgs["test"] to pass extra arguments, such as --timeout or --filter. Along with
+// the results, this returns a HelmTestsFailed error with the logs of the failed hooks if any test hook fails.
+func RunHelmTestsE(t testing.TestingT, options *Options, releaseName string) ([]HelmTestResult, error) {
+	args := append([]string{releaseName}, options.ExtraArgs["test"]...)
+	// helm exits with an error when a test fails, but the outcome of each hook is read from the release status below.
+	_, testErr := RunHelmCommandAndGetOutputE(t, options, "test", args...)
+
+	status, err := GetReleaseStatusE(t, options, releaseName)
+	if err != nil {
+		return nil, err
+	}
+	kubectlOptions := releaseKubectlOptions(options, status.Namespace)
+
+	results := []HelmTestResult{}
+	for _, hook := range status.Hooks {
+		if !isTestHook(hook) || hook.Phase == "" || hook.Phase == "Unknown" {
+			// Skip hooks that are not tests, or that were not run, e.g. because of --filter.
+			continue
+		}
+		result := HelmTestResult{
+			Name:   hook.Name,
+			Kind:   hook.Kind,
+			Phase:  hook.Phase,
+			Passed: hook.Phase == "Succeeded",
+			Logs:   map[string]string{},
+		}
+		result.LogsError = collectTestHookLogsE(t, kubectlOptions, hook, result.Logs)
+		if result.LogsError != nil {
+			logger.Logf(t, "Could not get the logs of test %s of release %s: %s", hook.Name, releaseName, result.LogsError)
+		}
+		results = append(results, result)
+	}
+
+	for _, result := range results {
+		if !result.Passed {
+			return results, HelmTestsFailed{ReleaseName: releaseName, Results: results}
+		}
+	}
+	// If helm failed but no hook did, the tests could not be run at all.
+	if testErr != nil {
+		return results, testErr
+	}
+	return results, nil
+}
+
+// isTestHook returns true if the hook is run by `helm test`.
+func isTestHook(hook ReleaseHook) bool {
+	for _, event := range hook.Events {
+		if event == "test" || event == "test-success" {
+			return true
+		}
+	}
+	return false
+}
+
+// releaseKubectlOptions returns a copy of the kubectl options of the helm options, targeting the given namespace.
+func releaseKubectlOptions(options *Options, namespace string) *k8s.KubectlOptions {
+	if options.KubectlOptions == nil {
+		return k8s.NewKubectlOptions("", "", namespace)
+	}
+	kubectlOptions := *options.KubectlOptions
+	kubectlOptions.Namespace = namespace
+	return &kubectlOptions
+}
+
+// collectTestHookLogsE fetches the logs of every container of the pods run by the given Pod or Job test hook.
+func collectTestHookLogsE(t testing.TestingT, kubectlOptions *k8s.KubectlOptions, hook ReleaseHook, logs map[string]string) error {
+	var filters metav1.ListOptions
+	switch hook.Kind {
+	case "Pod":
+		filters = metav1.ListOptions{FieldSelector: fmt.Sprintf("metadata.name=%s", hook.Name)}
+	case "Job":
+		filters = metav1.ListOptions{LabelSelector: fmt.Sprintf("job-name=%s", hook.Name)}
+	default:
+		return nil
+	}
+	pods, err := k8s.ListPodsE(t, kubectlOptions, filters)
+	if err != nil {
+		return err
+	}
+	if len(pods) == 0 {
+		return TestHookPodsNotFound{HookName: hook.Name}
+	}
+	for i := range pods {
+		pod := &pods[i]
+		for _, container := range pod.Spec.Containers {
+			podLogs, err := k8s.GetPodLogsE(t, kubectlOptions, pod, container.Name)
+			if err != nil {
+				return err
+			}
+			logs[fmt.Sprintf("%s/%s", pod.Name, container.Name)] = podLogs
+		}
+	}
+	return nil
+}
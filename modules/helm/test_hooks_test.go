@@ -0,0 +1,99 @@
+//go:build kubeall || helm
+// +build kubeall helm
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests, and further differentiate helm
+// tests. This is done because minikube is heavy and can interfere with docker related tests in terratest. Similarly,
+// helm can overload the minikube system and thus interfere with the other kubernetes tests. To avoid overloading the
+// system, we run the kubernetes tests and helm tests separately from the others.
+
+package helm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/random"
+)
+
+const testHookPodTemplate = `apiVersion: v1
+kind: Pod
+metadata:
+  name: {{ .Release.Name }}-%s
+  annotations:
+    "helm.sh/hook": test
+spec:
+  restartPolicy: Never
+  containers:
+    - name: test
+      image: busybox:1.36
+      command: ["sh", "-c", "echo %s; exit %d"]
+`
+
+// writeTestHookChart writes a chart with a test hook that passes, and one that fails if failing is true.
+func writeTestHookChart(t *testing.T, failing bool) string {
+	chartDir := t.TempDir()
+	chartFiles := map[string]string{
+		"Chart.yaml":                "apiVersion: v2\nname: test-hooks\nversion: 0.1.0\n",
+		"templates/tests/pass.yaml": fmt.Sprintf(testHookPodTemplate, "pass", "all good", 0),
+	}
+	if failing {
+		chartFiles["templates/tests/fail.yaml"] = fmt.Sprintf(testHookPodTemplate, "fail", "something broke", 1)
+	}
+	for name, content := range chartFiles {
+		path := filepath.Join(chartDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	return chartDir
+}
+
+func TestRunHelmTests(t *testing.T) {
+	t.Parallel()
+
+	namespaceName := fmt.Sprintf("helm-tests-%s", strings.ToLower(random.UniqueId()))
+	kubectlOptions := k8s.NewKubectlOptions("", "", namespaceName)
+	k8s.CreateNamespace(t, kubectlOptions, namespaceName)
+	defer k8s.DeleteNamespace(t, kubectlOptions, namespaceName)
+
+	options := &Options{KubectlOptions: kubectlOptions}
+	releaseName := fmt.Sprintf("helm-tests-%s", strings.ToLower(random.UniqueId()))
+	defer Delete(t, options, releaseName, true)
+	Install(t, options, writeTestHookChart(t, false), releaseName)
+
+	results := RunHelmTests(t, options, releaseName)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Passed)
+	assert.Equal(t, "Pod", results[0].Kind)
+	assert.Contains(t, results[0].Logs[fmt.Sprintf("%s-pass/test", releaseName)], "all good")
+}
+
+func TestRunHelmTestsReportsFailedHookLogs(t *testing.T) {
+	t.Parallel()
+
+	namespaceName := fmt.Sprintf("helm-tests-%s", strings.ToLower(random.UniqueId()))
+	kubectlOptions := k8s.NewKubectlOptions("", "", namespaceName)
+	k8s.CreateNamespace(t, kubectlOptions, namespaceName)
+	defer k8s.DeleteNamespace(t, kubectlOptions, namespaceName)
+
+	options := &Options{KubectlOptions: kubectlOptions}
+	releaseName := fmt.Sprintf("helm-tests-%s", strings.ToLower(random.UniqueId()))
+	defer Delete(t, options, releaseName, true)
+	Install(t, options, writeTestHookChart(t, true), releaseName)
+
+	// helm stops at the first failed hook, so run the failing one alone to get a deterministic result.
+	options.ExtraArgs = map[string][]string{"test": {"--filter", fmt.Sprintf("name=%s-fail", releaseName)}}
+	results, err := RunHelmTestsE(t, options, releaseName)
+	require.Error(t, err)
+	assert.IsType(t, HelmTestsFailed{}, err)
+	assert.Contains(t, err.Error(), "something broke")
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Passed)
+	assert.Equal(t, "Failed", results[0].Phase)
+}
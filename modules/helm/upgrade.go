@@ -18,7 +18,7 @@ func Upgrade(t testing.TestingT, options *Options, chart string, releaseName str
 // UpgradeE will upgrade the release and chart will be deployed with the lastest configuration.
 func UpgradeE(t testing.TestingT, options *Options, chart string, releaseName string) error {
 	// If the chart refers to a path, convert to absolute path. Otherwise, pass straight through as it may be a remote
-	// chart.
+	// chart, such as REPO/CHART or an oci:// reference.
 	if files.FileExists(chart) {
 		absChartDir, err := filepath.Abs(chart)
 		if err != nil {
@@ -12,6 +12,8 @@ import (
 type GetResponse struct {
 	StatusCode int
 	Body       string
+	Time       time.Time // When the request was sent.
+	Err        error     // The error making the request, if any.
 }
 
 // Continuously check the given URL every 1 second until the stopChecking channel receives a signal to stop.
@@ -23,6 +25,28 @@ func ContinuouslyCheckUrl(
 	url string,
 	stopChecking <-chan bool,
 	sleepBetweenChecks time.Duration,
+) (*sync.WaitGroup, <-chan GetResponse) {
+	return continuouslyCheckUrl(t, url, stopChecking, sleepBetweenChecks, true)
+}
+
+// ContinuouslyCheckUrlE is the same as ContinuouslyCheckUrl, but does not fail the test when a request fails or
+// returns a non-200 response. Failures are only reported on the responses channel, with their time and error, so that
+// the caller can decide how much downtime is acceptable.
+func ContinuouslyCheckUrlE(
+	t testing.TestingT,
+	url string,
+	stopChecking <-chan bool,
+	sleepBetweenChecks time.Duration,
+) (*sync.WaitGroup, <-chan GetResponse) {
+	return continuouslyCheckUrl(t, url, stopChecking, sleepBetweenChecks, false)
+}
+
+func continuouslyCheckUrl(
+	t testing.TestingT,
+	url string,
+	stopChecking <-chan bool,
+	sleepBetweenChecks time.Duration,
+	failOnError bool,
 ) (*sync.WaitGroup, <-chan GetResponse) {
 	var wg sync.WaitGroup
 	wg.Add(1)
@@ -36,15 +60,19 @@ func ContinuouslyCheckUrl(
 				logger.Logf(t, "Got signal to stop downtime checks for URL %s.\n", url)
 				return
 			case <-time.After(sleepBetweenChecks):
+				requestTime := time.Now()
 				statusCode, body, err := HttpGetE(t, url, &tls.Config{})
 				// Non-blocking send, defaulting to logging a warning if there is no channel reader
 				select {
-				case responses <- GetResponse{StatusCode: statusCode, Body: body}:
+				case responses <- GetResponse{StatusCode: statusCode, Body: body, Time: requestTime, Err: err}:
 					// do nothing since all we want to do is send the response
 				default:
 					logger.Logf(t, "WARNING: ContinuouslyCheckUrl responses channel buffer is full")
 				}
 				logger.Logf(t, "Got response %d and err %v from URL at %s", statusCode, err, url)
+				if !failOnError {
+					continue
+				}
 				if err != nil {
 					// We use Errorf instead of Fatalf here because Fatalf is not goroutine safe, while Errorf is. Refer
 					// to the docs on `T`: https://godoc.org/testing#T
@@ -0,0 +1,374 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// ServerSideApplyFieldManager is the field manager used to own the fields set by ServerSideApply.
+const ServerSideApplyFieldManager = "terratest"
+
+// AppliedObject identifies a Kubernetes object that was created or updated by ServerSideApply.
+type AppliedObject struct {
+	// GroupVersionKind is the API group, version and kind of the object.
+	GroupVersionKind schema.GroupVersionKind
+
+	// Resource is the API resource the kind maps to, used to address the object in the API.
+	Resource schema.GroupVersionResource
+
+	// Namespace of the object. This is empty for cluster scoped objects.
+	Namespace string
+
+	// Name of the object.
+	Name string
+
+	// Object is the state of the object as returned by the API server after it was applied.
+	Object *unstructured.Unstructured
+}
+
+// String returns a human readable identifier of the object, e.g. apps/v1, Kind=Deployment default/nginx.
+func (obj AppliedObject) String() string {
+	if obj.Namespace == "" {
+		return fmt.Sprintf("%s %s", obj.GroupVersionKind, obj.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", obj.GroupVersionKind, obj.Namespace, obj.Name)
+}
+
+// ParseManifests parses a multi-document YAML (or JSON) string of Kubernetes manifests into unstructured objects, in
+// the order they appear. Empty documents are skipped. This will fail the test if there is an error.
+func ParseManifests(t testing.TestingT, manifests string) []*unstructured.Unstructured {
+	objects, err := ParseManifestsE(t, manifests)
+	require.NoError(t, err)
+	return objects
+}
+
+// ParseManifestsE parses a multi-document YAML (or JSON) string of Kubernetes manifests into unstructured objects, in
+// the order they appear. Empty documents are skipped.
+func ParseManifestsE(t testing.TestingT, manifests string) ([]*unstructured.Unstructured, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(strings.NewReader(manifests), 4096)
+	objects := []*unstructured.Unstructured{}
+	for {
+		var data map[string]interface{}
+		err := decoder.Decode(&data)
+		if err == io.EOF {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		obj := &unstructured.Unstructured{Object: data}
+		// A List (e.g. the output of kubectl get -o yaml) is flattened into its items.
+		if obj.IsList() {
+			list, err := obj.ToList()
+			if err != nil {
+				return nil, err
+			}
+			for i := range list.Items {
+				objects = append(objects, &list.Items[i])
+			}
+			continue
+		}
+		if obj.GetKind() == "" || obj.GetAPIVersion() == "" {
+			return nil, MalformedManifest{Reason: fmt.Sprintf("object %q is missing apiVersion or kind", obj.GetName())}
+		}
+		objects = append(objects, obj)
+	}
+}
+
+// ServerSideApply will take in a file path, or a directory of manifest files, and apply it to the cluster targeted by
+// KubectlOptions using server-side apply through the Kubernetes API, without the kubectl binary. Returns the list of
+// applied objects, which can be passed to WaitUntilObjectsReady and DeleteAppliedObjects. If there are any errors,
+// fail the test immediately.
+func ServerSideApply(t testing.TestingT, options *KubectlOptions, configPath string) []AppliedObject {
+	objects, err := ServerSideApplyE(t, options, configPath)
+	require.NoError(t, err)
+	return objects
+}
+
+// ServerSideApplyE will take in a file path, or a directory of manifest files, and apply it to the cluster targeted by
+// KubectlOptions using server-side apply through the Kubernetes API, without the kubectl binary. Returns the list of
+// applied objects, which can be passed to WaitUntilObjectsReady and DeleteAppliedObjects.
+func ServerSideApplyE(t testing.TestingT, options *KubectlOptions, configPath string) ([]AppliedObject, error) {
+	manifests, err := readManifestsE(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return ServerSideApplyFromStringE(t, options, manifests)
+}
+
+// ServerSideApplyFromString will take in a kubernetes resource config as a string and apply it on the cluster
+// specified by the provided kubectl options using server-side apply. Returns the list of applied objects. If there are
+// any errors, fail the test immediately.
+func ServerSideApplyFromString(t testing.TestingT, options *KubectlOptions, configData string) []AppliedObject {
+	objects, err := ServerSideApplyFromStringE(t, options, configData)
+	require.NoError(t, err)
+	return objects
+}
+
+// ServerSideApplyFromStringE will take in a kubernetes resource config as a string and apply it on the cluster
+// specified by the provided kubectl options using server-side apply. Returns the list of applied objects, including
+// the ones that were applied before an error occurred, so that they can still be cleaned up.
+func ServerSideApplyFromStringE(t testing.TestingT, options *KubectlOptions, configData string) ([]AppliedObject, error) {
+	objects, err := ParseManifestsE(t, configData)
+	if err != nil {
+		return nil, err
+	}
+
+	clients, err := newObjectClientsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []AppliedObject{}
+	for _, obj := range objects {
+		appliedObject, err := clients.applyE(options, obj)
+		if err != nil {
+			return applied, err
+		}
+		logger.Logf(t, "Applied %s", appliedObject)
+		applied = append(applied, appliedObject)
+	}
+	return applied, nil
+}
+
+// WaitUntilObjectsReady waits until all the given objects are ready, using the readiness rules of IsObjectReady,
+// retrying the check for the specified amount of times, sleeping for the provided duration between each try. This
+// will fail the test if there is an error or if the check times out.
+func WaitUntilObjectsReady(t testing.TestingT, options *KubectlOptions, objects []AppliedObject, retries int, sleepBetweenRetries time.Duration) {
+	require.NoError(t, WaitUntilObjectsReadyE(t, options, objects, retries, sleepBetweenRetries))
+}
+
+// WaitUntilObjectsReadyE waits until all the given objects are ready, using the readiness rules of IsObjectReady,
+// retrying the check for the specified amount of times, sleeping for the provided duration between each try.
+func WaitUntilObjectsReadyE(t testing.TestingT, options *KubectlOptions, objects []AppliedObject, retries int, sleepBetweenRetries time.Duration) error {
+	clients, err := newObjectClientsE(t, options)
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range objects {
+		statusMsg := fmt.Sprintf("Wait for %s to be ready.", obj)
+		message, err := retry.DoWithRetryE(
+			t,
+			statusMsg,
+			retries,
+			sleepBetweenRetries,
+			func() (string, error) {
+				current, err := clients.getE(obj)
+				if err != nil {
+					return "", err
+				}
+				ready, reason, err := objectReadiness(current)
+				if err != nil {
+					return "", retry.FatalError{Underlying: err}
+				}
+				if !ready {
+					return "", ObjectNotReady{Object: obj.String(), Reason: reason}
+				}
+				return fmt.Sprintf("%s is now ready", obj), nil
+			},
+		)
+		if err != nil {
+			logger.Logf(t, "Timedout waiting for %s to be ready: %s", obj, err)
+			return err
+		}
+		logger.Logf(t, message)
+	}
+	return nil
+}
+
+// DeleteAppliedObjects deletes exactly the given objects, as returned by ServerSideApply, in the reverse order they
+// were applied. Objects that no longer exist are ignored. If there are any errors, fail the test immediately.
+func DeleteAppliedObjects(t testing.TestingT, options *KubectlOptions, objects []AppliedObject) {
+	require.NoError(t, DeleteAppliedObjectsE(t, options, objects))
+}
+
+// DeleteAppliedObjectsE deletes exactly the given objects, as returned by ServerSideApply, in the reverse order they
+// were applied. Objects that no longer exist are ignored.
+func DeleteAppliedObjectsE(t testing.TestingT, options *KubectlOptions, objects []AppliedObject) error {
+	clients, err := newObjectClientsE(t, options)
+	if err != nil {
+		return err
+	}
+
+	propagationPolicy := metav1.DeletePropagationBackground
+	for i := len(objects) - 1; i >= 0; i-- {
+		obj := objects[i]
+		err := clients.resourceFor(obj.Resource, obj.Namespace).Delete(
+			context.Background(),
+			obj.Name,
+			metav1.DeleteOptions{PropagationPolicy: &propagationPolicy},
+		)
+		if apierrors.IsNotFound(err) {
+			logger.Logf(t, "%s was already deleted", obj)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		logger.Logf(t, "Deleted %s", obj)
+	}
+	return nil
+}
+
+// readManifestsE reads the manifests at the given path. If the path is a directory, all the .yaml, .yml and .json files
+// it contains are concatenated as separate documents, in lexical order.
+func readManifestsE(configPath string) (string, error) {
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(configPath)
+		return string(data), err
+	}
+
+	entries, err := os.ReadDir(configPath)
+	if err != nil {
+		return "", err
+	}
+	paths := []string{}
+	for _, entry := range entries {
+		switch filepath.Ext(entry.Name()) {
+		case ".yaml", ".yml", ".json":
+			if !entry.IsDir() {
+				paths = append(paths, filepath.Join(configPath, entry.Name()))
+			}
+		}
+	}
+	sort.Strings(paths)
+
+	var manifests bytes.Buffer
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		manifests.WriteString("\n---\n")
+		manifests.Write(data)
+	}
+	return manifests.String(), nil
+}
+
+// objectClients bundles the clients needed to work with arbitrary kinds of objects through the API.
+type objectClients struct {
+	dynamicClient dynamic.Interface
+	mapper        *restmapper.DeferredDiscoveryRESTMapper
+}
+
+// newObjectClientsE creates the dynamic client and discovery based REST mapper for the cluster targeted by the given
+// options.
+func newObjectClientsE(t testing.TestingT, options *KubectlOptions) (*objectClients, error) {
+	config, err := GetRestConfigFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := GetKubernetesClientFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(clientset.Discovery()))
+	return &objectClients{dynamicClient: dynamicClient, mapper: mapper}, nil
+}
+
+// mappingForE returns the REST mapping of the given kind. Since the manifests may define new kinds through
+// CustomResourceDefinitions, the discovery cache is refreshed once if the kind is unknown.
+func (clients *objectClients) mappingForE(gvk schema.GroupVersionKind) (*meta.RESTMapping, error) {
+	mapping, err := clients.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		clients.mapper.Reset()
+		mapping, err = clients.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	return mapping, err
+}
+
+// resourceFor returns the dynamic client for the given resource, scoped to the namespace if one is given.
+func (clients *objectClients) resourceFor(resource schema.GroupVersionResource, namespace string) dynamic.ResourceInterface {
+	if namespace == "" {
+		return clients.dynamicClient.Resource(resource)
+	}
+	return clients.dynamicClient.Resource(resource).Namespace(namespace)
+}
+
+// applyE applies a single object using server-side apply, defaulting the namespace of namespaced objects to the one
+// in the options.
+func (clients *objectClients) applyE(options *KubectlOptions, obj *unstructured.Unstructured) (AppliedObject, error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := clients.mappingForE(gvk)
+	if err != nil {
+		return AppliedObject{}, err
+	}
+
+	namespace := ""
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		namespace = obj.GetNamespace()
+		if namespace == "" {
+			namespace = options.Namespace
+		}
+		if namespace == "" {
+			namespace = metav1.NamespaceDefault
+		}
+		obj.SetNamespace(namespace)
+	}
+
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return AppliedObject{}, err
+	}
+	force := true
+	result, err := clients.resourceFor(mapping.Resource, namespace).Patch(
+		context.Background(),
+		obj.GetName(),
+		types.ApplyPatchType,
+		data,
+		metav1.PatchOptions{FieldManager: ServerSideApplyFieldManager, Force: &force},
+	)
+	if err != nil {
+		return AppliedObject{}, err
+	}
+
+	return AppliedObject{
+		GroupVersionKind: gvk,
+		Resource:         mapping.Resource,
+		Namespace:        namespace,
+		Name:             obj.GetName(),
+		Object:           result,
+	}, nil
+}
+
+// getE fetches the current state of the given object.
+func (clients *objectClients) getE(obj AppliedObject) (*unstructured.Unstructured, error) {
+	return clients.resourceFor(obj.Resource, obj.Namespace).Get(context.Background(), obj.Name, metav1.GetOptions{})
+}
@@ -0,0 +1,65 @@
+//go:build kubeall || kubernetes
+// +build kubeall kubernetes
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests. This is done because minikube
+// is heavy and can interfere with docker related tests in terratest. Specifically, many of the tests start to fail with
+// `connection refused` errors from `minikube`. To avoid overloading the system, we run the kubernetes tests and helm
+// tests separately from the others. This may not be necessary if you have a sufficiently powerful machine.  We
+// recommend at least 4 cores and 16GB of RAM if you want to run all the tests together.
+
+package k8s
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+)
+
+func TestParseManifestsSplitsDocuments(t *testing.T) {
+	t.Parallel()
+
+	objects := ParseManifests(t, fmt.Sprintf(ExampleDeploymentYAMLTemplate, "parse-test")+"\n---\n# empty document\n")
+	require.Len(t, objects, 2)
+	require.Equal(t, "Namespace", objects[0].GetKind())
+	require.Equal(t, "Deployment", objects[1].GetKind())
+	require.Equal(t, "nginx-deployment", objects[1].GetName())
+}
+
+func TestParseManifestsERejectsObjectsWithoutKind(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseManifestsE(t, "apiVersion: v1\nmetadata:\n  name: foo\n")
+	require.IsType(t, MalformedManifest{}, err)
+}
+
+func TestServerSideApplyWaitsAndDeletesAppliedObjects(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(ExampleDeploymentYAMLTemplate, uniqueID)
+
+	objects := ServerSideApplyFromString(t, options, configData)
+	defer DeleteAppliedObjects(t, options, objects)
+	require.Len(t, objects, 2)
+	require.Equal(t, "Namespace", objects[0].GroupVersionKind.Kind)
+	require.Equal(t, "", objects[0].Namespace)
+	require.Equal(t, "Deployment", objects[1].GroupVersionKind.Kind)
+	require.Equal(t, uniqueID, objects[1].Namespace)
+	require.Equal(t, "nginx-deployment", objects[1].Name)
+
+	WaitUntilObjectsReady(t, options, objects, 60, 1*time.Second)
+	require.True(t, IsDeploymentAvailable(GetDeployment(t, options, "nginx-deployment")))
+
+	// Applying the same manifests again is a no-op that returns the same objects.
+	require.Len(t, ServerSideApplyFromString(t, options, configData), 2)
+
+	DeleteAppliedObjects(t, options, objects[1:])
+	_, err := GetDeploymentE(t, options, "nginx-deployment")
+	require.Error(t, err)
+}
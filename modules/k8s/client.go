@@ -25,6 +25,23 @@ func GetKubernetesClientE(t testing.TestingT) (*kubernetes.Clientset, error) {
 
 // GetKubernetesClientFromOptionsE returns a Kubernetes API client given a configured KubectlOptions object.
 func GetKubernetesClientFromOptionsE(t testing.TestingT, options *KubectlOptions) (*kubernetes.Clientset, error) {
+	config, err := GetRestConfigFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return clientset, nil
+}
+
+// GetRestConfigFromOptionsE returns the rest config used to talk to the Kubernetes API given a configured
+// KubectlOptions object. This is useful for client-go libraries that need the raw config rather than a Clientset (e.g.,
+// remotecommand or the dynamic client).
+func GetRestConfigFromOptionsE(t testing.TestingT, options *KubectlOptions) (*rest.Config, error) {
 	var err error
 	var config *rest.Config
 
@@ -55,10 +72,5 @@ func GetKubernetesClientFromOptionsE(t testing.TestingT, options *KubectlOptions
 		}
 	}
 
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return nil, err
-	}
-
-	return clientset, nil
+	return config, nil
 }
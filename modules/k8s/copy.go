@@ -0,0 +1,314 @@
+package k8s
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// CopyToPod copies the file or directory at localPath on the host into a container of the Pod at podPath, the same
+// way `kubectl cp` does: the content is streamed as a tar archive to the exec API, so the container image must contain
+// a tar binary. Pass the container name if there are more containers in the Pod or set to "" if there is only one. The
+// copy is retried up to the specified number of times if the connection to the Pod fails. This will fail the test if
+// there is an error.
+func CopyToPod(
+	t testing.TestingT,
+	options *KubectlOptions,
+	podName string,
+	containerName string,
+	localPath string,
+	podPath string,
+	retries int,
+	sleepBetweenRetries time.Duration,
+) {
+	require.NoError(t, CopyToPodE(t, options, podName, containerName, localPath, podPath, retries, sleepBetweenRetries))
+}
+
+// CopyToPodE copies the file or directory at localPath on the host into a container of the Pod at podPath, the same
+// way `kubectl cp` does: the content is streamed as a tar archive to the exec API, so the container image must contain
+// a tar binary. Pass the container name if there are more containers in the Pod or set to "" if there is only one. The
+// copy is retried up to the specified number of times if the connection to the Pod fails.
+func CopyToPodE(
+	t testing.TestingT,
+	options *KubectlOptions,
+	podName string,
+	containerName string,
+	localPath string,
+	podPath string,
+	retries int,
+	sleepBetweenRetries time.Duration,
+) error {
+	podPath = path.Clean(podPath)
+	destDir := path.Dir(podPath)
+
+	// Build the archive up front so that it can be replayed on each retry.
+	var archive bytes.Buffer
+	if err := writeTarArchive(&archive, localPath, path.Base(podPath)); err != nil {
+		return err
+	}
+
+	statusMsg := fmt.Sprintf("Copy %s to %s in pod %s", localPath, podPath, podName)
+	_, err := retry.DoWithRetryE(
+		t,
+		statusMsg,
+		retries,
+		sleepBetweenRetries,
+		func() (string, error) {
+			if err := runCopyCommandInPodE(t, options, podName, containerName, nil, "mkdir", "-p", destDir); err != nil {
+				return "", err
+			}
+			stdin := bytes.NewReader(archive.Bytes())
+			if err := runCopyCommandInPodE(t, options, podName, containerName, stdin, "tar", "-xmf", "-", "-C", destDir); err != nil {
+				return "", err
+			}
+			return "Copied files to pod", nil
+		},
+	)
+	return err
+}
+
+// CopyFromPod copies the file or directory at podPath in a container of the Pod to localPath on the host, the same way
+// `kubectl cp` does: the content is streamed as a tar archive from the exec API, so the container image must contain a
+// tar binary. Pass the container name if there are more containers in the Pod or set to "" if there is only one. The
+// copy is retried up to the specified number of times if the connection to the Pod fails. This will fail the test if
+// there is an error.
+func CopyFromPod(
+	t testing.TestingT,
+	options *KubectlOptions,
+	podName string,
+	containerName string,
+	podPath string,
+	localPath string,
+	retries int,
+	sleepBetweenRetries time.Duration,
+) {
+	require.NoError(t, CopyFromPodE(t, options, podName, containerName, podPath, localPath, retries, sleepBetweenRetries))
+}
+
+// CopyFromPodE copies the file or directory at podPath in a container of the Pod to localPath on the host, the same way
+// `kubectl cp` does: the content is streamed as a tar archive from the exec API, so the container image must contain a
+// tar binary. Pass the container name if there are more containers in the Pod or set to "" if there is only one. The
+// copy is retried up to the specified number of times if the connection to the Pod fails.
+func CopyFromPodE(
+	t testing.TestingT,
+	options *KubectlOptions,
+	podName string,
+	containerName string,
+	podPath string,
+	localPath string,
+	retries int,
+	sleepBetweenRetries time.Duration,
+) error {
+	podPath = path.Clean(podPath)
+
+	statusMsg := fmt.Sprintf("Copy %s in pod %s to %s", podPath, podName, localPath)
+	_, err := retry.DoWithRetryE(
+		t,
+		statusMsg,
+		retries,
+		sleepBetweenRetries,
+		func() (string, error) {
+			command := []string{"tar", "-cf", "-", "-C", path.Dir(podPath), path.Base(podPath)}
+			result, err := execInPodE(t, options, podName, containerName, nil, command)
+			if err != nil {
+				return "", err
+			}
+			if result.ExitCode != 0 {
+				return "", retry.FatalError{Underlying: NewExecCommandFailedError(podName, command, result)}
+			}
+			if err := extractTarArchive(strings.NewReader(result.Stdout), path.Base(podPath), localPath); err != nil {
+				return "", retry.FatalError{Underlying: err}
+			}
+			return "Copied files from pod", nil
+		},
+	)
+	return err
+}
+
+// runCopyCommandInPodE runs one of the helper commands used to copy files and converts a non zero exit code into a
+// fatal error, since there is no point in retrying a command that ran but failed (e.g., tar is missing from the image).
+func runCopyCommandInPodE(
+	t testing.TestingT,
+	options *KubectlOptions,
+	podName string,
+	containerName string,
+	stdin io.Reader,
+	command ...string,
+) error {
+	result, err := execInPodE(t, options, podName, containerName, stdin, command)
+	if err != nil {
+		return err
+	}
+	if result.ExitCode != 0 {
+		return retry.FatalError{Underlying: NewExecCommandFailedError(podName, command, result)}
+	}
+	return nil
+}
+
+// writeTarArchive writes the file or directory at srcPath into a tar archive on the given writer. All entries in the
+// archive are rooted at archiveRoot, so that extracting the archive creates archiveRoot in the target directory.
+func writeTarArchive(writer io.Writer, srcPath string, archiveRoot string) error {
+	tarWriter := tar.NewWriter(writer)
+	srcPath = filepath.Clean(srcPath)
+
+	err := filepath.Walk(srcPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(srcPath, filePath)
+		if err != nil {
+			return err
+		}
+		name := path.Join(archiveRoot, filepath.ToSlash(relPath))
+
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(filePath)
+			if err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tarWriter, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tarWriter.Close()
+}
+
+// extractTarArchive extracts the tar archive read from the given reader into destPath, replacing the archiveRoot
+// prefix of every entry with destPath. Entries that would be written outside of destPath, either because of their name
+// or because of a symbolic link extracted before them, and symbolic links that point outside of destPath are rejected.
+func extractTarArchive(reader io.Reader, archiveRoot string, destPath string) error {
+	tarReader := tar.NewReader(reader)
+	destPath = filepath.Clean(destPath)
+	realDestPath, err := resolvePath(destPath)
+	if err != nil {
+		return err
+	}
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(header.Name)
+		if name != archiveRoot && !strings.HasPrefix(name, archiveRoot+"/") {
+			return UnsafeArchiveEntry{Name: header.Name}
+		}
+		relPath := strings.TrimPrefix(name, archiveRoot)
+		target := filepath.Join(destPath, filepath.FromSlash(relPath))
+		if !isWithinPath(destPath, target) {
+			return UnsafeArchiveEntry{Name: header.Name}
+		}
+
+		// The name is safe, but the entry may still be written through a symbolic link extracted before it, so check
+		// where it really lands.
+		realTarget, err := resolvePath(target)
+		if err != nil || !isWithinPath(realDestPath, realTarget) {
+			return UnsafeArchiveEntry{Name: header.Name}
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(header.Mode)|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(file, tarReader); err != nil {
+				file.Close()
+				return err
+			}
+			if err := file.Close(); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			linkTarget := filepath.FromSlash(header.Linkname)
+			if !filepath.IsAbs(linkTarget) {
+				linkTarget = filepath.Join(filepath.Dir(realTarget), linkTarget)
+			}
+			realLinkTarget, err := resolvePath(linkTarget)
+			if err != nil || !isWithinPath(realDestPath, realLinkTarget) {
+				return UnsafeArchiveEntry{Name: header.Name}
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// resolvePath returns the given path with the symbolic links resolved, like filepath.EvalSymlinks, but also for a path
+// that does not exist yet, in which case the symbolic links of its longest existing prefix are resolved. An error is
+// returned if the path goes through a dangling symbolic link, since it is not known where it leads.
+func resolvePath(filePath string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(filePath)
+	if err == nil {
+		return resolved, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+	if _, lstatErr := os.Lstat(filePath); lstatErr == nil {
+		return "", err
+	}
+
+	parent := filepath.Dir(filePath)
+	if parent == filePath {
+		return filePath, nil
+	}
+	resolvedParent, err := resolvePath(parent)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(resolvedParent, filepath.Base(filePath)), nil
+}
+
+// isWithinPath returns true if filePath is basePath or a path inside of it. Both paths must be clean.
+func isWithinPath(basePath string, filePath string) bool {
+	return filePath == basePath || strings.HasPrefix(filePath, strings.TrimSuffix(basePath, string(os.PathSeparator))+string(os.PathSeparator))
+}
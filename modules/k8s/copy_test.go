@@ -0,0 +1,158 @@
+package k8s
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarArchiveRoundTripsDirectory(t *testing.T) {
+	t.Parallel()
+
+	srcDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "nested"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "top.txt"), []byte("top"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "nested", "inner.txt"), []byte("inner"), 0600))
+
+	var archive bytes.Buffer
+	require.NoError(t, writeTarArchive(&archive, srcDir, "config"))
+
+	destDir := filepath.Join(t.TempDir(), "restored")
+	require.NoError(t, extractTarArchive(&archive, "config", destDir))
+
+	top, err := os.ReadFile(filepath.Join(destDir, "top.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "top", string(top))
+
+	inner, err := os.ReadFile(filepath.Join(destDir, "nested", "inner.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "inner", string(inner))
+
+	info, err := os.Stat(filepath.Join(destDir, "nested", "inner.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestTarArchiveRoundTripsSingleFile(t *testing.T) {
+	t.Parallel()
+
+	srcFile := filepath.Join(t.TempDir(), "app.conf")
+	require.NoError(t, os.WriteFile(srcFile, []byte("key=value"), 0644))
+
+	var archive bytes.Buffer
+	require.NoError(t, writeTarArchive(&archive, srcFile, "renamed.conf"))
+
+	destFile := filepath.Join(t.TempDir(), "local.conf")
+	require.NoError(t, extractTarArchive(&archive, "renamed.conf", destFile))
+
+	contents, err := os.ReadFile(destFile)
+	require.NoError(t, err)
+	assert.Equal(t, "key=value", string(contents))
+}
+
+func TestExtractTarArchiveRejectsUnsafeEntries(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title string
+		name  string
+	}{
+		{"PathTraversal", "config/../../escape.txt"},
+		{"OutsideArchiveRoot", "other/file.txt"},
+		{"SharedPrefix", "configuration/file.txt"},
+	}
+
+	for _, testCase := range testCases {
+		// capture range variable so that it doesn't update when the subtest goroutine swaps.
+		testCase := testCase
+		t.Run(testCase.title, func(t *testing.T) {
+			t.Parallel()
+
+			var archive bytes.Buffer
+			tarWriter := tar.NewWriter(&archive)
+			require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: testCase.name, Mode: 0644, Size: 1, Typeflag: tar.TypeReg}))
+			_, err := tarWriter.Write([]byte("x"))
+			require.NoError(t, err)
+			require.NoError(t, tarWriter.Close())
+
+			err = extractTarArchive(&archive, "config", filepath.Join(t.TempDir(), "dest"))
+			assert.IsType(t, UnsafeArchiveEntry{}, err)
+		})
+	}
+}
+
+func TestExtractTarArchiveRejectsSymlinksOutsideDestination(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title    string
+		linkname string
+	}{
+		{"AbsoluteLink", "/etc"},
+		{"RelativeLink", "../.."},
+		{"RelativeLinkInSubdirectory", "nested/../../outside"},
+	}
+
+	for _, testCase := range testCases {
+		// capture range variable so that it doesn't update when the subtest goroutine swaps.
+		testCase := testCase
+		t.Run(testCase.title, func(t *testing.T) {
+			t.Parallel()
+
+			var archive bytes.Buffer
+			tarWriter := tar.NewWriter(&archive)
+			require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "config/", Mode: 0755, Typeflag: tar.TypeDir}))
+			require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "config/link", Linkname: testCase.linkname, Typeflag: tar.TypeSymlink}))
+			require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "config/link/escape.txt", Mode: 0644, Size: 1, Typeflag: tar.TypeReg}))
+			_, err := tarWriter.Write([]byte("x"))
+			require.NoError(t, err)
+			require.NoError(t, tarWriter.Close())
+
+			destDir := filepath.Join(t.TempDir(), "dest")
+			err = extractTarArchive(&archive, "config", destDir)
+			assert.IsType(t, UnsafeArchiveEntry{}, err)
+			assert.NoFileExists(t, filepath.Join(destDir, "link"))
+		})
+	}
+}
+
+func TestExtractTarArchiveRejectsEntriesThroughExistingSymlinks(t *testing.T) {
+	t.Parallel()
+
+	outsideDir := t.TempDir()
+	destDir := t.TempDir()
+	require.NoError(t, os.Symlink(outsideDir, filepath.Join(destDir, "link")))
+
+	var archive bytes.Buffer
+	tarWriter := tar.NewWriter(&archive)
+	require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "config/link/escape.txt", Mode: 0644, Size: 1, Typeflag: tar.TypeReg}))
+	_, err := tarWriter.Write([]byte("x"))
+	require.NoError(t, err)
+	require.NoError(t, tarWriter.Close())
+
+	err = extractTarArchive(&archive, "config", destDir)
+	assert.IsType(t, UnsafeArchiveEntry{}, err)
+	assert.NoFileExists(t, filepath.Join(outsideDir, "escape.txt"))
+}
+
+func TestExtractTarArchiveAllowsSymlinksInsideDestination(t *testing.T) {
+	t.Parallel()
+
+	var archive bytes.Buffer
+	tarWriter := tar.NewWriter(&archive)
+	require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "config/nested/", Mode: 0755, Typeflag: tar.TypeDir}))
+	require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "config/link", Linkname: "nested", Typeflag: tar.TypeSymlink}))
+	require.NoError(t, tarWriter.WriteHeader(&tar.Header{Name: "config/link/file.txt", Mode: 0644, Size: 1, Typeflag: tar.TypeReg}))
+	_, err := tarWriter.Write([]byte("x"))
+	require.NoError(t, err)
+	require.NoError(t, tarWriter.Close())
+
+	destDir := filepath.Join(t.TempDir(), "dest")
+	require.NoError(t, extractTarArchive(&archive, "config", destDir))
+	assert.FileExists(t, filepath.Join(destDir, "nested", "file.txt"))
+}
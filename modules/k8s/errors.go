@@ -2,6 +2,7 @@ package k8s
 
 import (
 	"fmt"
+	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
@@ -281,3 +282,212 @@ type JSONPathMalformedJSONPathResultErr struct {
 func (err JSONPathMalformedJSONPathResultErr) Error() string {
 	return fmt.Sprintf("Error unmarshaling json path output: %s", err.underlyingErr)
 }
+
+// EmptyExecCommand is returned when ExecInPod is called without a command to run.
+type EmptyExecCommand struct {
+	PodName string
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err EmptyExecCommand) Error() string {
+	return fmt.Sprintf("No command provided to run in pod %s", err.PodName)
+}
+
+// ExecCommandFailed is returned when a command run in a Pod by one of the helper functions exits with a non zero exit
+// code.
+type ExecCommandFailed struct {
+	PodName  string
+	Command  []string
+	ExitCode int
+	Stderr   string
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err ExecCommandFailed) Error() string {
+	return fmt.Sprintf(
+		"Command '%s' in pod %s exited with code %d, stderr: %s",
+		strings.Join(err.Command, " "),
+		err.PodName,
+		err.ExitCode,
+		err.Stderr,
+	)
+}
+
+// NewExecCommandFailedError returns an ExecCommandFailed struct built from the result of running the command in the Pod
+func NewExecCommandFailedError(podName string, command []string, result *ExecResult) ExecCommandFailed {
+	return ExecCommandFailed{PodName: podName, Command: command, ExitCode: result.ExitCode, Stderr: result.Stderr}
+}
+
+// UnsafeArchiveEntry is returned when a tar archive copied from a Pod contains an entry that would be extracted outside
+// of the requested destination.
+type UnsafeArchiveEntry struct {
+	Name string
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err UnsafeArchiveEntry) Error() string {
+	return fmt.Sprintf("Refusing to extract archive entry %s outside of the destination path", err.Name)
+}
+
+// NoAvailablePodForResource is returned when a tunnel can not find an available pod backing the targeted resource.
+type NoAvailablePodForResource struct {
+	ResourceType KubeResourceType
+	ResourceName string
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err NoAvailablePodForResource) Error() string {
+	return fmt.Sprintf("No available pod found for resource %s/%s", err.ResourceType, err.ResourceName)
+}
+
+// NewNoAvailablePodForResourceError returns a NoAvailablePodForResource struct when no available pod backs the resource
+func NewNoAvailablePodForResourceError(resourceType KubeResourceType, resourceName string) NoAvailablePodForResource {
+	return NoAvailablePodForResource{ResourceType: resourceType, ResourceName: resourceName}
+}
+
+// TunnelClosedBeforeReady is returned when a port forwarding session stops before it was ready to accept connections.
+type TunnelClosedBeforeReady struct {
+	PodName string
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err TunnelClosedBeforeReady) Error() string {
+	return fmt.Sprintf("Port forwarding tunnel to pod %s was closed before it was ready", err.PodName)
+}
+
+// PortForwardingLost is returned when an established port forwarding session to a pod is lost.
+type PortForwardingLost struct {
+	PodName string
+	Reason  string
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err PortForwardingLost) Error() string {
+	if err.Reason == "" {
+		return fmt.Sprintf("Port forwarding tunnel to pod %s was lost", err.PodName)
+	}
+	return fmt.Sprintf("Port forwarding tunnel to pod %s was lost: %s", err.PodName, err.Reason)
+}
+
+// MalformedManifest is returned when a Kubernetes manifest can not be turned into an object.
+type MalformedManifest struct {
+	Reason string
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err MalformedManifest) Error() string {
+	return fmt.Sprintf("Malformed Kubernetes manifest: %s", err.Reason)
+}
+
+// ObjectNotReady is returned when a Kubernetes object is not ready according to the rules of IsObjectReady.
+type ObjectNotReady struct {
+	Object string
+	Reason string
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err ObjectNotReady) Error() string {
+	return fmt.Sprintf("%s is not ready: %s", err.Object, err.Reason)
+}
+
+// UnknownLocalClusterProvider is returned when LocalClusterOptions refers to a provider that is not supported.
+type UnknownLocalClusterProvider struct {
+	Provider LocalClusterProvider
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err UnknownLocalClusterProvider) Error() string {
+	return fmt.Sprintf("Unknown local cluster provider %q: expected %q or %q", err.Provider, LocalClusterProviderKind, LocalClusterProviderK3d)
+}
+
+// PermissionMatrixMismatch is returned when some actions of a permission matrix are not allowed or denied as expected.
+type PermissionMatrixMismatch struct {
+	Report PermissionMatrixReport
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err PermissionMatrixMismatch) Error() string {
+	return err.Report.String()
+}
+
+// ManifestPolicyViolations is returned when some objects of a set of manifests break the manifest policy rules.
+type ManifestPolicyViolations struct {
+	Report PolicyReport
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err ManifestPolicyViolations) Error() string {
+	return err.Report.String()
+}
+
+// KustomizationNotFound is returned when a directory does not contain a kustomization file.
+type KustomizationNotFound struct {
+	Dir string
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err KustomizationNotFound) Error() string {
+	return fmt.Sprintf("No kustomization file found in %s", err.Dir)
+}
+
+// RenderedObjectNotFound is returned when no rendered object matches a filter.
+type RenderedObjectNotFound struct {
+	Filter    RenderedObjectFilter
+	Available []RenderedObject
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err RenderedObjectNotFound) Error() string {
+	available := []string{}
+	for _, object := range err.Available {
+		available = append(available, object.String())
+	}
+	return fmt.Sprintf("No rendered object matches %s. Rendered objects: [%s]", err.Filter, strings.Join(available, ", "))
+}
+
+// MultipleRenderedObjectsFound is returned when more than one rendered object matches a filter that should select a
+// single object.
+type MultipleRenderedObjectsFound struct {
+	Filter  RenderedObjectFilter
+	Matches []RenderedObject
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err MultipleRenderedObjectsFound) Error() string {
+	matches := []string{}
+	for _, object := range err.Matches {
+		matches = append(matches, object.String())
+	}
+	return fmt.Sprintf("%d rendered objects match %s: [%s]", len(err.Matches), err.Filter, strings.Join(matches, ", "))
+}
+
+// DeploymentRolloutNotComplete is returned when the rollout of a new revision of a Deployment is not complete.
+type DeploymentRolloutNotComplete struct {
+	Deployment string
+	Reason     string
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err DeploymentRolloutNotComplete) Error() string {
+	return fmt.Sprintf("Rollout of deployment %s is not complete: %s", err.Deployment, err.Reason)
+}
+
+// RolloutProbeURLRequired is returned when zero downtime is asserted for a rollout without a ProbeURL.
+type RolloutProbeURLRequired struct {
+	Deployment string
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err RolloutProbeURLRequired) Error() string {
+	return fmt.Sprintf("A ProbeURL is required to assert zero downtime during the rollout of deployment %s: a Tunnel fails requests while the rollout replaces its pod", err.Deployment)
+}
+
+// RolloutDowntime is returned when some requests to a service failed during the rollout of a Deployment.
+type RolloutDowntime struct {
+	Report RolloutReport
+}
+
+// Error is a simple function to return a formatted error message as a string
+func (err RolloutDowntime) Error() string {
+	return err.Report.String()
+}
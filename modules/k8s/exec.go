@@ -0,0 +1,159 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// ExecResult represents the outcome of running a command in a container of a Pod.
+type ExecResult struct {
+	// Stdout is everything the command wrote to stdout.
+	Stdout string
+
+	// Stderr is everything the command wrote to stderr.
+	Stderr string
+
+	// ExitCode is the exit code of the command.
+	ExitCode int
+}
+
+// ExecInPod runs the given command in a container of the Pod using the Kubernetes API (the same API used by
+// `kubectl exec`, but without the need for the kubectl binary) and returns the stdout, stderr and exit code. Pass the
+// container name if there are more containers in the Pod or set to "" if there is only one. A non zero exit code is not
+// considered an error: check ExitCode on the returned result. This will fail the test if the command could not be run.
+func ExecInPod(t testing.TestingT, options *KubectlOptions, podName string, containerName string, command ...string) *ExecResult {
+	result, err := ExecInPodE(t, options, podName, containerName, command...)
+	require.NoError(t, err)
+	return result
+}
+
+// ExecInPodE runs the given command in a container of the Pod using the Kubernetes API (the same API used by
+// `kubectl exec`, but without the need for the kubectl binary) and returns the stdout, stderr and exit code. Pass the
+// container name if there are more containers in the Pod or set to "" if there is only one. A non zero exit code is not
+// considered an error: check ExitCode on the returned result.
+func ExecInPodE(t testing.TestingT, options *KubectlOptions, podName string, containerName string, command ...string) (*ExecResult, error) {
+	return execInPodE(t, options, podName, containerName, nil, command)
+}
+
+// ExecInPodWithRetry runs the given command in a container of the Pod using the Kubernetes API, retrying up to
+// the specified number of times if the connection to the Pod could not be established or was interrupted. A non zero
+// exit code is not retried: check ExitCode on the returned result. This will fail the test if the retries are
+// exhausted.
+func ExecInPodWithRetry(
+	t testing.TestingT,
+	options *KubectlOptions,
+	podName string,
+	containerName string,
+	retries int,
+	sleepBetweenRetries time.Duration,
+	command ...string,
+) *ExecResult {
+	result, err := ExecInPodWithRetryE(t, options, podName, containerName, retries, sleepBetweenRetries, command...)
+	require.NoError(t, err)
+	return result
+}
+
+// ExecInPodWithRetryE runs the given command in a container of the Pod using the Kubernetes API, retrying up to
+// the specified number of times if the connection to the Pod could not be established or was interrupted. A non zero
+// exit code is not retried: check ExitCode on the returned result.
+func ExecInPodWithRetryE(
+	t testing.TestingT,
+	options *KubectlOptions,
+	podName string,
+	containerName string,
+	retries int,
+	sleepBetweenRetries time.Duration,
+	command ...string,
+) (*ExecResult, error) {
+	statusMsg := fmt.Sprintf("Run command '%s' in pod %s", strings.Join(command, " "), podName)
+	result, err := retry.DoWithRetryInterfaceE(
+		t,
+		statusMsg,
+		retries,
+		sleepBetweenRetries,
+		func() (interface{}, error) {
+			return execInPodE(t, options, podName, containerName, nil, command)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*ExecResult), nil
+}
+
+// execInPodE opens a remotecommand stream to the exec subresource of the Pod, feeding it the optional stdin, and
+// collects the output of the command. Only errors establishing or maintaining the stream are returned as errors; the
+// exit code of the command is reported on the result.
+func execInPodE(
+	t testing.TestingT,
+	options *KubectlOptions,
+	podName string,
+	containerName string,
+	stdin io.Reader,
+	command []string,
+) (*ExecResult, error) {
+	if len(command) == 0 {
+		return nil, EmptyExecCommand{PodName: podName}
+	}
+
+	config, err := GetRestConfigFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := GetKubernetesClientFromOptionsE(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build a url to the exec endpoint
+	// example: https://localhost:6443/api/v1/namespaces/default/pods/nginx/exec?command=ls&stdout=true&stderr=true
+	request := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(options.Namespace).
+		Name(podName).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: containerName,
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(config, "POST", request.URL())
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Logf(t, "Running command '%s' in pod %s", strings.Join(command, " "), podName)
+
+	var stdout, stderr bytes.Buffer
+	err = executor.StreamWithContext(context.Background(), remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	result := &ExecResult{Stdout: stdout.String(), Stderr: stderr.String()}
+	if err != nil {
+		exitErr, isExitErr := err.(utilexec.ExitError)
+		if !isExitErr {
+			return nil, err
+		}
+		result.ExitCode = exitErr.ExitStatus()
+	}
+	return result, nil
+}
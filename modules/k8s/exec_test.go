@@ -0,0 +1,72 @@
+//go:build kubeall || kubernetes
+// +build kubeall kubernetes
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests. This is done because minikube
+// is heavy and can interfere with docker related tests in terratest. Specifically, many of the tests start to fail with
+// `connection refused` errors from `minikube`. To avoid overloading the system, we run the kubernetes tests and helm
+// tests separately from the others. This may not be necessary if you have a sufficiently powerful machine.  We
+// recommend at least 4 cores and 16GB of RAM if you want to run all the tests together.
+
+package k8s
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+)
+
+func TestExecInPodReturnsOutputAndExitCode(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(EXAMPLE_POD_YAML_TEMPLATE, uniqueID, uniqueID)
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+	WaitUntilPodAvailable(t, options, "nginx-pod", 60, 1*time.Second)
+
+	result := ExecInPodWithRetry(t, options, "nginx-pod", "", 10, 1*time.Second, "sh", "-c", "echo hello; echo oops >&2; exit 3")
+	require.Equal(t, "hello\n", result.Stdout)
+	require.Equal(t, "oops\n", result.Stderr)
+	require.Equal(t, 3, result.ExitCode)
+}
+
+func TestExecInPodEReturnsErrorForNonExistantPod(t *testing.T) {
+	t.Parallel()
+
+	options := NewKubectlOptions("", "", "default")
+	_, err := ExecInPodE(t, options, "does-not-exist", "", "ls")
+	require.Error(t, err)
+}
+
+func TestCopyToAndFromPod(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(EXAMPLE_POD_YAML_TEMPLATE, uniqueID, uniqueID)
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+	WaitUntilPodAvailable(t, options, "nginx-pod", 60, 1*time.Second)
+
+	srcDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "nested"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "nested", "hello.txt"), []byte("hello world"), 0644))
+
+	CopyToPod(t, options, "nginx-pod", "", srcDir, "/tmp/terratest/copied", 10, 1*time.Second)
+	result := ExecInPod(t, options, "nginx-pod", "", "cat", "/tmp/terratest/copied/nested/hello.txt")
+	require.Equal(t, "hello world", result.Stdout)
+
+	destDir := filepath.Join(t.TempDir(), "copied-back")
+	CopyFromPod(t, options, "nginx-pod", "", "/tmp/terratest/copied", destDir, 10, 1*time.Second)
+	contents, err := os.ReadFile(filepath.Join(destDir, "nested", "hello.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(contents))
+}
@@ -24,6 +24,17 @@ func RunKubectlE(t testing.TestingT, options *KubectlOptions, args ...string) er
 // RunKubectlAndGetOutputE will call kubectl using the provided options and args, returning the output of stdout and
 // stderr.
 func RunKubectlAndGetOutputE(t testing.TestingT, options *KubectlOptions, args ...string) (string, error) {
+	return shell.RunCommandAndGetOutputE(t, prepareKubectlCommand(options, args...))
+}
+
+// RunKubectlAndGetStdOutE will call kubectl using the provided options and args, returning the output of stdout only,
+// e.g. to parse manifests printed by kubectl without its warnings.
+func RunKubectlAndGetStdOutE(t testing.TestingT, options *KubectlOptions, args ...string) (string, error) {
+	return shell.RunCommandAndGetStdOutE(t, prepareKubectlCommand(options, args...))
+}
+
+// prepareKubectlCommand returns the command to call kubectl with the provided options and args.
+func prepareKubectlCommand(options *KubectlOptions, args ...string) shell.Command {
 	cmdArgs := []string{}
 	if options.ContextName != "" {
 		cmdArgs = append(cmdArgs, "--context", options.ContextName)
@@ -35,13 +46,12 @@ func RunKubectlAndGetOutputE(t testing.TestingT, options *KubectlOptions, args .
 		cmdArgs = append(cmdArgs, "--namespace", options.Namespace)
 	}
 	cmdArgs = append(cmdArgs, args...)
-	command := shell.Command{
+	return shell.Command{
 		Command: "kubectl",
 		Args:    cmdArgs,
 		Env:     options.Env,
 		Logger:  options.Logger,
 	}
-	return shell.RunCommandAndGetOutputE(t, command)
 }
 
 // KubectlDelete will take in a file path and delete it from the cluster targeted by KubectlOptions. If there are any
@@ -0,0 +1,310 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// KustomizeOptions configures how RenderKustomize builds a kustomization.
+type KustomizeOptions struct {
+	// Build with `kubectl kustomize` instead of in-process with the kustomize Go API, e.g. to use the exact kustomize
+	// version of the kubectl used to apply the kustomization.
+	UseKubectl bool
+	// The options used to call kubectl when UseKubectl is set. `nil` => use defaults.
+	KubectlOptions *KubectlOptions
+	// Allow the kustomization to load files outside of its directory (--load-restrictor LoadRestrictionsNone).
+	LoadRestrictionsNone bool
+	// Set a non-default logger that should be used. See the logger package for more info.
+	Logger *logger.Logger
+}
+
+// KustomizeResult is the output of building a kustomization. Its objects are looked up the same way as the objects
+// rendered by a helm chart, e.g. with FindAll or GetRenderedObject.
+type KustomizeResult struct {
+	RenderResult
+	Dir       string
+	Manifests string // The multi-document YAML output, e.g. to pass to helm.AssertMatchesSnapshot.
+	// The namePrefix, nameSuffix and namespace of the kustomization, used to match the objects of different overlays.
+	namePrefix string
+	nameSuffix string
+	namespace  string
+}
+
+// RenderKustomize builds the kustomization in the given directory without applying it, and returns the resulting
+// objects. This will fail the test if there is an error.
+func RenderKustomize(t testing.TestingT, options *KustomizeOptions, kustomizationDir string) *KustomizeResult {
+	result, err := RenderKustomizeE(t, options, kustomizationDir)
+	require.NoError(t, err)
+	return result
+}
+
+// RenderKustomizeE builds the kustomization in the given directory without applying it, and returns the resulting
+// objects. The kustomization is built in-process with the kustomize Go API, unless KustomizeOptions.UseKubectl is set.
+func RenderKustomizeE(t testing.TestingT, options *KustomizeOptions, kustomizationDir string) (*KustomizeResult, error) {
+	if options == nil {
+		options = &KustomizeOptions{}
+	}
+	absDir, err := filepath.Abs(kustomizationDir)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	kustomization, err := readKustomizationE(absDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifests string
+	if options.UseKubectl {
+		manifests, err = renderKustomizeWithKubectlE(t, options, absDir)
+	} else {
+		manifests, err = renderKustomizeInProcessE(absDir, options)
+	}
+	if err != nil {
+		return nil, err
+	}
+	objects, err := ParseManifestsE(t, manifests)
+	if err != nil {
+		return nil, err
+	}
+	options.Logger.Logf(t, "Built kustomization %s: %d objects", kustomizationDir, len(objects))
+	return &KustomizeResult{
+		RenderResult: *NewRenderResult(objects, ""),
+		Dir:          absDir,
+		Manifests:    manifests,
+		namePrefix:   kustomization.NamePrefix,
+		nameSuffix:   kustomization.NameSuffix,
+		namespace:    kustomization.Namespace,
+	}, nil
+}
+
+// renderKustomizeInProcessE builds the kustomization with the kustomize Go API, the same way `kubectl kustomize` does.
+func renderKustomizeInProcessE(dir string, options *KustomizeOptions) (string, error) {
+	kustomizerOptions := krusty.MakeDefaultOptions()
+	if options.LoadRestrictionsNone {
+		kustomizerOptions.LoadRestrictions = types.LoadRestrictionsNone
+	}
+	resMap, err := krusty.MakeKustomizer(kustomizerOptions).Run(filesys.MakeFsOnDisk(), dir)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	manifests, err := resMap.AsYaml()
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	return string(manifests), nil
+}
+
+// renderKustomizeWithKubectlE builds the kustomization with `kubectl kustomize`.
+func renderKustomizeWithKubectlE(t testing.TestingT, options *KustomizeOptions, dir string) (string, error) {
+	kubectlOptions := options.KubectlOptions
+	if kubectlOptions == nil {
+		kubectlOptions = &KubectlOptions{Logger: options.Logger}
+	}
+	args := []string{"kustomize", dir}
+	if options.LoadRestrictionsNone {
+		args = append(args, "--load-restrictor", types.LoadRestrictionsNone.String())
+	}
+	return RunKubectlAndGetStdOutE(t, kubectlOptions, args...)
+}
+
+// readKustomizationE reads the kustomization file of the given directory.
+func readKustomizationE(dir string) (*types.Kustomization, error) {
+	for _, fileName := range konfig.RecognizedKustomizationFileNames() {
+		data, err := os.ReadFile(filepath.Join(dir, fileName))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		var kustomization types.Kustomization
+		if err := yaml.Unmarshal(data, &kustomization); err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		return &kustomization, nil
+	}
+	return nil, errors.WithStackTrace(KustomizationNotFound{Dir: dir})
+}
+
+// KustomizeDiff lists the differences between the objects of two kustomizations, such as a base and one of its
+// overlays, or two overlays. Objects are identified as [GROUP/]KIND/[NAMESPACE/]NAME, e.g. apps/Deployment/web or
+// ConfigMap/team-a/config, where NAME has the namePrefix and nameSuffix of its kustomization removed, and NAMESPACE is
+// left out when it is the namespace set by the kustomization, so that the objects of overlays match the objects they
+// are built from. A namespace changed by an overlay is then reported as a changed field.
+type KustomizeDiff struct {
+	Added   []string            // The objects only found in the second kustomization.
+	Removed []string            // The objects only found in the first kustomization.
+	Changed map[string][]string // The changed fields of each object found in both, e.g. spec.replicas: 1 -> 3
+}
+
+// HasDiffs returns true if any object was added, removed or changed.
+func (diff *KustomizeDiff) HasDiffs() bool {
+	return len(diff.Added) > 0 || len(diff.Removed) > 0 || len(diff.Changed) > 0
+}
+
+// String returns a human readable description of the differences.
+func (diff *KustomizeDiff) String() string {
+	var builder strings.Builder
+	for _, key := range diff.Removed {
+		fmt.Fprintf(&builder, "- %s\n", key)
+	}
+	for _, key := range diff.Added {
+		fmt.Fprintf(&builder, "+ %s\n", key)
+	}
+	keys := []string{}
+	for key := range diff.Changed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&builder, "~ %s\n", key)
+		for _, change := range diff.Changed[key] {
+			fmt.Fprintf(&builder, "    %s\n", change)
+		}
+	}
+	return builder.String()
+}
+
+// DiffKustomizations builds the two kustomizations, such as a base and an overlay, and returns the differences between
+// their objects. This will fail the test if either kustomization can not be built.
+func DiffKustomizations(t testing.TestingT, options *KustomizeOptions, dir string, otherDir string) *KustomizeDiff {
+	diff, err := DiffKustomizationsE(t, options, dir, otherDir)
+	require.NoError(t, err)
+	return diff
+}
+
+// DiffKustomizationsE builds the two kustomizations, such as a base and an overlay, and returns the differences between
+// their objects.
+func DiffKustomizationsE(t testing.TestingT, options *KustomizeOptions, dir string, otherDir string) (*KustomizeDiff, error) {
+	result, err := RenderKustomizeE(t, options, dir)
+	if err != nil {
+		return nil, err
+	}
+	otherResult, err := RenderKustomizeE(t, options, otherDir)
+	if err != nil {
+		return nil, err
+	}
+	return DiffKustomizeResults(result, otherResult), nil
+}
+
+// DiffKustomizeResults returns the differences between the objects of two kustomization outputs.
+func DiffKustomizeResults(result *KustomizeResult, otherResult *KustomizeResult) *KustomizeDiff {
+	objects := result.objectsByKey()
+	otherObjects := otherResult.objectsByKey()
+
+	diff := &KustomizeDiff{Added: []string{}, Removed: []string{}, Changed: map[string][]string{}}
+	for key, object := range objects {
+		otherObject, found := otherObjects[key]
+		if !found {
+			diff.Removed = append(diff.Removed, key)
+			continue
+		}
+		changes := []string{}
+		diffFields("", object.Object.Object, otherObject.Object.Object, &changes)
+		if len(changes) > 0 {
+			diff.Changed[key] = changes
+		}
+	}
+	for key := range otherObjects {
+		if _, found := objects[key]; !found {
+			diff.Added = append(diff.Added, key)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	return diff
+}
+
+// objectsByKey indexes the objects as [GROUP/]KIND/[NAMESPACE/]NAME, with the namePrefix and nameSuffix of the
+// kustomization removed from NAME, and the namespace of the kustomization left out.
+func (result *KustomizeResult) objectsByKey() map[string]RenderedObject {
+	objects := map[string]RenderedObject{}
+	for _, object := range result.Objects {
+		parts := []string{}
+		if group := object.GroupVersionKind.Group; group != "" {
+			parts = append(parts, group)
+		}
+		parts = append(parts, object.GroupVersionKind.Kind)
+		if object.Namespace != "" && object.Namespace != result.namespace {
+			parts = append(parts, object.Namespace)
+		}
+		parts = append(parts, strings.TrimSuffix(strings.TrimPrefix(object.Name, result.namePrefix), result.nameSuffix))
+		objects[strings.Join(parts, "/")] = object
+	}
+	return objects
+}
+
+// diffFields appends a description of every field that differs between the two values to changes. The name of the
+// objects is skipped, as it is what objects are matched on.
+func diffFields(path string, value interface{}, otherValue interface{}, changes *[]string) {
+	if path == ".metadata.name" {
+		return
+	}
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		otherTyped, isMap := otherValue.(map[string]interface{})
+		if !isMap {
+			break
+		}
+		keys := map[string]bool{}
+		for key := range typed {
+			keys[key] = true
+		}
+		for key := range otherTyped {
+			keys[key] = true
+		}
+		sortedKeys := []string{}
+		for key := range keys {
+			sortedKeys = append(sortedKeys, key)
+		}
+		sort.Strings(sortedKeys)
+		for _, key := range sortedKeys {
+			fieldPath := fmt.Sprintf("%s.%s", path, key)
+			if strings.ContainsAny(key, "./") {
+				fieldPath = fmt.Sprintf("%s[%q]", path, key)
+			}
+			diffFields(fieldPath, typed[key], otherTyped[key], changes)
+		}
+		return
+	case []interface{}:
+		otherTyped, isList := otherValue.([]interface{})
+		if !isList || len(typed) != len(otherTyped) {
+			break
+		}
+		for i := range typed {
+			diffFields(fmt.Sprintf("%s[%d]", path, i), typed[i], otherTyped[i], changes)
+		}
+		return
+	}
+	if !reflect.DeepEqual(value, otherValue) {
+		*changes = append(*changes, fmt.Sprintf("%s: %s -> %s", strings.TrimPrefix(path, "."), formatDiffValue(value), formatDiffValue(otherValue)))
+	}
+}
+
+// formatDiffValue formats a field value of a KustomizeDiff as compact JSON.
+func formatDiffValue(value interface{}) string {
+	if value == nil {
+		return "<none>"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
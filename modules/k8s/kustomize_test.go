@@ -0,0 +1,120 @@
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// writeKustomizeTestFiles writes the given files, keyed by their path relative to dir.
+func writeKustomizeTestFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+// writeKustomizeTestOverlays writes a base that reuses the kustomize example, and a staging and a prod overlay.
+func writeKustomizeTestOverlays(t *testing.T) string {
+	dir := t.TempDir()
+	exampleDir, err := filepath.Abs("../../examples/kubernetes-kustomize-example")
+	require.NoError(t, err)
+	for _, name := range []string{"deployment.yaml", "service.yaml", "kustomization.yaml"} {
+		data, err := os.ReadFile(filepath.Join(exampleDir, name))
+		require.NoError(t, err)
+		writeKustomizeTestFiles(t, dir, map[string]string{filepath.Join("base", name): string(data)})
+	}
+	writeKustomizeTestFiles(t, dir, map[string]string{
+		"overlays/staging/kustomization.yaml": "resources:\n- ../../base\nnamePrefix: staging-\nnamespace: staging\n",
+		"overlays/prod/kustomization.yaml": `resources:
+- ../../base
+- configmap.yaml
+namePrefix: prod-
+namespace: prod
+labels:
+- pairs:
+    env: prod
+patches:
+- target:
+    kind: Deployment
+  patch: |-
+    - op: replace
+      path: /spec/replicas
+      value: 3
+`,
+		"overlays/prod/configmap.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: nginx-config\ndata:\n  worker_processes: \"4\"\n",
+	})
+	return dir
+}
+
+func TestRenderKustomizeExample(t *testing.T) {
+	t.Parallel()
+
+	result := RenderKustomize(t, nil, "../../examples/kubernetes-kustomize-example")
+	require.Len(t, result.Objects, 2)
+	assert.Contains(t, result.Manifests, "kind: Service")
+
+	deployment := GetRenderedObject[appsv1.Deployment](t, &result.RenderResult, RenderedObjectFilter{Name: "nginx-deployment"})
+	assert.Equal(t, "nginx:1.15.7", deployment.Spec.Template.Spec.Containers[0].Image)
+	service := GetRenderedObject[corev1.Service](t, &result.RenderResult, RenderedObjectFilter{})
+	assert.Equal(t, "nginx-service", service.Name)
+	assert.Len(t, result.FindAll(RenderedObjectFilter{Kind: "Service"}), 1)
+
+	_, err := GetRenderedObjectE[corev1.ConfigMap](&result.RenderResult, RenderedObjectFilter{Name: "nginx-deployment"})
+	require.Error(t, err)
+	assert.IsType(t, RenderedObjectNotFound{}, err)
+
+	_, err = RenderKustomizeE(t, nil, t.TempDir())
+	require.Error(t, err)
+}
+
+func TestDiffKustomizeOverlays(t *testing.T) {
+	t.Parallel()
+
+	dir := writeKustomizeTestOverlays(t)
+	prod := RenderKustomize(t, nil, filepath.Join(dir, "overlays/prod"))
+	deployment := GetRenderedObject[appsv1.Deployment](t, &prod.RenderResult, RenderedObjectFilter{Name: "prod-nginx-deployment"})
+	assert.Equal(t, "prod", deployment.Namespace)
+	assert.Equal(t, int32(3), *deployment.Spec.Replicas)
+
+	// Objects are matched across the overlays, even though their names have a different prefix.
+	diff := DiffKustomizations(t, nil, filepath.Join(dir, "overlays/staging"), filepath.Join(dir, "overlays/prod"))
+	require.True(t, diff.HasDiffs())
+	assert.Equal(t, []string{"ConfigMap/nginx-config"}, diff.Added)
+	assert.Empty(t, diff.Removed)
+	assert.Contains(t, diff.Changed["apps/Deployment/nginx-deployment"], "spec.replicas: 1 -> 3")
+	assert.Contains(t, diff.Changed["apps/Deployment/nginx-deployment"], "metadata.namespace: \"staging\" -> \"prod\"")
+	assert.Contains(t, diff.Changed["Service/nginx-service"], `metadata.labels: <none> -> {"env":"prod"}`)
+	assert.Contains(t, diff.String(), "+ ConfigMap/nginx-config")
+
+	diff = DiffKustomizations(t, nil, filepath.Join(dir, "base"), filepath.Join(dir, "base"))
+	assert.False(t, diff.HasDiffs())
+}
+
+func TestDiffKustomizeObjectsInSeveralNamespaces(t *testing.T) {
+	t.Parallel()
+
+	configMaps := func(teamBValue string) string {
+		return "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n  namespace: team-a\ndata:\n  value: a\n" +
+			"---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n  namespace: team-b\ndata:\n  value: " + teamBValue + "\n"
+	}
+	dir := t.TempDir()
+	writeKustomizeTestFiles(t, dir, map[string]string{
+		"before/kustomization.yaml": "resources:\n- configmaps.yaml\n",
+		"before/configmaps.yaml":    configMaps("b"),
+		"after/kustomization.yaml":  "resources:\n- configmaps.yaml\n",
+		"after/configmaps.yaml":     configMaps("changed"),
+	})
+
+	// The objects have the same kind and name, so they are told apart by their namespace.
+	diff := DiffKustomizations(t, nil, filepath.Join(dir, "before"), filepath.Join(dir, "after"))
+	assert.Empty(t, diff.Added)
+	assert.Empty(t, diff.Removed)
+	assert.Equal(t, map[string][]string{"ConfigMap/team-b/config": {`data.value: "b" -> "changed"`}}, diff.Changed)
+}
@@ -0,0 +1,324 @@
+package k8s
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	gotesting "testing"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// LocalClusterProvider is the tool used to provision a local Kubernetes cluster.
+type LocalClusterProvider string
+
+const (
+	// LocalClusterProviderKind provisions the cluster with kind (https://kind.sigs.k8s.io), which runs each node as a
+	// docker container.
+	LocalClusterProviderKind LocalClusterProvider = "kind"
+	// LocalClusterProviderK3d provisions the cluster with k3d (https://k3d.io), which runs k3s nodes as docker
+	// containers.
+	LocalClusterProviderK3d LocalClusterProvider = "k3d"
+)
+
+// DefaultLocalClusterWaitTimeout is how long to wait for the control plane of a local cluster to be ready when
+// LocalClusterOptions.WaitTimeout is not set.
+const DefaultLocalClusterWaitTimeout = 5 * time.Minute
+
+// LocalClusterOptions represents the options for provisioning a local Kubernetes cluster.
+type LocalClusterOptions struct {
+	// The tool used to provision the cluster. Defaults to kind.
+	Provider LocalClusterProvider
+
+	// The name of the cluster. Defaults to a unique name prefixed with "terratest-".
+	Name string
+
+	// The node image to use (e.g. kindest/node:v1.28.0 or rancher/k3s:v1.28.4-k3s2). When the image is already present
+	// in the local docker daemon, the cluster can be created without network access. Defaults to the provider default.
+	NodeImage string
+
+	// Path to a provider specific cluster configuration file (a kind Cluster config or a k3d Simple config).
+	ConfigPath string
+
+	// Path where the kubeconfig of the cluster is written. Defaults to a file in a new temporary directory, so the
+	// default kubeconfig of the user is never modified.
+	KubeConfigPath string
+
+	// How long to wait for the control plane and the nodes to be ready. Defaults to DefaultLocalClusterWaitTimeout.
+	WaitTimeout time.Duration
+
+	// Additional arguments passed to the create command of the provider.
+	ExtraArgs []string
+
+	// Additional environment variables to set when running the provider commands.
+	Env map[string]string
+
+	// Use the specified logger for the provider commands. Use logger.Discard to not print the output.
+	Logger *logger.Logger
+}
+
+// LocalCluster is a local Kubernetes cluster provisioned with CreateLocalCluster.
+type LocalCluster struct {
+	Name           string
+	Provider       LocalClusterProvider
+	KubeConfigPath string
+
+	options *LocalClusterOptions
+
+	// The temporary directory created for the kubeconfig when LocalClusterOptions.KubeConfigPath is not set.
+	tempDir string
+}
+
+// CreateLocalCluster provisions a local Kubernetes cluster with kind or k3d, writes its kubeconfig to an isolated file
+// and waits until all the nodes are ready. This will fail the test if there is an error. Make sure to call Delete on
+// the returned cluster (e.g. with defer) to tear it down.
+func CreateLocalCluster(t testing.TestingT, options *LocalClusterOptions) *LocalCluster {
+	cluster, err := CreateLocalClusterE(t, options)
+	require.NoError(t, err)
+	return cluster
+}
+
+// CreateLocalClusterE provisions a local Kubernetes cluster with kind or k3d, writes its kubeconfig to an isolated file
+// and waits until all the nodes are ready. If any step fails once the cluster has been created, e.g. the nodes do not
+// become ready, the cluster is deleted before returning the error.
+func CreateLocalClusterE(t testing.TestingT, options *LocalClusterOptions) (*LocalCluster, error) {
+	cluster, err := newLocalClusterE(options)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Logf(t, "Creating %s cluster %s with kubeconfig %s", cluster.Provider, cluster.Name, cluster.KubeConfigPath)
+	for i, args := range cluster.createArgs() {
+		if _, err := shell.RunCommandAndGetOutputE(t, cluster.command(args)); err != nil {
+			if i == 0 {
+				// The cluster was not created, so there is only the kubeconfig to clean up.
+				if removeErr := cluster.removeKubeConfigE(); removeErr != nil {
+					logger.Logf(t, "Failed to remove kubeconfig of cluster %s: %s", cluster.Name, removeErr)
+				}
+			} else {
+				cluster.deleteAfterError(t)
+			}
+			return nil, err
+		}
+	}
+
+	kubectlOptions := cluster.KubectlOptions("")
+	sleepBetweenRetries := 2 * time.Second
+	retries := int(cluster.waitTimeout()/sleepBetweenRetries) + 1
+	if err := WaitUntilAllNodesReadyE(t, kubectlOptions, retries, sleepBetweenRetries); err != nil {
+		cluster.deleteAfterError(t)
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// KubectlOptions returns the KubectlOptions to interact with the given namespace of the cluster.
+func (cluster *LocalCluster) KubectlOptions(namespace string) *KubectlOptions {
+	return NewKubectlOptions("", cluster.KubeConfigPath, namespace)
+}
+
+// LoadImages loads docker images that were built or pulled locally into all the nodes of the cluster, so that pods can
+// use them without access to a registry. This will fail the test if there is an error.
+func (cluster *LocalCluster) LoadImages(t testing.TestingT, images ...string) {
+	require.NoError(t, cluster.LoadImagesE(t, images...))
+}
+
+// LoadImagesE loads docker images that were built or pulled locally into all the nodes of the cluster, so that pods
+// can use them without access to a registry. Pods using these images should set imagePullPolicy to IfNotPresent or
+// Never, and avoid the latest tag which defaults to Always.
+func (cluster *LocalCluster) LoadImagesE(t testing.TestingT, images ...string) error {
+	if len(images) == 0 {
+		return nil
+	}
+	logger.Logf(t, "Loading images %s into cluster %s", strings.Join(images, ", "), cluster.Name)
+	_, err := shell.RunCommandAndGetOutputE(t, cluster.command(cluster.loadImagesArgs(images)))
+	return err
+}
+
+// Delete tears down the cluster and removes the kubeconfig written for it. This will fail the test if there is an
+// error.
+func (cluster *LocalCluster) Delete(t testing.TestingT) {
+	require.NoError(t, cluster.DeleteE(t))
+}
+
+// DeleteE tears down the cluster and removes the kubeconfig written for it, along with the temporary directory created
+// for it when LocalClusterOptions.KubeConfigPath is not set. The kubeconfig is removed even if the cluster can not be
+// torn down.
+func (cluster *LocalCluster) DeleteE(t testing.TestingT) error {
+	logger.Logf(t, "Deleting %s cluster %s", cluster.Provider, cluster.Name)
+	var errorsOccurred = new(multierror.Error)
+	if _, err := shell.RunCommandAndGetOutputE(t, cluster.command(cluster.deleteArgs())); err != nil {
+		errorsOccurred = multierror.Append(errorsOccurred, err)
+	}
+	if err := cluster.removeKubeConfigE(); err != nil {
+		errorsOccurred = multierror.Append(errorsOccurred, err)
+	}
+	return errorsOccurred.ErrorOrNil()
+}
+
+// deleteAfterError deletes the cluster when its creation failed, only logging the error if that fails too, so that the
+// error of the creation is the one returned.
+func (cluster *LocalCluster) deleteAfterError(t testing.TestingT) {
+	if err := cluster.DeleteE(t); err != nil {
+		logger.Logf(t, "Failed to delete cluster %s: %s", cluster.Name, err)
+	}
+}
+
+// removeKubeConfigE removes the kubeconfig written for the cluster, or the whole temporary directory created for it.
+func (cluster *LocalCluster) removeKubeConfigE() error {
+	if cluster.tempDir != "" {
+		return os.RemoveAll(cluster.tempDir)
+	}
+	if err := os.Remove(cluster.KubeConfigPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// RunTestsWithLocalCluster is meant to be called from TestMain. It provisions a local cluster, points the KUBECONFIG
+// environment variable to it so that KubectlOptions with an empty config path target the cluster, runs the tests of
+// the package, and tears the cluster down once they are done. It returns the exit code to pass to os.Exit. For
+// example:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(k8s.RunTestsWithLocalCluster(m, &k8s.LocalClusterOptions{NodeImage: "kindest/node:v1.28.0"}))
+//	}
+func RunTestsWithLocalCluster(m *gotesting.M, options *LocalClusterOptions) int {
+	t := &testMainT{}
+	cluster, err := CreateLocalClusterE(t, options)
+	if err != nil {
+		logger.Logf(t, "Failed to create local cluster: %s", err)
+		return 1
+	}
+	defer func() {
+		if err := cluster.DeleteE(t); err != nil {
+			logger.Logf(t, "Failed to delete local cluster %s: %s", cluster.Name, err)
+		}
+	}()
+
+	originalKubeConfig, hadKubeConfig := os.LookupEnv("KUBECONFIG")
+	os.Setenv("KUBECONFIG", cluster.KubeConfigPath)
+	defer func() {
+		if hadKubeConfig {
+			os.Setenv("KUBECONFIG", originalKubeConfig)
+		} else {
+			os.Unsetenv("KUBECONFIG")
+		}
+	}()
+
+	return m.Run()
+}
+
+// newLocalClusterE fills in the defaults of the options and returns the cluster they describe, without creating it.
+func newLocalClusterE(options *LocalClusterOptions) (*LocalCluster, error) {
+	cluster := &LocalCluster{
+		Name:           options.Name,
+		Provider:       options.Provider,
+		KubeConfigPath: options.KubeConfigPath,
+		options:        options,
+	}
+	if cluster.Provider == "" {
+		cluster.Provider = LocalClusterProviderKind
+	}
+	if cluster.Provider != LocalClusterProviderKind && cluster.Provider != LocalClusterProviderK3d {
+		return nil, UnknownLocalClusterProvider{Provider: cluster.Provider}
+	}
+	if cluster.Name == "" {
+		cluster.Name = fmt.Sprintf("terratest-%s", strings.ToLower(random.UniqueId()))
+	}
+	if cluster.KubeConfigPath == "" {
+		dir, err := os.MkdirTemp("", cluster.Name)
+		if err != nil {
+			return nil, err
+		}
+		cluster.KubeConfigPath = filepath.Join(dir, "kubeconfig")
+		cluster.tempDir = dir
+	}
+	return cluster, nil
+}
+
+// waitTimeout returns the configured wait timeout, or the default one.
+func (cluster *LocalCluster) waitTimeout() time.Duration {
+	if cluster.options.WaitTimeout > 0 {
+		return cluster.options.WaitTimeout
+	}
+	return DefaultLocalClusterWaitTimeout
+}
+
+// command returns the shell command to run the provider binary with the given args.
+func (cluster *LocalCluster) command(args []string) shell.Command {
+	return shell.Command{
+		Command: string(cluster.Provider),
+		Args:    args,
+		Env:     cluster.options.Env,
+		Logger:  cluster.options.Logger,
+	}
+}
+
+// createArgs returns the args of the provider commands that create the cluster and write its kubeconfig.
+func (cluster *LocalCluster) createArgs() [][]string {
+	options := cluster.options
+	timeout := cluster.waitTimeout().String()
+
+	var args []string
+	if cluster.Provider == LocalClusterProviderKind {
+		args = []string{"create", "cluster", "--name", cluster.Name, "--kubeconfig", cluster.KubeConfigPath, "--wait", timeout}
+	} else {
+		args = []string{
+			"cluster", "create", cluster.Name,
+			"--kubeconfig-update-default=false",
+			"--kubeconfig-switch-context=false",
+			"--wait",
+			"--timeout", timeout,
+		}
+	}
+	if options.NodeImage != "" {
+		args = append(args, "--image", options.NodeImage)
+	}
+	if options.ConfigPath != "" {
+		args = append(args, "--config", options.ConfigPath)
+	}
+	args = append(args, options.ExtraArgs...)
+
+	if cluster.Provider == LocalClusterProviderKind {
+		return [][]string{args}
+	}
+	// k3d can't write the kubeconfig of a new cluster to an arbitrary path, so it is written in a second step.
+	return [][]string{args, {"kubeconfig", "write", cluster.Name, "--output", cluster.KubeConfigPath}}
+}
+
+// loadImagesArgs returns the args of the provider command that loads the given images into the cluster.
+func (cluster *LocalCluster) loadImagesArgs(images []string) []string {
+	if cluster.Provider == LocalClusterProviderKind {
+		return append([]string{"load", "docker-image", "--name", cluster.Name}, images...)
+	}
+	return append([]string{"image", "import", "--cluster", cluster.Name}, images...)
+}
+
+// deleteArgs returns the args of the provider command that deletes the cluster.
+func (cluster *LocalCluster) deleteArgs() []string {
+	if cluster.Provider == LocalClusterProviderKind {
+		return []string{"delete", "cluster", "--name", cluster.Name, "--kubeconfig", cluster.KubeConfigPath}
+	}
+	return []string{"cluster", "delete", cluster.Name}
+}
+
+// testMainT is the TestingT used by RunTestsWithLocalCluster, since there is no test running yet in TestMain. It only
+// backs the E functions, which never call the failure methods, so those just log.
+type testMainT struct{}
+
+func (t *testMainT) Fail()                                     {}
+func (t *testMainT) FailNow()                                  {}
+func (t *testMainT) Fatal(args ...interface{})                 { logger.Log(t, args...) }
+func (t *testMainT) Fatalf(format string, args ...interface{}) { logger.Logf(t, format, args...) }
+func (t *testMainT) Error(args ...interface{})                 { logger.Log(t, args...) }
+func (t *testMainT) Errorf(format string, args ...interface{}) { logger.Logf(t, format, args...) }
+func (t *testMainT) Name() string                              { return "TestMain" }
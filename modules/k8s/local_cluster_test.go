@@ -0,0 +1,148 @@
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLocalClusterDefaults(t *testing.T) {
+	t.Parallel()
+
+	cluster, err := newLocalClusterE(&LocalClusterOptions{})
+	require.NoError(t, err)
+	defer os.RemoveAll(filepath.Dir(cluster.KubeConfigPath))
+
+	assert.Equal(t, LocalClusterProviderKind, cluster.Provider)
+	assert.True(t, strings.HasPrefix(cluster.Name, "terratest-"))
+	assert.Equal(t, strings.ToLower(cluster.Name), cluster.Name)
+	assert.Equal(t, "kubeconfig", filepath.Base(cluster.KubeConfigPath))
+	assert.DirExists(t, filepath.Dir(cluster.KubeConfigPath))
+	assert.Equal(t, DefaultLocalClusterWaitTimeout, cluster.waitTimeout())
+	assert.Equal(t, cluster.KubeConfigPath, cluster.KubectlOptions("default").ConfigPath)
+}
+
+func TestNewLocalClusterUnknownProvider(t *testing.T) {
+	t.Parallel()
+
+	_, err := newLocalClusterE(&LocalClusterOptions{Provider: "minikube"})
+	require.Error(t, err)
+	assert.IsType(t, UnknownLocalClusterProvider{}, err)
+}
+
+func TestLocalClusterKindArgs(t *testing.T) {
+	t.Parallel()
+
+	cluster, err := newLocalClusterE(&LocalClusterOptions{
+		Name:           "test",
+		NodeImage:      "kindest/node:v1.28.0",
+		ConfigPath:     "kind.yaml",
+		KubeConfigPath: "/tmp/kubeconfig",
+		WaitTimeout:    time.Minute,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(
+		t,
+		[][]string{{
+			"create", "cluster", "--name", "test", "--kubeconfig", "/tmp/kubeconfig", "--wait", "1m0s",
+			"--image", "kindest/node:v1.28.0", "--config", "kind.yaml",
+		}},
+		cluster.createArgs(),
+	)
+	assert.Equal(t, []string{"load", "docker-image", "--name", "test", "app:1", "app:2"}, cluster.loadImagesArgs([]string{"app:1", "app:2"}))
+	assert.Equal(t, []string{"delete", "cluster", "--name", "test", "--kubeconfig", "/tmp/kubeconfig"}, cluster.deleteArgs())
+}
+
+func TestLocalClusterK3dArgs(t *testing.T) {
+	t.Parallel()
+
+	cluster, err := newLocalClusterE(&LocalClusterOptions{
+		Provider:       LocalClusterProviderK3d,
+		Name:           "test",
+		NodeImage:      "rancher/k3s:v1.28.4-k3s2",
+		KubeConfigPath: "/tmp/kubeconfig",
+		ExtraArgs:      []string{"--no-lb"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(
+		t,
+		[][]string{
+			{
+				"cluster", "create", "test", "--kubeconfig-update-default=false", "--kubeconfig-switch-context=false",
+				"--wait", "--timeout", "5m0s", "--image", "rancher/k3s:v1.28.4-k3s2", "--no-lb",
+			},
+			{"kubeconfig", "write", "test", "--output", "/tmp/kubeconfig"},
+		},
+		cluster.createArgs(),
+	)
+	assert.Equal(t, []string{"image", "import", "--cluster", "test", "app:1"}, cluster.loadImagesArgs([]string{"app:1"}))
+	assert.Equal(t, []string{"cluster", "delete", "test"}, cluster.deleteArgs())
+}
+
+func TestCreateLocalClusterCleansUpWhenCreateFails(t *testing.T) {
+	// Not parallel, since PATH is changed to use a fake k3d that creates the cluster but fails to write its kubeconfig.
+	binDir := t.TempDir()
+	callsPath := filepath.Join(binDir, "calls")
+	fakeK3d := "#!/bin/sh\necho \"$@\" >> " + callsPath + "\nif [ \"$1\" = kubeconfig ]; then exit 1; fi\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "k3d"), []byte(fakeK3d), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cluster, err := newLocalClusterE(&LocalClusterOptions{Provider: LocalClusterProviderK3d})
+	require.NoError(t, err)
+	require.NoError(t, os.RemoveAll(cluster.tempDir))
+
+	_, err = CreateLocalClusterE(t, &LocalClusterOptions{Provider: LocalClusterProviderK3d, Name: cluster.Name})
+	require.Error(t, err)
+
+	calls, err := os.ReadFile(callsPath)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(calls)), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "cluster create "+cluster.Name))
+	assert.True(t, strings.HasPrefix(lines[1], "kubeconfig write "+cluster.Name))
+	assert.Equal(t, "cluster delete "+cluster.Name, lines[2])
+
+	tempDirs, err := filepath.Glob(filepath.Join(os.TempDir(), cluster.Name+"*"))
+	require.NoError(t, err)
+	assert.Empty(t, tempDirs)
+}
+
+func TestDeleteLocalClusterRemovesTempDirWhenDeleteFails(t *testing.T) {
+	// Not parallel, since PATH is changed to use a fake k3d that fails to delete the cluster.
+	binDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "k3d"), []byte("#!/bin/sh\necho cluster not found >&2\nexit 1\n"), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cluster, err := newLocalClusterE(&LocalClusterOptions{Provider: LocalClusterProviderK3d})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(cluster.KubeConfigPath, []byte("apiVersion: v1"), 0600))
+
+	assert.Error(t, cluster.DeleteE(t))
+	assert.NoDirExists(t, cluster.tempDir)
+}
+
+func TestLocalClusterRemovesTempDir(t *testing.T) {
+	t.Parallel()
+
+	cluster, err := newLocalClusterE(&LocalClusterOptions{})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(cluster.KubeConfigPath, []byte("apiVersion: v1"), 0600))
+
+	require.NoError(t, cluster.removeKubeConfigE())
+	assert.NoDirExists(t, cluster.tempDir)
+
+	kubeConfigPath := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, os.WriteFile(kubeConfigPath, []byte("apiVersion: v1"), 0600))
+	cluster, err = newLocalClusterE(&LocalClusterOptions{KubeConfigPath: kubeConfigPath})
+	require.NoError(t, err)
+	require.NoError(t, cluster.removeKubeConfigE())
+	assert.NoFileExists(t, kubeConfigPath)
+	assert.DirExists(t, filepath.Dir(kubeConfigPath))
+}
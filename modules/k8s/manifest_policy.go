@@ -0,0 +1,523 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/opa"
+	"github.com/gruntwork-io/terratest/modules/shell"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// Names of the built-in manifest policy rules.
+const (
+	PolicyRuleResources           = "resources"             // Every container sets cpu and memory requests and limits.
+	PolicyRuleNoLatestTag         = "no-latest-tag"         // Every image is pinned to a tag other than latest, or to a digest.
+	PolicyRuleRunAsNonRoot        = "run-as-non-root"       // Every container runs with runAsNonRoot, set on the container or the pod.
+	PolicyRuleReadinessProbe      = "readiness-probe"       // Every container of a long-running workload has a readiness probe.
+	PolicyRulePodDisruptionBudget = "pod-disruption-budget" // Every Deployment or StatefulSet with more than one replica is covered by a PodDisruptionBudget.
+
+	// PolicyRuleOPA is the rule reported for objects that fail the OPA query of PolicyOptions.
+	PolicyRuleOPA = "opa"
+)
+
+// PolicyRule is a rule that every object of a set of manifests must follow.
+type PolicyRule struct {
+	Name        string
+	Description string
+	// Check returns a message for every way the object breaks the rule, or nothing if it follows the rule. All the
+	// objects being checked are passed along, for rules that relate objects to one another.
+	Check func(object *unstructured.Unstructured, objects []*unstructured.Unstructured) ([]string, error)
+}
+
+// PolicyExemption exempts objects from a rule. Empty fields match anything, and Name can be a glob pattern.
+type PolicyExemption struct {
+	Rule      string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// matches returns true if the exemption applies to the given rule and object.
+func (exemption PolicyExemption) matches(rule string, object *unstructured.Unstructured) bool {
+	if exemption.Rule != "" && exemption.Rule != rule {
+		return false
+	}
+	if exemption.Kind != "" && exemption.Kind != object.GetKind() {
+		return false
+	}
+	if exemption.Namespace != "" && exemption.Namespace != object.GetNamespace() {
+		return false
+	}
+	if exemption.Name != "" {
+		matched, err := path.Match(exemption.Name, object.GetName())
+		return err == nil && matched
+	}
+	return true
+}
+
+// PolicyOptions configures the rules checked by CheckManifestPolicies and the related functions.
+type PolicyOptions struct {
+	Rules       []PolicyRule      // The rules to check. `nil` => use BuiltinPolicyRules.
+	CustomRules []PolicyRule      // Rules checked in addition to Rules, e.g. to add organization specific rules to the built-in ones.
+	Exemptions  []PolicyExemption // Objects that are allowed to break some rules.
+
+	// When set, every object is also evaluated with `opa eval` against the policy of OPAEvalOptions.RulePath, querying
+	// OPAQuery (e.g. data.kubernetes.allow). Objects that fail the query are reported as PolicyRuleOPA violations, while
+	// an invalid policy or query is returned as an error.
+	OPAEvalOptions *opa.EvalOptions
+	OPAQuery       string
+}
+
+// PolicyViolation is a rule broken by an object.
+type PolicyViolation struct {
+	Rule      string
+	Kind      string
+	Namespace string
+	Name      string
+	Message   string
+}
+
+// String returns a human readable description of the violation, e.g. [no-latest-tag] Deployment default/web: ...
+func (violation PolicyViolation) String() string {
+	object := violation.Name
+	if violation.Namespace != "" {
+		object = fmt.Sprintf("%s/%s", violation.Namespace, violation.Name)
+	}
+	return fmt.Sprintf("[%s] %s %s: %s", violation.Rule, violation.Kind, object, violation.Message)
+}
+
+// PolicyReport is the outcome of checking manifests against a set of rules.
+type PolicyReport struct {
+	Objects    int      // The number of objects checked.
+	Rules      []string // The names of the rules checked.
+	Violations []PolicyViolation
+}
+
+// Passed returns true if no object broke any rule.
+func (report PolicyReport) Passed() bool {
+	return len(report.Violations) == 0
+}
+
+// ViolationsOf returns the violations of the given rule.
+func (report PolicyReport) ViolationsOf(rule string) []PolicyViolation {
+	violations := []PolicyViolation{}
+	for _, violation := range report.Violations {
+		if violation.Rule == rule {
+			violations = append(violations, violation)
+		}
+	}
+	return violations
+}
+
+// String returns a human readable report listing every violation.
+func (report PolicyReport) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(
+		&builder,
+		"Manifest policies: %d objects checked against %d rules, %d violations\n",
+		report.Objects,
+		len(report.Rules),
+		len(report.Violations),
+	)
+	for _, violation := range report.Violations {
+		fmt.Fprintf(&builder, "  - %s\n", violation)
+	}
+	return builder.String()
+}
+
+// BuiltinPolicyRules returns the built-in security and best-practice rules: PolicyRuleResources,
+// PolicyRuleNoLatestTag, PolicyRuleRunAsNonRoot, PolicyRuleReadinessProbe and PolicyRulePodDisruptionBudget.
+func BuiltinPolicyRules() []PolicyRule {
+	return []PolicyRule{
+		{
+			Name:        PolicyRuleResources,
+			Description: "containers must set cpu and memory requests and limits",
+			Check:       checkPodSpecPolicy(checkContainerResources),
+		},
+		{
+			Name:        PolicyRuleNoLatestTag,
+			Description: "images must be pinned to a tag other than latest, or to a digest",
+			Check:       checkPodSpecPolicy(checkImageTags),
+		},
+		{
+			Name:        PolicyRuleRunAsNonRoot,
+			Description: "containers must run with runAsNonRoot",
+			Check:       checkPodSpecPolicy(checkRunAsNonRoot),
+		},
+		{
+			Name:        PolicyRuleReadinessProbe,
+			Description: "containers of long-running workloads must have a readiness probe",
+			Check:       checkReadinessProbes,
+		},
+		{
+			Name:        PolicyRulePodDisruptionBudget,
+			Description: "Deployments and StatefulSets with more than one replica must be covered by a PodDisruptionBudget",
+			Check:       checkPodDisruptionBudget,
+		},
+	}
+}
+
+// CheckManifestPolicies checks every object of the given multi-document YAML manifests, such as the output of
+// helm.RenderTemplate, against the rules of the options and returns a report. This will fail the test if the manifests
+// can not be parsed or the rules can not be evaluated, but not if some objects break the rules.
+func CheckManifestPolicies(t testing.TestingT, options *PolicyOptions, manifests string) PolicyReport {
+	report, err := CheckManifestPoliciesE(t, options, manifests)
+	require.NoError(t, err)
+	return report
+}
+
+// CheckManifestPoliciesE checks every object of the given multi-document YAML manifests, such as the output of
+// helm.RenderTemplate, against the rules of the options and returns a report. This will return an error if the
+// manifests can not be parsed or the rules can not be evaluated, but not if some objects break the rules.
+func CheckManifestPoliciesE(t testing.TestingT, options *PolicyOptions, manifests string) (PolicyReport, error) {
+	objects, err := ParseManifestsE(t, manifests)
+	if err != nil {
+		return PolicyReport{}, err
+	}
+	return CheckObjectPoliciesE(t, options, objects)
+}
+
+// CheckManifestFilePolicies checks every object of the given manifest file, or directory of manifest files, such as
+// the ones passed to KubectlApply, against the rules of the options and returns a report. This will fail the test if
+// the manifests can not be read or the rules can not be evaluated, but not if some objects break the rules.
+func CheckManifestFilePolicies(t testing.TestingT, options *PolicyOptions, configPath string) PolicyReport {
+	report, err := CheckManifestFilePoliciesE(t, options, configPath)
+	require.NoError(t, err)
+	return report
+}
+
+// CheckManifestFilePoliciesE checks every object of the given manifest file, or directory of manifest files, such as
+// the ones passed to KubectlApply, against the rules of the options and returns a report. This will return an error if
+// the manifests can not be read or the rules can not be evaluated, but not if some objects break the rules.
+func CheckManifestFilePoliciesE(t testing.TestingT, options *PolicyOptions, configPath string) (PolicyReport, error) {
+	manifests, err := readManifestsE(configPath)
+	if err != nil {
+		return PolicyReport{}, err
+	}
+	return CheckManifestPoliciesE(t, options, manifests)
+}
+
+// CheckObjectPolicies checks every given object against the rules of the options and returns a report. This will fail
+// the test if the rules can not be evaluated, but not if some objects break the rules.
+func CheckObjectPolicies(t testing.TestingT, options *PolicyOptions, objects []*unstructured.Unstructured) PolicyReport {
+	report, err := CheckObjectPoliciesE(t, options, objects)
+	require.NoError(t, err)
+	return report
+}
+
+// CheckObjectPoliciesE checks every given object against the rules of the options and returns a report. This will
+// return an error if the rules can not be evaluated, but not if some objects break the rules.
+func CheckObjectPoliciesE(t testing.TestingT, options *PolicyOptions, objects []*unstructured.Unstructured) (PolicyReport, error) {
+	if options == nil {
+		options = &PolicyOptions{}
+	}
+	rules := options.Rules
+	if rules == nil {
+		rules = BuiltinPolicyRules()
+	}
+	rules = append(append([]PolicyRule{}, rules...), options.CustomRules...)
+
+	report := PolicyReport{Objects: len(objects), Rules: []string{}, Violations: []PolicyViolation{}}
+	for _, rule := range rules {
+		report.Rules = append(report.Rules, rule.Name)
+		for _, object := range objects {
+			messages, err := rule.Check(object, objects)
+			if err != nil {
+				return report, errors.WithStackTrace(err)
+			}
+			for _, message := range messages {
+				report.addViolation(options, rule.Name, object, message)
+			}
+		}
+	}
+
+	if options.OPAEvalOptions != nil {
+		report.Rules = append(report.Rules, PolicyRuleOPA)
+		if err := checkOPAPolicyE(t, options, objects, &report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// addViolation adds a violation of the rule by the object to the report, unless the object is exempted from the rule.
+func (report *PolicyReport) addViolation(options *PolicyOptions, rule string, object *unstructured.Unstructured, message string) {
+	for _, exemption := range options.Exemptions {
+		if exemption.matches(rule, object) {
+			return
+		}
+	}
+	report.Violations = append(report.Violations, PolicyViolation{
+		Rule:      rule,
+		Kind:      object.GetKind(),
+		Namespace: object.GetNamespace(),
+		Name:      object.GetName(),
+		Message:   message,
+	})
+}
+
+// AssertManifestPolicies checks every object of the given multi-document YAML manifests against the rules of the
+// options, and fails the test with a report of the violations if any object breaks a rule.
+func AssertManifestPolicies(t testing.TestingT, options *PolicyOptions, manifests string) {
+	require.NoError(t, AssertManifestPoliciesE(t, options, manifests))
+}
+
+// AssertManifestPoliciesE checks every object of the given multi-document YAML manifests against the rules of the
+// options, and returns a ManifestPolicyViolations error with the report if any object breaks a rule.
+func AssertManifestPoliciesE(t testing.TestingT, options *PolicyOptions, manifests string) error {
+	report, err := CheckManifestPoliciesE(t, options, manifests)
+	if err != nil {
+		return err
+	}
+	if !report.Passed() {
+		return ManifestPolicyViolations{Report: report}
+	}
+	logger.Logf(t, "All %d objects follow the %d manifest policy rules", report.Objects, len(report.Rules))
+	return nil
+}
+
+// opaQueryFailedExitCode is the exit code of `opa eval` when the query fails with --fail or --fail-defined. Other non
+// zero exit codes mean that the query could not be evaluated, e.g. because the policy is not valid rego.
+const opaQueryFailedExitCode = 1
+
+// checkOPAPolicyE evaluates each object with `opa eval`, adding a PolicyRuleOPA violation for every object that fails
+// the query of the options. An error is returned if the query can not be evaluated, e.g. because of a syntax error in
+// the policy, so that a broken policy is not reported as non compliant manifests.
+func checkOPAPolicyE(t testing.TestingT, options *PolicyOptions, objects []*unstructured.Unstructured, report *PolicyReport) error {
+	// Fail early rather than report every object as a violation when opa is not available.
+	if _, err := exec.LookPath("opa"); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	if _, err := opa.DownloadPolicyE(t, options.OPAEvalOptions.RulePath); err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "terratest-manifest-policy")
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for i, object := range objects {
+		data, err := json.Marshal(object.Object)
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		jsonPath := filepath.Join(tmpDir, fmt.Sprintf("%d.json", i))
+		if err := os.WriteFile(jsonPath, data, 0644); err != nil {
+			return errors.WithStackTrace(err)
+		}
+		err = opa.EvalE(t, options.OPAEvalOptions, []string{jsonPath}, options.OPAQuery)
+		if err == nil {
+			continue
+		}
+		if !isOPAQueryFailure(err) {
+			return errors.WithStackTrace(err)
+		}
+		report.addViolation(options, PolicyRuleOPA, object, fmt.Sprintf("opa query %s failed", options.OPAQuery))
+	}
+	return nil
+}
+
+// isOPAQueryFailure returns true if the error returned by opa.EvalE for a single file is the query failing, rather than
+// opa failing to evaluate it.
+func isOPAQueryFailure(err error) bool {
+	if evalErrors, isMultiError := err.(*multierror.Error); isMultiError && len(evalErrors.Errors) == 1 {
+		err = evalErrors.Errors[0]
+	}
+	exitCode, exitCodeErr := shell.GetExitCodeForRunCommandError(err)
+	return exitCodeErr == nil && exitCode == opaQueryFailedExitCode
+}
+
+// podSpecPath returns the path to the pod spec of workloads of the given kind, or nil if it has none.
+func podSpecPath(kind string) []string {
+	switch kind {
+	case "Pod":
+		return []string{"spec"}
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "ReplicationController", "Job":
+		return []string{"spec", "template", "spec"}
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return nil
+	}
+}
+
+// getPodSpecE returns the pod spec of the given workload, or nil if the object has no pod spec.
+func getPodSpecE(object *unstructured.Unstructured) (*corev1.PodSpec, error) {
+	fields := podSpecPath(object.GetKind())
+	if fields == nil {
+		return nil, nil
+	}
+	data, found, err := unstructured.NestedMap(object.Object, fields...)
+	if err != nil || !found {
+		return nil, err
+	}
+	var podSpec corev1.PodSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(data, &podSpec); err != nil {
+		return nil, err
+	}
+	return &podSpec, nil
+}
+
+// checkPodSpecPolicy adapts a check on pod specs to a rule that applies to every kind of workload.
+func checkPodSpecPolicy(check func(podSpec *corev1.PodSpec) []string) func(*unstructured.Unstructured, []*unstructured.Unstructured) ([]string, error) {
+	return func(object *unstructured.Unstructured, _ []*unstructured.Unstructured) ([]string, error) {
+		podSpec, err := getPodSpecE(object)
+		if err != nil || podSpec == nil {
+			return nil, err
+		}
+		return check(podSpec), nil
+	}
+}
+
+// allContainers returns the init containers and the containers of the pod spec.
+func allContainers(podSpec *corev1.PodSpec) []corev1.Container {
+	return append(append([]corev1.Container{}, podSpec.InitContainers...), podSpec.Containers...)
+}
+
+func checkContainerResources(podSpec *corev1.PodSpec) []string {
+	messages := []string{}
+	for _, container := range allContainers(podSpec) {
+		missing := []string{}
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			if _, hasRequest := container.Resources.Requests[name]; !hasRequest {
+				missing = append(missing, fmt.Sprintf("requests.%s", name))
+			}
+			if _, hasLimit := container.Resources.Limits[name]; !hasLimit {
+				missing = append(missing, fmt.Sprintf("limits.%s", name))
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			messages = append(messages, fmt.Sprintf("container %s does not set %s", container.Name, strings.Join(missing, ", ")))
+		}
+	}
+	return messages
+}
+
+func checkImageTags(podSpec *corev1.PodSpec) []string {
+	messages := []string{}
+	for _, container := range allContainers(podSpec) {
+		if strings.Contains(container.Image, "@") {
+			continue
+		}
+		// The tag follows the last colon, unless that colon is the port of the registry.
+		image := container.Image
+		tag := ""
+		if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+			tag = image[i+1:]
+		}
+		if tag == "" || tag == "latest" {
+			messages = append(messages, fmt.Sprintf("container %s uses image %s, which is not pinned to a tag other than latest", container.Name, image))
+		}
+	}
+	return messages
+}
+
+func checkRunAsNonRoot(podSpec *corev1.PodSpec) []string {
+	podRunAsNonRoot := podSpec.SecurityContext != nil && podSpec.SecurityContext.RunAsNonRoot != nil && *podSpec.SecurityContext.RunAsNonRoot
+	messages := []string{}
+	for _, container := range allContainers(podSpec) {
+		runAsNonRoot := podRunAsNonRoot
+		// The security context of the container overrides the one of the pod.
+		if container.SecurityContext != nil && container.SecurityContext.RunAsNonRoot != nil {
+			runAsNonRoot = *container.SecurityContext.RunAsNonRoot
+		}
+		if !runAsNonRoot {
+			messages = append(messages, fmt.Sprintf("container %s does not set runAsNonRoot", container.Name))
+		}
+	}
+	return messages
+}
+
+func checkReadinessProbes(object *unstructured.Unstructured, _ []*unstructured.Unstructured) ([]string, error) {
+	// Pods, Jobs and CronJobs run to completion, or are not load balanced through a workload.
+	switch object.GetKind() {
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "ReplicationController":
+	default:
+		return nil, nil
+	}
+	podSpec, err := getPodSpecE(object)
+	if err != nil || podSpec == nil {
+		return nil, err
+	}
+	messages := []string{}
+	for _, container := range podSpec.Containers {
+		if container.ReadinessProbe == nil {
+			messages = append(messages, fmt.Sprintf("container %s has no readiness probe", container.Name))
+		}
+	}
+	return messages, nil
+}
+
+func checkPodDisruptionBudget(object *unstructured.Unstructured, objects []*unstructured.Unstructured) ([]string, error) {
+	if object.GetKind() != "Deployment" && object.GetKind() != "StatefulSet" {
+		return nil, nil
+	}
+	replicas, found, err := unstructured.NestedFieldNoCopy(object.Object, "spec", "replicas")
+	if err != nil || !found {
+		// The default is a single replica.
+		return nil, err
+	}
+	if count, isNumber := toInt64(replicas); !isNumber || count <= 1 {
+		return nil, nil
+	}
+	podLabels, _, err := unstructured.NestedStringMap(object.Object, "spec", "template", "metadata", "labels")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, candidate := range objects {
+		if candidate.GetKind() != "PodDisruptionBudget" || candidate.GetNamespace() != object.GetNamespace() {
+			continue
+		}
+		selectorData, found, err := unstructured.NestedMap(candidate.Object, "spec", "selector")
+		if err != nil || !found {
+			continue
+		}
+		var labelSelector metav1.LabelSelector
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(selectorData, &labelSelector); err != nil {
+			return nil, err
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
+		if err != nil {
+			return nil, err
+		}
+		if !selector.Empty() && selector.Matches(labels.Set(podLabels)) {
+			return nil, nil
+		}
+	}
+	return []string{fmt.Sprintf("%v replicas are not covered by any PodDisruptionBudget", replicas)}, nil
+}
+
+// toInt64 converts a number decoded from YAML or JSON manifests to an int64.
+func toInt64(value interface{}) (int64, bool) {
+	switch number := value.(type) {
+	case int64:
+		return number, true
+	case int:
+		return int64(number), true
+	case float64:
+		return int64(number), true
+	default:
+		return 0, false
+	}
+}
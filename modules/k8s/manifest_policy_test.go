@@ -0,0 +1,250 @@
+package k8s
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/gruntwork-io/terratest/modules/opa"
+)
+
+const compliantPolicyManifests = `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 3
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      securityContext:
+        runAsNonRoot: true
+      containers:
+        - name: web
+          image: registry.example.com:5000/web:1.2.3
+          readinessProbe:
+            httpGet:
+              path: /healthz
+              port: 8080
+          resources:
+            requests:
+              cpu: 100m
+              memory: 64Mi
+            limits:
+              cpu: 200m
+              memory: 128Mi
+---
+apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+  namespace: default
+spec:
+  minAvailable: 1
+  selector:
+    matchLabels:
+      app: web
+`
+
+const nonCompliantPolicyManifests = `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: default
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: api
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      containers:
+        - name: api
+          image: registry.example.com:5000/api
+          securityContext:
+            runAsNonRoot: false
+          resources:
+            requests:
+              cpu: 100m
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+  namespace: default
+spec:
+  schedule: "@daily"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: Never
+          securityContext:
+            runAsNonRoot: true
+          containers:
+            - name: cleanup
+              image: busybox:latest
+              resources:
+                requests:
+                  cpu: 10m
+                  memory: 16Mi
+                limits:
+                  cpu: 10m
+                  memory: 16Mi
+`
+
+func TestCheckManifestPoliciesCompliant(t *testing.T) {
+	t.Parallel()
+
+	report := CheckManifestPolicies(t, nil, compliantPolicyManifests)
+	assert.True(t, report.Passed(), report.String())
+	assert.Equal(t, 2, report.Objects)
+	assert.Len(t, report.Rules, 5)
+	AssertManifestPolicies(t, nil, compliantPolicyManifests)
+}
+
+func TestCheckManifestPoliciesReportsViolations(t *testing.T) {
+	t.Parallel()
+
+	report := CheckManifestPolicies(t, nil, nonCompliantPolicyManifests)
+	require.False(t, report.Passed())
+
+	resources := report.ViolationsOf(PolicyRuleResources)
+	require.Len(t, resources, 1)
+	assert.Equal(t, "container api does not set limits.cpu, limits.memory, requests.memory", resources[0].Message)
+
+	latest := report.ViolationsOf(PolicyRuleNoLatestTag)
+	require.Len(t, latest, 2)
+	assert.Equal(t, "api", latest[0].Name)
+	assert.Equal(t, "CronJob", latest[1].Kind)
+
+	nonRoot := report.ViolationsOf(PolicyRuleRunAsNonRoot)
+	require.Len(t, nonRoot, 1)
+	assert.Equal(t, "api", nonRoot[0].Name)
+
+	// The CronJob runs to completion, so it does not need a readiness probe.
+	probes := report.ViolationsOf(PolicyRuleReadinessProbe)
+	require.Len(t, probes, 1)
+	assert.Equal(t, "Deployment", probes[0].Kind)
+
+	budgets := report.ViolationsOf(PolicyRulePodDisruptionBudget)
+	require.Len(t, budgets, 1)
+	assert.Equal(t, "[pod-disruption-budget] Deployment default/api: 2 replicas are not covered by any PodDisruptionBudget", budgets[0].String())
+
+	err := AssertManifestPoliciesE(t, nil, nonCompliantPolicyManifests)
+	require.Error(t, err)
+	assert.IsType(t, ManifestPolicyViolations{}, err)
+	assert.Contains(t, err.Error(), "6 violations")
+}
+
+func TestCheckManifestPoliciesWithCustomRulesAndExemptions(t *testing.T) {
+	t.Parallel()
+
+	requireTeamLabel := PolicyRule{
+		Name:        "team-label",
+		Description: "objects must have a team label",
+		Check: func(object *unstructured.Unstructured, _ []*unstructured.Unstructured) ([]string, error) {
+			if object.GetLabels()["team"] == "" {
+				return []string{"missing team label"}, nil
+			}
+			return nil, nil
+		},
+	}
+	options := &PolicyOptions{
+		Rules:       []PolicyRule{},
+		CustomRules: []PolicyRule{requireTeamLabel},
+		Exemptions:  []PolicyExemption{{Rule: "team-label", Kind: "PodDisruptionBudget"}},
+	}
+	report := CheckManifestPolicies(t, options, compliantPolicyManifests)
+	assert.Equal(t, []string{"team-label"}, report.Rules)
+	require.Len(t, report.Violations, 1)
+	assert.Equal(t, "Deployment", report.Violations[0].Kind)
+
+	// Exempt the non compliant objects from all the built-in rules, by name.
+	options = &PolicyOptions{Exemptions: []PolicyExemption{{Name: "api"}, {Name: "clean*"}}}
+	assert.True(t, CheckManifestPolicies(t, options, nonCompliantPolicyManifests).Passed())
+}
+
+func TestCheckManifestFilePolicies(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "compliant.yaml"), []byte(compliantPolicyManifests), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "non-compliant.yml"), []byte(nonCompliantPolicyManifests), 0644))
+
+	report := CheckManifestFilePolicies(t, nil, dir)
+	assert.Equal(t, 4, report.Objects)
+	assert.Len(t, report.Violations, 6)
+
+	_, err := CheckManifestFilePoliciesE(t, nil, filepath.Join(dir, "nonexistent.yaml"))
+	require.Error(t, err)
+}
+
+func TestImageTagPolicy(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]bool{
+		"nginx":                          false,
+		"nginx:latest":                   false,
+		"localhost:5000/nginx":           false,
+		"nginx:1.25":                     true,
+		"localhost:5000/nginx:1.25":      true,
+		"nginx@sha256:0123456789abcdef0": true,
+	}
+	for image, pinned := range testCases {
+		manifest := fmt.Sprintf("apiVersion: v1\nkind: Pod\nmetadata:\n  name: test\nspec:\n  containers:\n    - name: test\n      image: %s\n", image)
+		report := CheckManifestPolicies(t, &PolicyOptions{Rules: []PolicyRule{BuiltinPolicyRules()[1]}}, manifest)
+		assert.Equal(t, pinned, report.Passed(), image)
+	}
+}
+
+func TestCheckManifestPoliciesWithOPA(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("opa"); err != nil {
+		t.Skip("opa is not installed")
+	}
+
+	policyDir := t.TempDir()
+	validPolicy := filepath.Join(policyDir, "valid.rego")
+	require.NoError(t, os.WriteFile(validPolicy, []byte("package kubernetes\n\nallow {\n\tinput.kind == \"Deployment\"\n}\n"), 0644))
+	invalidPolicy := filepath.Join(policyDir, "invalid.rego")
+	require.NoError(t, os.WriteFile(invalidPolicy, []byte("package kubernetes\n\nallow {{\n\tinput.kind ==\n"), 0644))
+
+	options := &PolicyOptions{
+		Rules:          []PolicyRule{},
+		OPAEvalOptions: &opa.EvalOptions{FailMode: opa.FailUndefined, RulePath: validPolicy},
+		OPAQuery:       "data.kubernetes.allow",
+	}
+	report := CheckManifestPolicies(t, options, compliantPolicyManifests)
+	violations := report.ViolationsOf(PolicyRuleOPA)
+	require.Len(t, violations, 1)
+	assert.Equal(t, "PodDisruptionBudget", violations[0].Kind)
+
+	// A broken policy is an error, rather than every object breaking the policy.
+	options.OPAEvalOptions.RulePath = invalidPolicy
+	_, err := CheckManifestPoliciesE(t, options, compliantPolicyManifests)
+	require.Error(t, err)
+
+	options.OPAEvalOptions.RulePath = validPolicy
+	options.OPAQuery = "data.kubernetes.[["
+	_, err = CheckManifestPoliciesE(t, options, compliantPolicyManifests)
+	require.Error(t, err)
+}
@@ -0,0 +1,196 @@
+package k8s
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// IsObjectReady returns true if the given object is ready, using rules that depend on its kind:
+//   - Deployment, StatefulSet, DaemonSet and ReplicaSet: the latest generation is observed and all replicas are updated
+//     and ready.
+//   - Pod: all containers are ready and started (see IsPodAvailable), or the pod completed successfully.
+//   - Job: the job succeeded (see IsJobSucceeded).
+//   - Service: the service is available (see IsServiceAvailable).
+//   - PersistentVolumeClaim: the claim is bound.
+//   - Namespace: the namespace is active.
+//   - CustomResourceDefinition: the definition is established.
+//   - Any other kind is considered ready as soon as it exists.
+func IsObjectReady(obj *unstructured.Unstructured) (bool, error) {
+	ready, _, err := objectReadiness(obj)
+	return ready, err
+}
+
+// objectReadiness implements IsObjectReady, returning a human readable reason when the object is not ready.
+func objectReadiness(obj *unstructured.Unstructured) (bool, string, error) {
+	gk := obj.GroupVersionKind().GroupKind()
+	switch gk.String() {
+	case "Deployment.apps":
+		var deploy appsv1.Deployment
+		if err := fromUnstructured(obj, &deploy); err != nil {
+			return false, "", err
+		}
+		return deploymentReadiness(&deploy)
+	case "StatefulSet.apps":
+		var statefulSet appsv1.StatefulSet
+		if err := fromUnstructured(obj, &statefulSet); err != nil {
+			return false, "", err
+		}
+		return statefulSetReadiness(&statefulSet)
+	case "DaemonSet.apps":
+		var daemonSet appsv1.DaemonSet
+		if err := fromUnstructured(obj, &daemonSet); err != nil {
+			return false, "", err
+		}
+		return daemonSetReadiness(&daemonSet)
+	case "ReplicaSet.apps":
+		var replicaSet appsv1.ReplicaSet
+		if err := fromUnstructured(obj, &replicaSet); err != nil {
+			return false, "", err
+		}
+		return replicaSetReadiness(&replicaSet)
+	case "Pod":
+		var pod corev1.Pod
+		if err := fromUnstructured(obj, &pod); err != nil {
+			return false, "", err
+		}
+		if pod.Status.Phase == corev1.PodSucceeded || IsPodAvailable(&pod) {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("pod is %s and not all containers are ready", pod.Status.Phase), nil
+	case "Job.batch":
+		var job batchv1.Job
+		if err := fromUnstructured(obj, &job); err != nil {
+			return false, "", err
+		}
+		if IsJobSucceeded(&job) {
+			return true, "", nil
+		}
+		return false, "job has not succeeded yet", nil
+	case "Service":
+		var service corev1.Service
+		if err := fromUnstructured(obj, &service); err != nil {
+			return false, "", err
+		}
+		if IsServiceAvailable(&service) {
+			return true, "", nil
+		}
+		return false, "load balancer has no ingress yet", nil
+	case "PersistentVolumeClaim":
+		var pvc corev1.PersistentVolumeClaim
+		if err := fromUnstructured(obj, &pvc); err != nil {
+			return false, "", err
+		}
+		if pvc.Status.Phase == corev1.ClaimBound {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("claim is %s", pvc.Status.Phase), nil
+	case "Namespace":
+		var namespace corev1.Namespace
+		if err := fromUnstructured(obj, &namespace); err != nil {
+			return false, "", err
+		}
+		if namespace.Status.Phase == corev1.NamespaceActive {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("namespace is %s", namespace.Status.Phase), nil
+	case "CustomResourceDefinition.apiextensions.k8s.io":
+		return customResourceDefinitionReadiness(obj)
+	default:
+		return true, "", nil
+	}
+}
+
+// fromUnstructured converts the unstructured object into the given typed object.
+func fromUnstructured(obj *unstructured.Unstructured, into interface{}) error {
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), into)
+}
+
+// desiredReplicas returns the number of replicas requested in a workload spec, which defaults to 1 when unset.
+func desiredReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+// deploymentReadiness follows the same logic as `kubectl rollout status` for Deployments.
+func deploymentReadiness(deploy *appsv1.Deployment) (bool, string, error) {
+	replicas := desiredReplicas(deploy.Spec.Replicas)
+	switch {
+	case deploy.Status.ObservedGeneration < deploy.Generation:
+		return false, "waiting for the latest generation to be observed", nil
+	case deploy.Status.UpdatedReplicas < replicas:
+		return false, fmt.Sprintf("%d of %d replicas updated", deploy.Status.UpdatedReplicas, replicas), nil
+	case deploy.Status.Replicas > deploy.Status.UpdatedReplicas:
+		return false, fmt.Sprintf("%d old replicas pending termination", deploy.Status.Replicas-deploy.Status.UpdatedReplicas), nil
+	case deploy.Status.AvailableReplicas < replicas:
+		return false, fmt.Sprintf("%d of %d replicas available", deploy.Status.AvailableReplicas, replicas), nil
+	}
+	return true, "", nil
+}
+
+// statefulSetReadiness follows the same logic as `kubectl rollout status` for StatefulSets.
+func statefulSetReadiness(statefulSet *appsv1.StatefulSet) (bool, string, error) {
+	replicas := desiredReplicas(statefulSet.Spec.Replicas)
+	switch {
+	case statefulSet.Status.ObservedGeneration < statefulSet.Generation:
+		return false, "waiting for the latest generation to be observed", nil
+	case statefulSet.Status.ReadyReplicas < replicas:
+		return false, fmt.Sprintf("%d of %d replicas ready", statefulSet.Status.ReadyReplicas, replicas), nil
+	case statefulSet.Spec.UpdateStrategy.Type == appsv1.RollingUpdateStatefulSetStrategyType &&
+		statefulSet.Spec.UpdateStrategy.RollingUpdate == nil &&
+		statefulSet.Status.UpdateRevision != statefulSet.Status.CurrentRevision:
+		return false, fmt.Sprintf("waiting for revision %s to be rolled out", statefulSet.Status.UpdateRevision), nil
+	}
+	return true, "", nil
+}
+
+// daemonSetReadiness follows the same logic as `kubectl rollout status` for DaemonSets.
+func daemonSetReadiness(daemonSet *appsv1.DaemonSet) (bool, string, error) {
+	desired := daemonSet.Status.DesiredNumberScheduled
+	switch {
+	case daemonSet.Status.ObservedGeneration < daemonSet.Generation:
+		return false, "waiting for the latest generation to be observed", nil
+	case daemonSet.Status.UpdatedNumberScheduled < desired:
+		return false, fmt.Sprintf("%d of %d pods updated", daemonSet.Status.UpdatedNumberScheduled, desired), nil
+	case daemonSet.Status.NumberAvailable < desired:
+		return false, fmt.Sprintf("%d of %d pods available", daemonSet.Status.NumberAvailable, desired), nil
+	}
+	return true, "", nil
+}
+
+// replicaSetReadiness returns true once all the replicas of the ReplicaSet are ready.
+func replicaSetReadiness(replicaSet *appsv1.ReplicaSet) (bool, string, error) {
+	replicas := desiredReplicas(replicaSet.Spec.Replicas)
+	switch {
+	case replicaSet.Status.ObservedGeneration < replicaSet.Generation:
+		return false, "waiting for the latest generation to be observed", nil
+	case replicaSet.Status.ReadyReplicas < replicas:
+		return false, fmt.Sprintf("%d of %d replicas ready", replicaSet.Status.ReadyReplicas, replicas), nil
+	}
+	return true, "", nil
+}
+
+// customResourceDefinitionReadiness returns true once the CustomResourceDefinition has the Established condition. The
+// apiextensions types are not a dependency of this package, so the condition is read from the unstructured object.
+func customResourceDefinitionReadiness(obj *unstructured.Unstructured) (bool, string, error) {
+	conditions, _, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil {
+		return false, "", err
+	}
+	for _, condition := range conditions {
+		conditionMap, isMap := condition.(map[string]interface{})
+		if !isMap {
+			continue
+		}
+		if conditionMap["type"] == "Established" && conditionMap["status"] == string(corev1.ConditionTrue) {
+			return true, "", nil
+		}
+	}
+	return false, "waiting for the definition to be established", nil
+}
@@ -0,0 +1,143 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestIsObjectReady(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		title         string
+		manifest      string
+		expectedReady bool
+	}{
+		{
+			title: "DeploymentRolledOut",
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: nginx, generation: 2}
+spec: {replicas: 2}
+status: {observedGeneration: 2, replicas: 2, updatedReplicas: 2, availableReplicas: 2}
+`,
+			expectedReady: true,
+		},
+		{
+			title: "DeploymentGenerationNotObserved",
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: nginx, generation: 3}
+spec: {replicas: 2}
+status: {observedGeneration: 2, replicas: 2, updatedReplicas: 2, availableReplicas: 2}
+`,
+			expectedReady: false,
+		},
+		{
+			title: "DeploymentWithOldReplicas",
+			manifest: `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: nginx, generation: 2}
+spec: {replicas: 2}
+status: {observedGeneration: 2, replicas: 3, updatedReplicas: 2, availableReplicas: 2}
+`,
+			expectedReady: false,
+		},
+		{
+			title: "StatefulSetNotReady",
+			manifest: `
+apiVersion: apps/v1
+kind: StatefulSet
+metadata: {name: db, generation: 1}
+spec: {replicas: 3, updateStrategy: {type: RollingUpdate}}
+status: {observedGeneration: 1, readyReplicas: 2, currentRevision: a, updateRevision: a}
+`,
+			expectedReady: false,
+		},
+		{
+			title: "StatefulSetRevisionPending",
+			manifest: `
+apiVersion: apps/v1
+kind: StatefulSet
+metadata: {name: db, generation: 1}
+spec: {replicas: 1, updateStrategy: {type: RollingUpdate}}
+status: {observedGeneration: 1, readyReplicas: 1, currentRevision: a, updateRevision: b}
+`,
+			expectedReady: false,
+		},
+		{
+			title: "DaemonSetAvailable",
+			manifest: `
+apiVersion: apps/v1
+kind: DaemonSet
+metadata: {name: agent, generation: 1}
+status: {observedGeneration: 1, desiredNumberScheduled: 3, updatedNumberScheduled: 3, numberAvailable: 3}
+`,
+			expectedReady: true,
+		},
+		{
+			title: "CompletedPod",
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata: {name: migrate}
+status: {phase: Succeeded}
+`,
+			expectedReady: true,
+		},
+		{
+			title: "PendingClaim",
+			manifest: `
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata: {name: data}
+status: {phase: Pending}
+`,
+			expectedReady: false,
+		},
+		{
+			title: "EstablishedCRD",
+			manifest: `
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata: {name: widgets.example.com}
+status:
+  conditions:
+  - {type: NamesAccepted, status: "True"}
+  - {type: Established, status: "True"}
+`,
+			expectedReady: true,
+		},
+		{
+			title: "ConfigMapAlwaysReady",
+			manifest: `
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: settings}
+`,
+			expectedReady: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		// capture range variable so that it doesn't update when the subtest goroutine swaps.
+		testCase := testCase
+		t.Run(testCase.title, func(t *testing.T) {
+			t.Parallel()
+
+			obj := &unstructured.Unstructured{}
+			require.NoError(t, yaml.Unmarshal([]byte(testCase.manifest), &obj.Object))
+
+			ready, err := IsObjectReady(obj)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedReady, ready)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package k8s
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// RenderedObject is a single object rendered by a tool such as helm or kustomize, along with where it came from.
+type RenderedObject struct {
+	GroupVersionKind schema.GroupVersionKind
+	Namespace        string
+	Name             string
+	// The template that rendered the object, relative to the chart (e.g. templates/deployment.yaml, or
+	// charts/SUBCHART/templates/deployment.yaml for subcharts). Empty if the output has no `# Source:` comments, such as
+	// the output of kustomize.
+	SourceTemplate string
+	Object         *unstructured.Unstructured
+}
+
+// String returns a human readable identifier for the object.
+func (object RenderedObject) String() string {
+	name := object.Name
+	if object.Namespace != "" {
+		name = fmt.Sprintf("%s/%s", object.Namespace, object.Name)
+	}
+	id := fmt.Sprintf("%s %s", object.GroupVersionKind.Kind, name)
+	if object.SourceTemplate != "" {
+		id = fmt.Sprintf("%s (from %s)", id, object.SourceTemplate)
+	}
+	return id
+}
+
+// TypedObjectE decodes the object into its type in the client-go scheme, e.g. *appsv1.Deployment, or returns it as is,
+// as an *unstructured.Unstructured, if its kind is not in the scheme, such as a custom resource.
+func (object RenderedObject) TypedObjectE() (runtime.Object, error) {
+	typed, err := scheme.Scheme.New(object.GroupVersionKind)
+	if runtime.IsNotRegisteredError(err) {
+		return object.Object, nil
+	}
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object.UnstructuredContent(), typed); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return typed, nil
+}
+
+// RenderedObjectFilter selects rendered objects. Empty fields match any value, and SourceTemplate may be a glob pattern
+// (see filepath.Match), such as templates/*.yaml.
+type RenderedObjectFilter struct {
+	Group          string
+	Version        string
+	Kind           string
+	Namespace      string
+	Name           string
+	SourceTemplate string
+}
+
+// String returns a human readable description of the filter.
+func (filter RenderedObjectFilter) String() string {
+	parts := []string{}
+	for _, field := range []struct{ name, value string }{
+		{"group", filter.Group},
+		{"version", filter.Version},
+		{"kind", filter.Kind},
+		{"namespace", filter.Namespace},
+		{"name", filter.Name},
+		{"template", filter.SourceTemplate},
+	} {
+		if field.value != "" {
+			parts = append(parts, fmt.Sprintf("%s=%s", field.name, field.value))
+		}
+	}
+	if len(parts) == 0 {
+		return "any object"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// matches returns true if the object is selected by the filter.
+func (filter RenderedObjectFilter) matches(object RenderedObject) bool {
+	gvk := object.GroupVersionKind
+	if (filter.Group != "" && filter.Group != gvk.Group) ||
+		(filter.Version != "" && filter.Version != gvk.Version) ||
+		(filter.Kind != "" && filter.Kind != gvk.Kind) ||
+		(filter.Namespace != "" && filter.Namespace != object.Namespace) ||
+		(filter.Name != "" && filter.Name != object.Name) {
+		return false
+	}
+	if filter.SourceTemplate != "" {
+		matched, _ := filepath.Match(filter.SourceTemplate, object.SourceTemplate)
+		return matched
+	}
+	return true
+}
+
+// RenderResult holds all the objects rendered by a tool such as helm (see helm.RenderTemplateResult) or kustomize (see
+// RenderKustomize), in the order they were rendered.
+type RenderResult struct {
+	Objects []RenderedObject
+}
+
+// NewRenderResult returns a RenderResult holding the given objects, such as the ones returned by ParseManifests, all
+// rendered from the given template, if any.
+func NewRenderResult(objects []*unstructured.Unstructured, sourceTemplate string) *RenderResult {
+	result := &RenderResult{Objects: []RenderedObject{}}
+	for _, object := range objects {
+		result.Objects = append(result.Objects, RenderedObject{
+			GroupVersionKind: object.GroupVersionKind(),
+			Namespace:        object.GetNamespace(),
+			Name:             object.GetName(),
+			SourceTemplate:   sourceTemplate,
+			Object:           object,
+		})
+	}
+	return result
+}
+
+// FindAll returns all the rendered objects selected by the filter, in the order they were rendered.
+func (result *RenderResult) FindAll(filter RenderedObjectFilter) []RenderedObject {
+	objects := []RenderedObject{}
+	for _, object := range result.Objects {
+		if filter.matches(object) {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+// FindOneE returns the single rendered object selected by the filter. This returns a RenderedObjectNotFound error if
+// no object matches, and a MultipleRenderedObjectsFound error if more than one object matches.
+func (result *RenderResult) FindOneE(filter RenderedObjectFilter) (RenderedObject, error) {
+	objects := result.FindAll(filter)
+	switch len(objects) {
+	case 0:
+		return RenderedObject{}, RenderedObjectNotFound{Filter: filter, Available: result.Objects}
+	case 1:
+		return objects[0], nil
+	default:
+		return RenderedObject{}, MultipleRenderedObjectsFound{Filter: filter, Matches: objects}
+	}
+}
+
+// GetRenderedObject returns the single rendered object selected by the filter, decoded into the given type, such as
+// appsv1.Deployment. When the filter doesn't set a kind, the group, version and kind are inferred from the type for
+// the built-in Kubernetes types. This will fail the test if no object or more than one object matches. For example:
+//
+//	deployment := k8s.GetRenderedObject[appsv1.Deployment](t, &result.RenderResult, k8s.RenderedObjectFilter{Name: "prod-nginx"})
+func GetRenderedObject[T any](t testing.TestingT, result *RenderResult, filter RenderedObjectFilter) T {
+	out, err := GetRenderedObjectE[T](result, filter)
+	require.NoError(t, err)
+	return out
+}
+
+// GetRenderedObjectE returns the single rendered object selected by the filter, decoded into the given type, such as
+// appsv1.Deployment. When the filter doesn't set a kind, the group, version and kind are inferred from the type for
+// the built-in Kubernetes types. This returns a RenderedObjectNotFound error if no object matches, and a
+// MultipleRenderedObjectsFound error if more than one object matches.
+func GetRenderedObjectE[T any](result *RenderResult, filter RenderedObjectFilter) (T, error) {
+	var out T
+	if filter.Kind == "" {
+		if typed, isObject := any(&out).(runtime.Object); isObject {
+			if gvks, _, err := scheme.Scheme.ObjectKinds(typed); err == nil && len(gvks) > 0 {
+				filter.Group = gvks[0].Group
+				filter.Version = gvks[0].Version
+				filter.Kind = gvks[0].Kind
+			}
+		}
+	}
+	object, err := result.FindOneE(filter)
+	if err != nil {
+		return out, err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object.UnstructuredContent(), &out); err != nil {
+		return out, errors.WithStackTrace(err)
+	}
+	return out, nil
+}
@@ -0,0 +1,42 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestNewRenderResult(t *testing.T) {
+	t.Parallel()
+
+	objects := ParseManifests(t, `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: team-a
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: config
+`)
+	result := NewRenderResult(objects, "")
+	require.Len(t, result.Objects, 2)
+	assert.Equal(t, "ConfigMap team-a/config", result.Objects[0].String())
+
+	configMap, err := result.Objects[0].TypedObjectE()
+	require.NoError(t, err)
+	assert.IsType(t, &corev1.ConfigMap{}, configMap)
+	widget, err := result.Objects[1].TypedObjectE()
+	require.NoError(t, err)
+	assert.IsType(t, &unstructured.Unstructured{}, widget)
+
+	_, err = GetRenderedObjectE[corev1.ConfigMap](result, RenderedObjectFilter{Namespace: "team-b"})
+	assert.IsType(t, RenderedObjectNotFound{}, err)
+	_, err = result.FindOneE(RenderedObjectFilter{Name: "config"})
+	assert.IsType(t, MultipleRenderedObjectsFound{}, err)
+}
@@ -0,0 +1,357 @@
+package k8s
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/retry"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// deploymentRevisionAnnotation is the annotation the deployment controller sets on Deployments and their ReplicaSets
+// to track the revision of the pod template.
+const deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+
+const (
+	// DefaultRolloutProbeInterval is the default time between two requests probing the service during a rollout.
+	DefaultRolloutProbeInterval = 100 * time.Millisecond
+	// DefaultRolloutTimeout is the default time to wait for a rollout to complete.
+	DefaultRolloutTimeout = 5 * time.Minute
+	// DefaultRolloutProbeAfter is the default time the service keeps being probed after the rollout completes.
+	DefaultRolloutProbeAfter = 5 * time.Second
+)
+
+// RolloutOptions configures how a rollout is triggered, probed and waited for by RolloutAndProbe.
+type RolloutOptions struct {
+	DeploymentName string
+	ServiceName    string // The Service probed during the rollout, through a Tunnel, if ProbeURL is not set.
+	ServicePort    int    // The port of the Service to probe, if ProbeURL is not set.
+	Path           string // The HTTP path to probe, if ProbeURL is not set. Empty string means /.
+
+	// Probe this URL instead of opening a Tunnel to the Service. A Tunnel forwards traffic to a single pod at a time,
+	// which the rollout replaces, so requests fail until it reconnects to another pod. Use the URL of a LoadBalancer or
+	// NodePort Service (see GetServiceEndpoint) or of an Ingress to test the traffic as the clients of the Service see
+	// it. This is required by AssertZeroDowntimeRollout.
+	ProbeURL string
+
+	ProbeInterval  time.Duration // Time between two requests. 0 => DefaultRolloutProbeInterval.
+	RolloutTimeout time.Duration // Time to wait for the rollout to complete. 0 => DefaultRolloutTimeout.
+	ProbeAfter     time.Duration // Time the service keeps being probed after the rollout completes. 0 => DefaultRolloutProbeAfter.
+}
+
+// FailedRequest is a request to the service that failed or returned a non-200 response during a rollout.
+type FailedRequest struct {
+	Time       time.Time
+	StatusCode int
+	Err        error
+}
+
+// DowntimeWindow is a period of consecutive failed requests during a rollout.
+type DowntimeWindow struct {
+	Start    time.Time // The time of the first failed request.
+	End      time.Time // The time of the last failed request.
+	Failures int
+}
+
+// Duration returns the time between the first and the last failed request of the window.
+func (window DowntimeWindow) Duration() time.Duration {
+	return window.End.Sub(window.Start)
+}
+
+// RolloutReport is the outcome of probing a service during the rollout of a Deployment.
+type RolloutReport struct {
+	DeploymentName   string
+	PreviousRevision int64
+	Revision         int64
+	ReplicaSetName   string    // The ReplicaSet of the new revision.
+	Started          time.Time // When the rollout was triggered.
+	Completed        time.Time // When the rollout was observed to be complete.
+	Requests         int
+	FailedRequests   []FailedRequest
+	DowntimeWindows  []DowntimeWindow
+}
+
+// ZeroDowntime returns true if no request failed during the rollout.
+func (report RolloutReport) ZeroDowntime() bool {
+	return len(report.FailedRequests) == 0
+}
+
+// String returns a human readable summary of the rollout and of its downtime windows.
+func (report RolloutReport) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(
+		&builder,
+		"Rollout of deployment %s from revision %d to %d (replica set %s) took %s: %d of %d requests failed\n",
+		report.DeploymentName,
+		report.PreviousRevision,
+		report.Revision,
+		report.ReplicaSetName,
+		report.Completed.Sub(report.Started),
+		len(report.FailedRequests),
+		report.Requests,
+	)
+	for _, window := range report.DowntimeWindows {
+		fmt.Fprintf(
+			&builder,
+			"  - %d failed requests from %s to %s (%s after the rollout started)\n",
+			window.Failures,
+			window.Start.Format(time.RFC3339Nano),
+			window.End.Format(time.RFC3339Nano),
+			window.Start.Sub(report.Started),
+		)
+	}
+	return builder.String()
+}
+
+// RolloutAndProbe continuously probes a service, calls trigger to change the Deployment (e.g. with KubectlApplyE or
+// helm.UpgradeE), waits for the rollout of the new revision to complete, and returns a report of the requests that
+// failed along the way. This will fail the test if the rollout can not be triggered or does not complete, but not if
+// some requests fail. Without a ProbeURL, the requests that fail while the Tunnel reconnects are reported too.
+func RolloutAndProbe(t testing.TestingT, options *KubectlOptions, rolloutOptions RolloutOptions, trigger func() error) RolloutReport {
+	report, err := RolloutAndProbeE(t, options, rolloutOptions, trigger)
+	require.NoError(t, err)
+	return report
+}
+
+// RolloutAndProbeE continuously probes a service, calls trigger to change the Deployment (e.g. with KubectlApplyE or
+// helm.UpgradeE), waits for the rollout of the new revision to complete, and returns a report of the requests that
+// failed along the way. This will return an error if the rollout can not be triggered or does not complete, but not if
+// some requests fail.
+func RolloutAndProbeE(t testing.TestingT, options *KubectlOptions, rolloutOptions RolloutOptions, trigger func() error) (RolloutReport, error) {
+	rolloutOptions = withRolloutDefaults(rolloutOptions)
+	report := RolloutReport{DeploymentName: rolloutOptions.DeploymentName}
+
+	deployment, err := GetDeploymentE(t, options, rolloutOptions.DeploymentName)
+	if err != nil {
+		return report, err
+	}
+	report.PreviousRevision = deploymentRevision(&deployment.ObjectMeta)
+
+	url := rolloutOptions.ProbeURL
+	if url == "" {
+		tunnel := NewTunnel(options, ResourceTypeService, rolloutOptions.ServiceName, 0, rolloutOptions.ServicePort)
+		if err := tunnel.ForwardPortE(t); err != nil {
+			return report, err
+		}
+		defer tunnel.Close()
+		url = fmt.Sprintf("http://%s%s", tunnel.Endpoint(), rolloutOptions.Path)
+	}
+
+	// Make sure the service works before the rollout, so that failures can be blamed on the rollout.
+	_, err = retry.DoWithRetryE(t, fmt.Sprintf("Probe %s before the rollout", url), 30, time.Second, func() (string, error) {
+		statusCode, _, err := http_helper.HttpGetE(t, url, nil)
+		if err != nil {
+			return "", err
+		}
+		if statusCode != 200 {
+			return "", fmt.Errorf("got status code %d", statusCode)
+		}
+		return "", nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	stopChecking := make(chan bool, 1)
+	wg, responses := http_helper.ContinuouslyCheckUrlE(t, url, stopChecking, rolloutOptions.ProbeInterval)
+	var collected []http_helper.GetResponse
+	var collectorWg sync.WaitGroup
+	collectorWg.Add(1)
+	go func() {
+		defer collectorWg.Done()
+		for response := range responses {
+			collected = append(collected, response)
+		}
+	}()
+	stopProbing := func() {
+		stopChecking <- true
+		wg.Wait()
+		collectorWg.Wait()
+	}
+
+	report.Started = time.Now()
+	if err := trigger(); err != nil {
+		stopProbing()
+		return report, err
+	}
+	retries := int(rolloutOptions.RolloutTimeout / time.Second)
+	replicaSet, err := WaitUntilDeploymentRolloutCompleteE(t, options, rolloutOptions.DeploymentName, report.PreviousRevision, retries, time.Second)
+	report.Completed = time.Now()
+	if err == nil {
+		time.Sleep(rolloutOptions.ProbeAfter)
+	}
+	stopProbing()
+	if err != nil {
+		return report, err
+	}
+
+	report.Revision = deploymentRevision(&replicaSet.ObjectMeta)
+	report.ReplicaSetName = replicaSet.Name
+	report.Requests = len(collected)
+	report.FailedRequests, report.DowntimeWindows = summarizeProbeResponses(collected)
+	logger.Logf(t, "%s", report)
+	return report, nil
+}
+
+// AssertZeroDowntimeRollout triggers and probes a rollout with RolloutAndProbe, and fails the test with a report of
+// the downtime windows if any request failed. This requires the ProbeURL of the options to be set, since a Tunnel
+// fails requests while the rollout replaces its pod.
+func AssertZeroDowntimeRollout(t testing.TestingT, options *KubectlOptions, rolloutOptions RolloutOptions, trigger func() error) RolloutReport {
+	report, err := AssertZeroDowntimeRolloutE(t, options, rolloutOptions, trigger)
+	require.NoError(t, err)
+	return report
+}
+
+// AssertZeroDowntimeRolloutE triggers and probes a rollout with RolloutAndProbeE, and returns a RolloutDowntime error
+// with the report if any request failed. This requires the ProbeURL of the options to be set, since a Tunnel fails
+// requests while the rollout replaces its pod.
+func AssertZeroDowntimeRolloutE(t testing.TestingT, options *KubectlOptions, rolloutOptions RolloutOptions, trigger func() error) (RolloutReport, error) {
+	if rolloutOptions.ProbeURL == "" {
+		return RolloutReport{DeploymentName: rolloutOptions.DeploymentName}, RolloutProbeURLRequired{Deployment: rolloutOptions.DeploymentName}
+	}
+	report, err := RolloutAndProbeE(t, options, rolloutOptions, trigger)
+	if err != nil {
+		return report, err
+	}
+	if !report.ZeroDowntime() {
+		return report, RolloutDowntime{Report: report}
+	}
+	return report, nil
+}
+
+// withRolloutDefaults returns the options with the defaults set for the fields left empty.
+func withRolloutDefaults(rolloutOptions RolloutOptions) RolloutOptions {
+	if rolloutOptions.Path == "" {
+		rolloutOptions.Path = "/"
+	}
+	if rolloutOptions.ProbeInterval == 0 {
+		rolloutOptions.ProbeInterval = DefaultRolloutProbeInterval
+	}
+	if rolloutOptions.RolloutTimeout == 0 {
+		rolloutOptions.RolloutTimeout = DefaultRolloutTimeout
+	}
+	if rolloutOptions.ProbeAfter == 0 {
+		rolloutOptions.ProbeAfter = DefaultRolloutProbeAfter
+	}
+	return rolloutOptions
+}
+
+// summarizeProbeResponses returns the failed requests among the responses, and groups consecutive failures into
+// downtime windows.
+func summarizeProbeResponses(responses []http_helper.GetResponse) ([]FailedRequest, []DowntimeWindow) {
+	failed := []FailedRequest{}
+	windows := []DowntimeWindow{}
+	var current *DowntimeWindow
+	for _, response := range responses {
+		if response.Err == nil && response.StatusCode == 200 {
+			if current != nil {
+				windows = append(windows, *current)
+				current = nil
+			}
+			continue
+		}
+		failed = append(failed, FailedRequest{Time: response.Time, StatusCode: response.StatusCode, Err: response.Err})
+		if current == nil {
+			current = &DowntimeWindow{Start: response.Time}
+		}
+		current.End = response.Time
+		current.Failures++
+	}
+	if current != nil {
+		windows = append(windows, *current)
+	}
+	return failed, windows
+}
+
+// deploymentRevision returns the revision of a Deployment or ReplicaSet, or 0 if it has none.
+func deploymentRevision(meta *metav1.ObjectMeta) int64 {
+	revision, err := strconv.ParseInt(meta.Annotations[deploymentRevisionAnnotation], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return revision
+}
+
+// WaitUntilDeploymentRolloutComplete waits until the Deployment rolled out a revision newer than previousRevision:
+// every replica runs the new revision and is available, and the ReplicaSets of the previous revisions are scaled down.
+// This returns the ReplicaSet of the new revision, retrying the check for the specified amount of times, sleeping for
+// the provided duration between each try. This will fail the test if there is an error.
+func WaitUntilDeploymentRolloutComplete(t testing.TestingT, options *KubectlOptions, deploymentName string, previousRevision int64, retries int, sleepBetweenRetries time.Duration) *appsv1.ReplicaSet {
+	replicaSet, err := WaitUntilDeploymentRolloutCompleteE(t, options, deploymentName, previousRevision, retries, sleepBetweenRetries)
+	require.NoError(t, err)
+	return replicaSet
+}
+
+// WaitUntilDeploymentRolloutCompleteE waits until the Deployment rolled out a revision newer than previousRevision:
+// every replica runs the new revision and is available, and the ReplicaSets of the previous revisions are scaled down.
+// This returns the ReplicaSet of the new revision, retrying the check for the specified amount of times, sleeping for
+// the provided duration between each try. This stops early if the rollout exceeds its progress deadline.
+func WaitUntilDeploymentRolloutCompleteE(t testing.TestingT, options *KubectlOptions, deploymentName string, previousRevision int64, retries int, sleepBetweenRetries time.Duration) (*appsv1.ReplicaSet, error) {
+	var replicaSet *appsv1.ReplicaSet
+	statusMsg := fmt.Sprintf("Wait for deployment %s to roll out a revision newer than %d.", deploymentName, previousRevision)
+	message, err := retry.DoWithRetryE(
+		t,
+		statusMsg,
+		retries,
+		sleepBetweenRetries,
+		func() (string, error) {
+			deployment, err := GetDeploymentE(t, options, deploymentName)
+			if err != nil {
+				return "", err
+			}
+			revision := deploymentRevision(&deployment.ObjectMeta)
+			if revision <= previousRevision {
+				return "", DeploymentRolloutNotComplete{Deployment: deploymentName, Reason: fmt.Sprintf("still at revision %d", revision)}
+			}
+			if condition := getDeploymentCondition(deployment, appsv1.DeploymentProgressing); condition != nil && condition.Reason == "ProgressDeadlineExceeded" {
+				return "", retry.FatalError{Underlying: DeploymentRolloutNotComplete{Deployment: deploymentName, Reason: condition.Message}}
+			}
+			ready, reason, err := deploymentReadiness(deployment)
+			if err != nil {
+				return "", err
+			}
+			if !ready {
+				return "", DeploymentRolloutNotComplete{Deployment: deploymentName, Reason: reason}
+			}
+			replicaSet, err = getDeploymentReplicaSetE(t, options, deployment, revision)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Deployment %s rolled out revision %d with replica set %s", deploymentName, revision, replicaSet.Name), nil
+		},
+	)
+	if err != nil {
+		logger.Logf(t, "Timedout waiting for Deployment rollout to complete: %s", err)
+		return nil, err
+	}
+	logger.Logf(t, message)
+	return replicaSet, nil
+}
+
+// getDeploymentReplicaSetE returns the ReplicaSet owned by the Deployment for the given revision.
+func getDeploymentReplicaSetE(t testing.TestingT, options *KubectlOptions, deployment *appsv1.Deployment, revision int64) (*appsv1.ReplicaSet, error) {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	replicaSets, err := ListReplicaSetsE(t, options, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	for i := range replicaSets {
+		replicaSet := &replicaSets[i]
+		if metav1.IsControlledBy(replicaSet, deployment) && deploymentRevision(&replicaSet.ObjectMeta) == revision {
+			return replicaSet, nil
+		}
+	}
+	return nil, DeploymentRolloutNotComplete{Deployment: deployment.Name, Reason: fmt.Sprintf("no replica set found for revision %d", revision)}
+}
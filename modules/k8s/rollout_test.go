@@ -0,0 +1,99 @@
+//go:build kubeall || kubernetes
+// +build kubeall kubernetes
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests. This is done because minikube
+// is heavy and can interfere with docker related tests in terratest. Specifically, many of the tests start to fail with
+// `connection refused` errors from `minikube`. To avoid overloading the system, we run the kubernetes tests and helm
+// tests separately from the others. This may not be necessary if you have a sufficiently powerful machine.  We
+// recommend at least 4 cores and 16GB of RAM if you want to run all the tests together.
+
+package k8s
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gruntwork-io/terratest/modules/random"
+)
+
+func TestRolloutAndProbe(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	KubectlApplyFromString(t, options, fmt.Sprintf(exampleRolloutYAMLTemplate, uniqueID, uniqueID, "1.24.0"))
+	defer KubectlDeleteFromString(t, options, fmt.Sprintf(exampleRolloutYAMLTemplate, uniqueID, uniqueID, "1.24.0"))
+	WaitUntilDeploymentAvailable(t, options, "nginx-rollout", 60, 1*time.Second)
+	WaitUntilServiceAvailable(t, options, "nginx-rollout", 60, 1*time.Second)
+
+	// Probe the NodePort of the Service, which load balances between the pods as they are replaced, rather than a
+	// Tunnel to one of them.
+	service := GetService(t, options, "nginx-rollout")
+	rolloutOptions := RolloutOptions{
+		DeploymentName: "nginx-rollout",
+		ProbeURL:       fmt.Sprintf("http://%s/", GetServiceEndpoint(t, options, service, 80)),
+	}
+	report := AssertZeroDowntimeRollout(t, options, rolloutOptions, func() error {
+		return KubectlApplyFromStringE(t, options, fmt.Sprintf(exampleRolloutYAMLTemplate, uniqueID, uniqueID, "1.25.0"))
+	})
+	assert.Equal(t, report.PreviousRevision+1, report.Revision)
+	assert.NotEmpty(t, report.ReplicaSetName)
+	assert.Greater(t, report.Requests, 0)
+	assert.True(t, report.Completed.After(report.Started))
+	assert.True(t, report.ZeroDowntime())
+
+	replicaSet := GetReplicaSet(t, options, report.ReplicaSetName)
+	assert.Equal(t, "nginx:1.25.0", replicaSet.Spec.Template.Spec.Containers[0].Image)
+}
+
+const exampleRolloutYAMLTemplate = `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: %s
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-rollout
+  namespace: %s
+spec:
+  replicas: 2
+  strategy:
+    rollingUpdate:
+      maxUnavailable: 0
+      maxSurge: 1
+  selector:
+    matchLabels:
+      app: nginx-rollout
+  template:
+    metadata:
+      labels:
+        app: nginx-rollout
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:%s
+        ports:
+        - containerPort: 80
+        readinessProbe:
+          httpGet:
+            path: /
+            port: 80
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: nginx-rollout
+spec:
+  type: NodePort
+  selector:
+    app: nginx-rollout
+  ports:
+  - port: 80
+    targetPort: 80
+`
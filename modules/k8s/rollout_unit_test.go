@@ -0,0 +1,46 @@
+package k8s
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
+)
+
+func TestSummarizeProbeResponses(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	responses := []http_helper.GetResponse{
+		{StatusCode: 200, Time: at(0)},
+		{StatusCode: 503, Time: at(1)},
+		{Err: errors.New("connection refused"), Time: at(2)},
+		{StatusCode: 200, Time: at(3)},
+		{StatusCode: 502, Time: at(4)},
+	}
+	failed, windows := summarizeProbeResponses(responses)
+	require.Len(t, failed, 3)
+	assert.Equal(t, 503, failed[0].StatusCode)
+	assert.Error(t, failed[1].Err)
+	require.Len(t, windows, 2)
+	assert.Equal(t, 2, windows[0].Failures)
+	assert.Equal(t, time.Second, windows[0].Duration())
+	assert.Equal(t, at(4), windows[1].Start)
+}
+
+func TestAssertZeroDowntimeRolloutRequiresProbeURL(t *testing.T) {
+	t.Parallel()
+
+	triggered := false
+	_, err := AssertZeroDowntimeRolloutE(t, NewKubectlOptions("", "", "default"), RolloutOptions{DeploymentName: "web", ServiceName: "web", ServicePort: 80}, func() error {
+		triggered = true
+		return nil
+	})
+	assert.Equal(t, RolloutProbeURLRequired{Deployment: "web"}, err)
+	assert.False(t, triggered)
+}
@@ -0,0 +1,269 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/require"
+	authv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// AccessReviewSubject is the user, with its groups, whose permissions are checked with a SubjectAccessReview. The
+// client configured by the KubectlOptions must be allowed to create SubjectAccessReviews, which is the case for cluster
+// admins.
+type AccessReviewSubject struct {
+	User   string
+	Groups []string
+}
+
+// NewUserAccessReviewSubject returns the subject for the given user, as a member of the given groups.
+func NewUserAccessReviewSubject(user string, groups ...string) AccessReviewSubject {
+	return AccessReviewSubject{User: user, Groups: groups}
+}
+
+// NewGroupAccessReviewSubject returns a subject that is only a member of the given group, which can be used to check
+// the permissions granted to a group independently of any user.
+func NewGroupAccessReviewSubject(group string) AccessReviewSubject {
+	return AccessReviewSubject{Groups: []string{group}}
+}
+
+// NewServiceAccountAccessReviewSubject returns the subject that the API server authenticates the given ServiceAccount
+// as, including the groups that every ServiceAccount belongs to.
+func NewServiceAccountAccessReviewSubject(namespace string, serviceAccountName string) AccessReviewSubject {
+	return AccessReviewSubject{
+		User: fmt.Sprintf("system:serviceaccount:%s:%s", namespace, serviceAccountName),
+		Groups: []string{
+			"system:serviceaccounts",
+			fmt.Sprintf("system:serviceaccounts:%s", namespace),
+			"system:authenticated",
+		},
+	}
+}
+
+// String returns the user of the subject, or its groups when there is no user.
+func (subject AccessReviewSubject) String() string {
+	if subject.User != "" {
+		return subject.User
+	}
+	return fmt.Sprintf("groups %s", strings.Join(subject.Groups, ", "))
+}
+
+// CanSubjectDo returns whether or not the provided action is allowed for the given subject. This will fail if there
+// are any errors accessing the kubernetes API (but not if the action is denied).
+func CanSubjectDo(t testing.TestingT, options *KubectlOptions, subject AccessReviewSubject, action authv1.ResourceAttributes) bool {
+	allowed, err := CanSubjectDoE(t, options, subject, action)
+	require.NoError(t, err)
+	return allowed
+}
+
+// CanSubjectDoE returns whether or not the provided action is allowed for the given subject. This will return an error
+// if there are problems accessing the kubernetes API (but not if the action is simply denied).
+func CanSubjectDoE(t testing.TestingT, options *KubectlOptions, subject AccessReviewSubject, action authv1.ResourceAttributes) (bool, error) {
+	allowed, _, err := subjectAccessReviewE(t, options, subject, action)
+	return allowed, err
+}
+
+// subjectAccessReviewE creates a SubjectAccessReview for the given subject and action, and returns whether the action
+// is allowed along with the reason given by the authorizer.
+func subjectAccessReviewE(t testing.TestingT, options *KubectlOptions, subject AccessReviewSubject, action authv1.ResourceAttributes) (bool, string, error) {
+	clientset, err := GetKubernetesClientFromOptionsE(t, options)
+	if err != nil {
+		return false, "", err
+	}
+	review := authv1.SubjectAccessReview{
+		Spec: authv1.SubjectAccessReviewSpec{
+			ResourceAttributes: &action,
+			User:               subject.User,
+			Groups:             subject.Groups,
+		},
+	}
+	resp, err := clientset.AuthorizationV1().SubjectAccessReviews().Create(context.Background(), &review, metav1.CreateOptions{})
+	if err != nil {
+		return false, "", errors.WithStackTrace(err)
+	}
+	return resp.Status.Allowed, resp.Status.Reason, nil
+}
+
+// PermissionExpectation is a row of a permission matrix: whether the action should be allowed or denied.
+type PermissionExpectation struct {
+	Action  authv1.ResourceAttributes
+	Allowed bool
+}
+
+// NewPermissionExpectations returns the expectations for every combination of the given verbs, resources and
+// namespaces, which makes it easy to build exhaustive permission matrices. Resources are written the same way as in
+// `kubectl auth can-i`: RESOURCE[.GROUP][/SUBRESOURCE], for example "pods", "pods/log" or "deployments.apps". Use an
+// empty namespace for cluster scoped resources, or to check the action across all namespaces.
+func NewPermissionExpectations(verbs []string, resources []string, namespaces []string, allowed bool) []PermissionExpectation {
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+	expectations := []PermissionExpectation{}
+	for _, namespace := range namespaces {
+		for _, resource := range resources {
+			for _, verb := range verbs {
+				action := parseResourceAttributes(resource)
+				action.Verb = verb
+				action.Namespace = namespace
+				expectations = append(expectations, PermissionExpectation{Action: action, Allowed: allowed})
+			}
+		}
+	}
+	return expectations
+}
+
+// parseResourceAttributes turns a resource written as RESOURCE[.GROUP][/SUBRESOURCE] into ResourceAttributes.
+func parseResourceAttributes(resource string) authv1.ResourceAttributes {
+	action := authv1.ResourceAttributes{}
+	if idx := strings.Index(resource, "/"); idx >= 0 {
+		action.Subresource = resource[idx+1:]
+		resource = resource[:idx]
+	}
+	if idx := strings.Index(resource, "."); idx >= 0 {
+		action.Group = resource[idx+1:]
+		resource = resource[:idx]
+	}
+	action.Resource = resource
+	return action
+}
+
+// PermissionCheckResult is the outcome of checking a single PermissionExpectation.
+type PermissionCheckResult struct {
+	Expectation PermissionExpectation
+	Allowed     bool
+	Reason      string
+}
+
+// Matches returns true if the action was allowed or denied as expected.
+func (result PermissionCheckResult) Matches() bool {
+	return result.Allowed == result.Expectation.Allowed
+}
+
+// PermissionMatrixReport is the outcome of checking a permission matrix for a subject.
+type PermissionMatrixReport struct {
+	Subject AccessReviewSubject
+	Results []PermissionCheckResult
+}
+
+// UnexpectedlyGranted returns the checks of actions that were allowed but expected to be denied.
+func (report PermissionMatrixReport) UnexpectedlyGranted() []PermissionCheckResult {
+	return report.filter(func(result PermissionCheckResult) bool { return result.Allowed && !result.Matches() })
+}
+
+// UnexpectedlyDenied returns the checks of actions that were denied but expected to be allowed.
+func (report PermissionMatrixReport) UnexpectedlyDenied() []PermissionCheckResult {
+	return report.filter(func(result PermissionCheckResult) bool { return !result.Allowed && !result.Matches() })
+}
+
+// Matches returns true if every action was allowed or denied as expected.
+func (report PermissionMatrixReport) Matches() bool {
+	return len(report.filter(func(result PermissionCheckResult) bool { return !result.Matches() })) == 0
+}
+
+func (report PermissionMatrixReport) filter(keep func(PermissionCheckResult) bool) []PermissionCheckResult {
+	results := []PermissionCheckResult{}
+	for _, result := range report.Results {
+		if keep(result) {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// String returns a human readable report listing the permissions that were unexpectedly granted or denied.
+func (report PermissionMatrixReport) String() string {
+	var builder strings.Builder
+	granted := report.UnexpectedlyGranted()
+	denied := report.UnexpectedlyDenied()
+	fmt.Fprintf(
+		&builder,
+		"Permission matrix for %s: %d checks, %d unexpectedly granted, %d unexpectedly denied\n",
+		report.Subject,
+		len(report.Results),
+		len(granted),
+		len(denied),
+	)
+	writeSection := func(title string, results []PermissionCheckResult) {
+		if len(results) == 0 {
+			return
+		}
+		fmt.Fprintf(&builder, "%s:\n", title)
+		for _, result := range results {
+			fmt.Fprintf(&builder, "  - %s", formatResourceAttributes(result.Expectation.Action))
+			if result.Reason != "" {
+				fmt.Fprintf(&builder, " (%s)", result.Reason)
+			}
+			builder.WriteString("\n")
+		}
+	}
+	writeSection("Unexpectedly granted", granted)
+	writeSection("Unexpectedly denied", denied)
+	return builder.String()
+}
+
+// formatResourceAttributes formats the action like the arguments of `kubectl auth can-i`.
+func formatResourceAttributes(action authv1.ResourceAttributes) string {
+	resource := action.Resource
+	if action.Group != "" {
+		resource = fmt.Sprintf("%s.%s", resource, action.Group)
+	}
+	if action.Subresource != "" {
+		resource = fmt.Sprintf("%s/%s", resource, action.Subresource)
+	}
+	if action.Name != "" {
+		resource = fmt.Sprintf("%s/%s", resource, action.Name)
+	}
+	if action.Namespace == "" {
+		return fmt.Sprintf("%s %s (all namespaces)", action.Verb, resource)
+	}
+	return fmt.Sprintf("%s %s in namespace %s", action.Verb, resource, action.Namespace)
+}
+
+// CheckPermissionMatrix checks every expectation of the permission matrix for the given subject and returns a report.
+// This will fail if there are any errors accessing the kubernetes API, but not if some expectations don't match.
+func CheckPermissionMatrix(t testing.TestingT, options *KubectlOptions, subject AccessReviewSubject, expectations []PermissionExpectation) PermissionMatrixReport {
+	report, err := CheckPermissionMatrixE(t, options, subject, expectations)
+	require.NoError(t, err)
+	return report
+}
+
+// CheckPermissionMatrixE checks every expectation of the permission matrix for the given subject and returns a report.
+// This will return an error if there are problems accessing the kubernetes API, but not if some expectations don't
+// match.
+func CheckPermissionMatrixE(t testing.TestingT, options *KubectlOptions, subject AccessReviewSubject, expectations []PermissionExpectation) (PermissionMatrixReport, error) {
+	report := PermissionMatrixReport{Subject: subject, Results: []PermissionCheckResult{}}
+	for _, expectation := range expectations {
+		allowed, reason, err := subjectAccessReviewE(t, options, subject, expectation.Action)
+		if err != nil {
+			return report, err
+		}
+		report.Results = append(report.Results, PermissionCheckResult{Expectation: expectation, Allowed: allowed, Reason: reason})
+	}
+	return report, nil
+}
+
+// AssertPermissionMatrix checks every expectation of the permission matrix for the given subject, and fails the test
+// with a report of the permissions that were unexpectedly granted or denied if any expectation doesn't match.
+func AssertPermissionMatrix(t testing.TestingT, options *KubectlOptions, subject AccessReviewSubject, expectations []PermissionExpectation) {
+	require.NoError(t, AssertPermissionMatrixE(t, options, subject, expectations))
+}
+
+// AssertPermissionMatrixE checks every expectation of the permission matrix for the given subject, and returns a
+// PermissionMatrixMismatch error with the report if any expectation doesn't match.
+func AssertPermissionMatrixE(t testing.TestingT, options *KubectlOptions, subject AccessReviewSubject, expectations []PermissionExpectation) error {
+	report, err := CheckPermissionMatrixE(t, options, subject, expectations)
+	if err != nil {
+		return err
+	}
+	if !report.Matches() {
+		return PermissionMatrixMismatch{Report: report}
+	}
+	logger.Logf(t, "All %d permission checks for %s matched", len(report.Results), subject)
+	return nil
+}
@@ -0,0 +1,66 @@
+//go:build kubeall || kubernetes
+// +build kubeall kubernetes
+
+// NOTE: we have build tags to differentiate kubernetes tests from non-kubernetes tests. This is done because minikube
+// is heavy and can interfere with docker related tests in terratest. Specifically, many of the tests start to fail with
+// `connection refused` errors from `minikube`. To avoid overloading the system, we run the kubernetes tests and helm
+// tests separately from the others. This may not be necessary if you have a sufficiently powerful machine.  We
+// recommend at least 4 cores and 16GB of RAM if you want to run all the tests together.
+
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "k8s.io/api/authorization/v1"
+)
+
+func TestCanSubjectDoForAdminAndServiceAccount(t *testing.T) {
+	t.Parallel()
+
+	action := authv1.ResourceAttributes{
+		Namespace: "kube-system",
+		Verb:      "list",
+		Resource:  "pods",
+	}
+	options := NewKubectlOptions("", "", "kube-system")
+	assert.True(t, CanSubjectDo(t, options, NewUserAccessReviewSubject("terratest-admin", "system:masters"), action))
+	assert.False(t, CanSubjectDo(t, options, NewServiceAccountAccessReviewSubject("default", "terratest-unknown"), action))
+}
+
+func TestAssertPermissionMatrixReportsMismatches(t *testing.T) {
+	t.Parallel()
+
+	options := NewKubectlOptions("", "", "default")
+	subject := NewServiceAccountAccessReviewSubject("default", "terratest-unknown")
+	expectations := append(
+		NewPermissionExpectations([]string{"get", "list"}, []string{"pods", "deployments.apps"}, []string{"default", "kube-system"}, false),
+		PermissionExpectation{Action: authv1.ResourceAttributes{Verb: "delete", Resource: "nodes"}, Allowed: true},
+	)
+
+	report := CheckPermissionMatrix(t, options, subject, expectations)
+	require.Len(t, report.Results, 9)
+	assert.Empty(t, report.UnexpectedlyGranted())
+	require.Len(t, report.UnexpectedlyDenied(), 1)
+	assert.Equal(t, "nodes", report.UnexpectedlyDenied()[0].Expectation.Action.Resource)
+
+	err := AssertPermissionMatrixE(t, options, subject, expectations)
+	require.Error(t, err)
+	assert.IsType(t, PermissionMatrixMismatch{}, err)
+	assert.Contains(t, err.Error(), "Unexpectedly denied:\n  - delete nodes (all namespaces)")
+
+	AssertPermissionMatrix(t, options, subject, expectations[:8])
+}
+
+func TestNewPermissionExpectationsParsesResources(t *testing.T) {
+	t.Parallel()
+
+	expectations := NewPermissionExpectations([]string{"get"}, []string{"pods/log", "deployments.apps", "ingresses.networking.k8s.io/status"}, nil, true)
+	require.Len(t, expectations, 3)
+	assert.Equal(t, authv1.ResourceAttributes{Verb: "get", Resource: "pods", Subresource: "log"}, expectations[0].Action)
+	assert.Equal(t, authv1.ResourceAttributes{Verb: "get", Resource: "deployments", Group: "apps"}, expectations[1].Action)
+	assert.Equal(t, authv1.ResourceAttributes{Verb: "get", Resource: "ingresses", Group: "networking.k8s.io", Subresource: "status"}, expectations[2].Action)
+	assert.True(t, expectations[0].Allowed)
+}
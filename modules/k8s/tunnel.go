@@ -3,9 +3,11 @@ package k8s
 // The following code is a fork of the Helm client. The main differences are:
 // - Support testing context for better logging
 // - Support resources other than pods
+// - Reconnect to a new pod when the pod backing the tunnel goes away
 // See: https://github.com/helm/helm/blob/master/pkg/kube/tunnel.go
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net"
@@ -13,9 +15,15 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/portforward"
 	"k8s.io/client-go/transport/spdy"
 
@@ -26,6 +34,12 @@ import (
 // Global lock to synchronize port selections
 var globalMutex sync.Mutex
 
+// tunnelReconnectInterval is how long the tunnel waits between attempts to reconnect to a new pod.
+const tunnelReconnectInterval = 1 * time.Second
+
+// tunnelEventsBufferSize is the number of events that are buffered on the Events channel before new ones are dropped.
+const tunnelEventsBufferSize = 100
+
 // KubeResourceType is an enum representing known resource types that can support port forwarding
 type KubeResourceType int
 
@@ -36,6 +50,15 @@ const (
 	ResourceTypeDeployment
 	// ResourceTypeService is a k8s service kind identifier
 	ResourceTypeService
+	// ResourceTypeStatefulSet is a k8s statefulset kind identifier
+	ResourceTypeStatefulSet
+	// ResourceTypeDaemonSet is a k8s daemonset kind identifier
+	ResourceTypeDaemonSet
+	// ResourceTypeReplicaSet is a k8s replicaset kind identifier
+	ResourceTypeReplicaSet
+	// ResourceTypeLabelSelector targets any available pod matching a label selector (e.g. app=nginx,tier=web), which is
+	// passed in place of the resource name.
+	ResourceTypeLabelSelector
 )
 
 func (resourceType KubeResourceType) String() string {
@@ -46,12 +69,72 @@ func (resourceType KubeResourceType) String() string {
 		return "pod"
 	case ResourceTypeService:
 		return "svc"
+	case ResourceTypeStatefulSet:
+		return "sts"
+	case ResourceTypeDaemonSet:
+		return "ds"
+	case ResourceTypeReplicaSet:
+		return "rs"
+	case ResourceTypeLabelSelector:
+		return "selector"
 	default:
 		// This should not happen
 		return "UNKNOWN_RESOURCE_TYPE"
 	}
 }
 
+// TunnelState is an enum representing the connection state of a Tunnel.
+type TunnelState int
+
+const (
+	// TunnelStateNotConnected is the state of a tunnel before ForwardPort is called.
+	TunnelStateNotConnected TunnelState = iota
+	// TunnelStateConnected means the tunnel is forwarding traffic to a pod.
+	TunnelStateConnected
+	// TunnelStateReconnecting means the pod backing the tunnel went away and the tunnel is looking for a new one.
+	TunnelStateReconnecting
+	// TunnelStateClosed means the tunnel was closed and will no longer forward traffic.
+	TunnelStateClosed
+)
+
+func (state TunnelState) String() string {
+	switch state {
+	case TunnelStateNotConnected:
+		return "NotConnected"
+	case TunnelStateConnected:
+		return "Connected"
+	case TunnelStateReconnecting:
+		return "Reconnecting"
+	case TunnelStateClosed:
+		return "Closed"
+	default:
+		// This should not happen
+		return "UNKNOWN_TUNNEL_STATE"
+	}
+}
+
+// TunnelEvent is published on the Events channel of a Tunnel every time its state changes, and every time an attempt
+// to reconnect fails.
+type TunnelEvent struct {
+	// State is the state of the tunnel after the event.
+	State TunnelState
+
+	// PodName is the pod the tunnel is (or was, when reconnecting) forwarding traffic to.
+	PodName string
+
+	// Err is the error that caused the event, if any.
+	Err error
+}
+
+// TunnelPort is a single local to remote port mapping of a Tunnel.
+type TunnelPort struct {
+	// Local is the port on the host. Use 0 to select an open port on the host automatically.
+	Local int
+
+	// Remote is the port on the pod.
+	Remote int
+}
+
 // makeLabels is a helper to format a map of label key and value pairs into a single string for use as a selector.
 func makeLabels(labels map[string]string) string {
 	out := []string{}
@@ -61,17 +144,38 @@ func makeLabels(labels map[string]string) string {
 	return strings.Join(out, ",")
 }
 
-// Tunnel is the main struct that configures and manages port forwading tunnels to Kubernetes resources.
+// Tunnel is the main struct that configures and manages port forwading tunnels to Kubernetes resources. Once the port
+// is forwarded, the tunnel watches the pod it is attached to and transparently reconnects to a new pod of the resource
+// when that pod is deleted, stops running or one of its containers restarts, until Close is called.
 type Tunnel struct {
 	out            io.Writer
-	localPort      int
-	remotePort     int
+	ports          []TunnelPort
 	kubectlOptions *KubectlOptions
 	resourceType   KubeResourceType
 	resourceName   string
 	logger         logger.TestLogger
 	stopChan       chan struct{}
-	readyChan      chan struct{}
+	closeOnce      sync.Once
+	events         chan TunnelEvent
+
+	// mutex protects the fields below, which are updated by the goroutine managing the connection.
+	mutex   sync.Mutex
+	state   TunnelState
+	podName string
+}
+
+// tunnelConnection represents a single port forwarding session to one pod. A Tunnel goes through one connection per
+// pod it is attached to over its lifetime.
+type tunnelConnection struct {
+	podName  string
+	stopChan chan struct{}
+	stopOnce sync.Once
+	errChan  chan error
+}
+
+// stop closes the connection, if it is not already closed.
+func (conn *tunnelConnection) stop() {
+	conn.stopOnce.Do(func() { close(conn.stopChan) })
 }
 
 // NewTunnel creates a new tunnel with NewTunnelWithLogger, setting logger.Terratest as the logger.
@@ -89,28 +193,108 @@ func NewTunnelWithLogger(
 	local int,
 	remote int,
 	logger logger.TestLogger,
+) *Tunnel {
+	return NewMultiPortTunnelWithLogger(kubectlOptions, resourceType, resourceName, []TunnelPort{{Local: local, Remote: remote}}, logger)
+}
+
+// NewMultiPortTunnel creates a new tunnel forwarding all the given ports with NewMultiPortTunnelWithLogger, setting
+// logger.Terratest as the logger.
+func NewMultiPortTunnel(kubectlOptions *KubectlOptions, resourceType KubeResourceType, resourceName string, ports []TunnelPort) *Tunnel {
+	return NewMultiPortTunnelWithLogger(kubectlOptions, resourceType, resourceName, ports, logger.Terratest)
+}
+
+// NewMultiPortTunnelWithLogger will create a new Tunnel struct forwarding all the given ports with the provided logger.
+// Note that if you use 0 for a local port, an open port on the host system will be selected automatically, and the
+// Tunnel struct will be updated with the selected port.
+func NewMultiPortTunnelWithLogger(
+	kubectlOptions *KubectlOptions,
+	resourceType KubeResourceType,
+	resourceName string,
+	ports []TunnelPort,
+	logger logger.TestLogger,
 ) *Tunnel {
 	return &Tunnel{
 		out:            io.Discard,
-		localPort:      local,
-		remotePort:     remote,
+		ports:          append([]TunnelPort{}, ports...),
 		kubectlOptions: kubectlOptions,
 		resourceType:   resourceType,
 		resourceName:   resourceName,
 		logger:         logger,
 		stopChan:       make(chan struct{}, 1),
-		readyChan:      make(chan struct{}, 1),
+		events:         make(chan TunnelEvent, tunnelEventsBufferSize),
+		state:          TunnelStateNotConnected,
 	}
 }
 
-// Endpoint returns the tunnel endpoint
+// Endpoint returns the tunnel endpoint of the first port
 func (tunnel *Tunnel) Endpoint() string {
-	return fmt.Sprintf("localhost:%d", tunnel.localPort)
+	return fmt.Sprintf("localhost:%d", tunnel.ports[0].Local)
+}
+
+// EndpointForRemotePort returns the tunnel endpoint for the given remote port, or an empty string if the tunnel does
+// not forward that port.
+func (tunnel *Tunnel) EndpointForRemotePort(remote int) string {
+	for _, port := range tunnel.ports {
+		if port.Remote == remote {
+			return fmt.Sprintf("localhost:%d", port.Local)
+		}
+	}
+	return ""
+}
+
+// Ports returns the port mappings of the tunnel, including the local ports that were selected automatically.
+func (tunnel *Tunnel) Ports() []TunnelPort {
+	return append([]TunnelPort{}, tunnel.ports...)
+}
+
+// State returns the current connection state of the tunnel.
+func (tunnel *Tunnel) State() TunnelState {
+	tunnel.mutex.Lock()
+	defer tunnel.mutex.Unlock()
+	return tunnel.state
+}
+
+// PodName returns the name of the pod the tunnel is currently attached to.
+func (tunnel *Tunnel) PodName() string {
+	tunnel.mutex.Lock()
+	defer tunnel.mutex.Unlock()
+	return tunnel.podName
+}
+
+// Events returns a channel on which every state change of the tunnel, and every failed attempt to reconnect, is
+// published. The channel is buffered and events are dropped when nobody reads it, so it is safe to ignore.
+func (tunnel *Tunnel) Events() <-chan TunnelEvent {
+	return tunnel.events
 }
 
-// Close disconnects a tunnel connection by closing the StopChan, thereby stopping the goroutine.
+// Close disconnects a tunnel connection by closing the StopChan, thereby stopping the goroutine. It is safe to call
+// Close more than once.
 func (tunnel *Tunnel) Close() {
-	close(tunnel.stopChan)
+	tunnel.closeOnce.Do(func() { close(tunnel.stopChan) })
+}
+
+// isClosed returns true if Close has been called on the tunnel.
+func (tunnel *Tunnel) isClosed() bool {
+	select {
+	case <-tunnel.stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// setState records the new state of the tunnel and publishes the corresponding event, without blocking if nobody is
+// reading the Events channel.
+func (tunnel *Tunnel) setState(state TunnelState, podName string, err error) {
+	tunnel.mutex.Lock()
+	tunnel.state = state
+	tunnel.podName = podName
+	tunnel.mutex.Unlock()
+
+	select {
+	case tunnel.events <- TunnelEvent{State: state, PodName: podName, Err: err}:
+	default:
+	}
 }
 
 // getAttachablePodForResource will find a pod that can be port forwarded to given the provided resource type and return
@@ -123,6 +307,14 @@ func (tunnel *Tunnel) getAttachablePodForResourceE(t testing.TestingT) (string,
 		return tunnel.getAttachablePodForServiceE(t)
 	case ResourceTypeDeployment:
 		return tunnel.getAttachablePodForDeploymentE(t)
+	case ResourceTypeStatefulSet:
+		return tunnel.getAttachablePodForStatefulSetE(t)
+	case ResourceTypeDaemonSet:
+		return tunnel.getAttachablePodForDaemonSetE(t)
+	case ResourceTypeReplicaSet:
+		return tunnel.getAttachablePodForReplicaSetE(t)
+	case ResourceTypeLabelSelector:
+		return tunnel.getAttachablePodForSelectorE(t, tunnel.resourceName)
 	default:
 		return "", UnknownKubeResourceType{tunnel.resourceType}
 	}
@@ -134,17 +326,54 @@ func (tunnel *Tunnel) getAttachablePodForDeploymentE(t testing.TestingT) (string
 	if err != nil {
 		return "", err
 	}
-	selectorLabelsOfPods := makeLabels(deploy.Spec.Selector.MatchLabels)
-	deploymentPods, err := ListPodsE(t, tunnel.kubectlOptions, metav1.ListOptions{LabelSelector: selectorLabelsOfPods})
+	podName, err := tunnel.findAvailablePodE(t, deploy.Spec.Selector)
 	if err != nil {
 		return "", err
 	}
-	for _, pod := range deploymentPods {
-		if IsPodAvailable(&pod) {
-			return pod.Name, nil
-		}
+	if podName == "" {
+		return "", DeploymentNotAvailable{deploy}
+	}
+	return podName, nil
+}
+
+// getAttachablePodForStatefulSetE will find an active pod associated with the StatefulSet and return the pod name.
+func (tunnel *Tunnel) getAttachablePodForStatefulSetE(t testing.TestingT) (string, error) {
+	statefulSet, err := GetStatefulSetE(t, tunnel.kubectlOptions, tunnel.resourceName)
+	if err != nil {
+		return "", err
+	}
+	return tunnel.getAttachablePodForControllerE(t, statefulSet.Spec.Selector)
+}
+
+// getAttachablePodForDaemonSetE will find an active pod associated with the DaemonSet and return the pod name.
+func (tunnel *Tunnel) getAttachablePodForDaemonSetE(t testing.TestingT) (string, error) {
+	daemonSet, err := GetDaemonSetE(t, tunnel.kubectlOptions, tunnel.resourceName)
+	if err != nil {
+		return "", err
+	}
+	return tunnel.getAttachablePodForControllerE(t, daemonSet.Spec.Selector)
+}
+
+// getAttachablePodForReplicaSetE will find an active pod associated with the ReplicaSet and return the pod name.
+func (tunnel *Tunnel) getAttachablePodForReplicaSetE(t testing.TestingT) (string, error) {
+	replicaSet, err := GetReplicaSetE(t, tunnel.kubectlOptions, tunnel.resourceName)
+	if err != nil {
+		return "", err
+	}
+	return tunnel.getAttachablePodForControllerE(t, replicaSet.Spec.Selector)
+}
+
+// getAttachablePodForControllerE will find an active pod matching the selector of a workload controller and return the
+// pod name.
+func (tunnel *Tunnel) getAttachablePodForControllerE(t testing.TestingT, selector *metav1.LabelSelector) (string, error) {
+	podName, err := tunnel.findAvailablePodE(t, selector)
+	if err != nil {
+		return "", err
 	}
-	return "", DeploymentNotAvailable{deploy}
+	if podName == "" {
+		return "", NewNoAvailablePodForResourceError(tunnel.resourceType, tunnel.resourceName)
+	}
+	return podName, nil
 }
 
 // getAttachablePodForServiceE will find an active pod associated with the Service and return the pod name.
@@ -153,17 +382,51 @@ func (tunnel *Tunnel) getAttachablePodForServiceE(t testing.TestingT) (string, e
 	if err != nil {
 		return "", err
 	}
-	selectorLabelsOfPods := makeLabels(service.Spec.Selector)
-	servicePods, err := ListPodsE(t, tunnel.kubectlOptions, metav1.ListOptions{LabelSelector: selectorLabelsOfPods})
+	podName, err := tunnel.findAvailablePodBySelectorE(t, makeLabels(service.Spec.Selector))
+	if err != nil {
+		return "", err
+	}
+	if podName == "" {
+		return "", ServiceNotAvailable{service}
+	}
+	return podName, nil
+}
+
+// getAttachablePodForSelectorE will find an active pod matching the given label selector and return the pod name.
+func (tunnel *Tunnel) getAttachablePodForSelectorE(t testing.TestingT, selector string) (string, error) {
+	podName, err := tunnel.findAvailablePodBySelectorE(t, selector)
+	if err != nil {
+		return "", err
+	}
+	if podName == "" {
+		return "", NewNoAvailablePodForResourceError(tunnel.resourceType, selector)
+	}
+	return podName, nil
+}
+
+// findAvailablePodE returns the name of the first available pod matching the given label selector, or an empty string
+// if there is none.
+func (tunnel *Tunnel) findAvailablePodE(t testing.TestingT, selector *metav1.LabelSelector) (string, error) {
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return "", err
+	}
+	return tunnel.findAvailablePodBySelectorE(t, labelSelector.String())
+}
+
+// findAvailablePodBySelectorE returns the name of the first available pod matching the given label selector string,
+// or an empty string if there is none.
+func (tunnel *Tunnel) findAvailablePodBySelectorE(t testing.TestingT, selector string) (string, error) {
+	pods, err := ListPodsE(t, tunnel.kubectlOptions, metav1.ListOptions{LabelSelector: selector})
 	if err != nil {
 		return "", err
 	}
-	for _, pod := range servicePods {
-		if IsPodAvailable(&pod) {
+	for _, pod := range pods {
+		if IsPodAvailable(&pod) && pod.DeletionTimestamp == nil {
 			return pod.Name, nil
 		}
 	}
-	return "", ServiceNotAvailable{service}
+	return "", nil
 }
 
 // ForwardPort opens a tunnel to a kubernetes resource, as specified by the provided tunnel struct. This will fail the
@@ -172,15 +435,16 @@ func (tunnel *Tunnel) ForwardPort(t testing.TestingT) {
 	require.NoError(t, tunnel.ForwardPortE(t))
 }
 
-// ForwardPortE opens a tunnel to a kubernetes resource, as specified by the provided tunnel struct.
+// ForwardPortE opens a tunnel to a kubernetes resource, as specified by the provided tunnel struct. Once the tunnel is
+// open, it is kept open in the background, reconnecting to another pod of the resource whenever the current pod goes
+// away, until Close is called.
 func (tunnel *Tunnel) ForwardPortE(t testing.TestingT) error {
 	tunnel.logger.Logf(
 		t,
-		"Creating a port forwarding tunnel for resource %s/%s routing local port %d to remote port %d",
+		"Creating a port forwarding tunnel for resource %s/%s routing ports %s",
 		tunnel.resourceType.String(),
 		tunnel.resourceName,
-		tunnel.localPort,
-		tunnel.remotePort,
+		strings.Join(tunnel.portSpecs(), ", "),
 	)
 
 	// Prepare a kubernetes client for the client-go library
@@ -189,22 +453,66 @@ func (tunnel *Tunnel) ForwardPortE(t testing.TestingT) error {
 		tunnel.logger.Logf(t, "Error creating a new Kubernetes client: %s", err)
 		return err
 	}
-	kubeConfigPath, err := tunnel.kubectlOptions.GetConfigPath(t)
+	config, err := GetRestConfigFromOptionsE(t, tunnel.kubectlOptions)
 	if err != nil {
-		tunnel.logger.Logf(t, "Error getting kube config path: %s", err)
+		tunnel.logger.Logf(t, "Error loading Kubernetes config: %s", err)
 		return err
 	}
-	config, err := LoadApiClientConfigE(kubeConfigPath, tunnel.kubectlOptions.ContextName)
+
+	// If a local port is 0, get an available port before continuing. We do this here instead of relying on the
+	// underlying portforwarder library, because the portforwarder library does not expose the selected local port in a
+	// machine readable manner. This also guarantees that we reuse the same local port when reconnecting.
+	// Synchronize on the global lock to avoid race conditions with concurrently selecting the same available port,
+	// since there is a brief moment between `GetAvailablePort` and `portforwader.ForwardPorts` where the selected port
+	// is available for selection again.
+	lockedGlobalMutex := false
+	for i := range tunnel.ports {
+		if tunnel.ports[i].Local != 0 {
+			continue
+		}
+		tunnel.logger.Logf(t, "Requested local port for remote port %d is 0. Selecting an open port on host system", tunnel.ports[i].Remote)
+		tunnel.ports[i].Local, err = GetAvailablePortE(t)
+		if err != nil {
+			tunnel.logger.Logf(t, "Error getting available port: %s", err)
+			return err
+		}
+		tunnel.logger.Logf(t, "Selected port %d", tunnel.ports[i].Local)
+		if !lockedGlobalMutex {
+			globalMutex.Lock()
+			defer globalMutex.Unlock()
+			lockedGlobalMutex = true
+		}
+	}
+
+	conn, err := tunnel.connectE(t, clientset, config)
 	if err != nil {
-		tunnel.logger.Logf(t, "Error loading Kubernetes config: %s", err)
+		tunnel.logger.Logf(t, "Error starting port forwarding tunnel: %s", err)
 		return err
 	}
+	tunnel.logger.Logf(t, "Successfully created port forwarding tunnel")
+	tunnel.setState(TunnelStateConnected, conn.podName, nil)
+
+	go tunnel.maintainConnection(t, clientset, config, conn)
+	return nil
+}
+
+// portSpecs returns the port mappings of the tunnel in the format expected by the portforward library.
+func (tunnel *Tunnel) portSpecs() []string {
+	specs := []string{}
+	for _, port := range tunnel.ports {
+		specs = append(specs, fmt.Sprintf("%d:%d", port.Local, port.Remote))
+	}
+	return specs
+}
 
+// connectE finds a pod to attach to and starts forwarding the ports of the tunnel to it, returning once the ports are
+// ready to accept connections.
+func (tunnel *Tunnel) connectE(t testing.TestingT, clientset *kubernetes.Clientset, config *rest.Config) (*tunnelConnection, error) {
 	// Find the pod to port forward to
 	podName, err := tunnel.getAttachablePodForResourceE(t)
 	if err != nil {
 		tunnel.logger.Logf(t, "Error finding available pod: %s", err)
-		return err
+		return nil, err
 	}
 	tunnel.logger.Logf(t, "Selected pod %s to open port forward to", podName)
 
@@ -225,52 +533,191 @@ func (tunnel *Tunnel) ForwardPortE(t testing.TestingT) error {
 	transport, upgrader, err := spdy.RoundTripperFor(config)
 	if err != nil {
 		tunnel.logger.Logf(t, "Error creating http client: %s", err)
-		return err
+		return nil, err
 	}
 	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", portForwardCreateURL)
 
-	// If the localport is 0, get an available port before continuing. We do this here instead of relying on the
-	// underlying portforwarder library, because the portforwarder library does not expose the selected local port in a
-	// machine readable manner.
-	// Synchronize on the global lock to avoid race conditions with concurrently selecting the same available port,
-	// since there is a brief moment between `GetAvailablePort` and `portforwader.ForwardPorts` where the selected port
-	// is available for selection again.
-	if tunnel.localPort == 0 {
-		tunnel.logger.Logf(t, "Requested local port is 0. Selecting an open port on host system")
-		tunnel.localPort, err = GetAvailablePortE(t)
-		if err != nil {
-			tunnel.logger.Logf(t, "Error getting available port: %s", err)
-			return err
-		}
-		tunnel.logger.Logf(t, "Selected port %d", tunnel.localPort)
-		globalMutex.Lock()
-		defer globalMutex.Unlock()
-	}
-
 	// Construct a new PortForwarder struct that manages the instructed port forward tunnel
-	ports := []string{fmt.Sprintf("%d:%d", tunnel.localPort, tunnel.remotePort)}
-	portforwarder, err := portforward.New(dialer, ports, tunnel.stopChan, tunnel.readyChan, tunnel.out, tunnel.out)
+	conn := &tunnelConnection{
+		podName:  podName,
+		stopChan: make(chan struct{}),
+		errChan:  make(chan error, 1),
+	}
+	readyChan := make(chan struct{})
+	portforwarder, err := portforward.New(dialer, tunnel.portSpecs(), conn.stopChan, readyChan, tunnel.out, tunnel.out)
 	if err != nil {
 		tunnel.logger.Logf(t, "Error creating port forwarding tunnel: %s", err)
-		return err
+		return nil, err
 	}
 
 	// Open the tunnel in a goroutine so that it is available in the background. Report errors to the main goroutine via
 	// a new channel.
-	errChan := make(chan error)
 	go func() {
-		errChan <- portforwarder.ForwardPorts()
+		conn.errChan <- portforwarder.ForwardPorts()
 	}()
 
 	// Wait for an error or the tunnel to be ready
 	select {
-	case err = <-errChan:
-		tunnel.logger.Logf(t, "Error starting port forwarding tunnel: %s", err)
-		return err
-	case <-portforwarder.Ready:
-		tunnel.logger.Logf(t, "Successfully created port forwarding tunnel")
+	case err = <-conn.errChan:
+		if err == nil {
+			err = TunnelClosedBeforeReady{PodName: podName}
+		}
+		return nil, err
+	case <-tunnel.stopChan:
+		conn.stop()
+		return nil, TunnelClosedBeforeReady{PodName: podName}
+	case <-readyChan:
+		return conn, nil
+	}
+}
+
+// maintainConnection runs in the background for the lifetime of the tunnel. It waits for the current connection to
+// break, and then reconnects to a new pod of the resource, until the tunnel is closed.
+func (tunnel *Tunnel) maintainConnection(t testing.TestingT, clientset *kubernetes.Clientset, config *rest.Config, conn *tunnelConnection) {
+	for {
+		disconnectErr := tunnel.waitForDisconnect(t, clientset, conn)
+		if tunnel.isClosed() {
+			tunnel.setState(TunnelStateClosed, conn.podName, nil)
+			return
+		}
+		tunnel.logger.Logf(t, "Port forwarding tunnel to pod %s lost (%s). Reconnecting.", conn.podName, disconnectErr)
+		tunnel.setState(TunnelStateReconnecting, conn.podName, disconnectErr)
+
+		for {
+			select {
+			case <-tunnel.stopChan:
+				tunnel.setState(TunnelStateClosed, conn.podName, nil)
+				return
+			case <-time.After(tunnelReconnectInterval):
+			}
+
+			newConn, err := tunnel.connectE(t, clientset, config)
+			if err == nil {
+				conn = newConn
+				break
+			}
+			tunnel.setState(TunnelStateReconnecting, conn.podName, err)
+		}
+		tunnel.logger.Logf(t, "Reconnected port forwarding tunnel to pod %s", conn.podName)
+		tunnel.setState(TunnelStateConnected, conn.podName, nil)
+	}
+}
+
+// waitForDisconnect blocks until the given connection is no longer usable, either because the port forwarding session
+// failed, the pod it is attached to is no longer available, or the tunnel was closed. The connection is always stopped
+// by the time this returns, and the returned error describes why it was disconnected.
+func (tunnel *Tunnel) waitForDisconnect(t testing.TestingT, clientset *kubernetes.Clientset, conn *tunnelConnection) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer conn.stop()
+
+	podGoneChan := make(chan error, 1)
+	go tunnel.watchPod(ctx, t, clientset, conn.podName, podGoneChan)
+
+	select {
+	case <-tunnel.stopChan:
 		return nil
+	case err := <-conn.errChan:
+		if err == nil {
+			err = PortForwardingLost{PodName: conn.podName}
+		}
+		return err
+	case err := <-podGoneChan:
+		return err
+	}
+}
+
+// watchPod watches the given pod and reports on the given channel once the pod is deleted or is no longer available.
+// Since the API server closes watches periodically, the watch is reopened until the context is cancelled.
+func (tunnel *Tunnel) watchPod(ctx context.Context, t testing.TestingT, clientset *kubernetes.Clientset, podName string, podGoneChan chan<- error) {
+	pods := clientset.CoreV1().Pods(tunnel.kubectlOptions.Namespace)
+	restartCounts := map[string]int32{}
+	for ctx.Err() == nil {
+		goneErr, err := watchPodUntilClosedE(ctx, pods, podName, restartCounts)
+		if goneErr != nil {
+			podGoneChan <- goneErr
+			return
+		}
+		if err != nil {
+			tunnel.logger.Logf(t, "Error watching pod %s: %s", podName, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(tunnelReconnectInterval):
+			}
+		}
+	}
+}
+
+// watchPodUntilClosedE lists the given pod, then watches it from the resource version of the list until the watch is
+// closed, so that a pod deleted while no watch was open is still seen. This returns a PortForwardingLost error as
+// goneErr once the pod can no longer serve a tunnel, and an error as err if the pod can not be listed or watched.
+func watchPodUntilClosedE(ctx context.Context, pods corev1client.PodInterface, podName string, restartCounts map[string]int32) (goneErr error, err error) {
+	listOptions := metav1.ListOptions{FieldSelector: "metadata.name=" + podName}
+	list, err := pods.List(ctx, listOptions)
+	if err != nil {
+		return nil, err
+	}
+	if goneErr := podGoneFromList(podName, list.Items, restartCounts); goneErr != nil {
+		return goneErr, nil
+	}
+
+	listOptions.ResourceVersion = list.ResourceVersion
+	watcher, err := pods.Watch(ctx, listOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer watcher.Stop()
+	for event := range watcher.ResultChan() {
+		if goneErr := podGoneFromWatchEvent(podName, event, restartCounts); goneErr != nil {
+			return goneErr, nil
+		}
+	}
+	return nil, nil
+}
+
+// podGoneFromList returns an error if the pod is not in the listed pods, or if it can no longer serve a tunnel (see
+// podGoneFromWatchEvent).
+func podGoneFromList(podName string, pods []corev1.Pod, restartCounts map[string]int32) error {
+	for i := range pods {
+		if pods[i].Name == podName {
+			return podGoneFromWatchEvent(podName, watch.Event{Type: watch.Modified, Object: &pods[i]}, restartCounts)
+		}
+	}
+	return PortForwardingLost{PodName: podName, Reason: "pod was deleted"}
+}
+
+// podGoneFromWatchEvent returns an error if the watch event indicates that the pod can no longer serve a tunnel, and
+// nil otherwise. A pod can no longer serve a tunnel if it is deleted, terminating, no longer running, or if one of its
+// containers restarted since the tunnel was opened, which breaks the port forwarding session. Readiness is deliberately
+// ignored, since port forwarding works with pods that are not ready and reconnecting on readiness would flap. The
+// restart count of each container is recorded in restartCounts the first time it is seen.
+func podGoneFromWatchEvent(podName string, event watch.Event, restartCounts map[string]int32) error {
+	switch event.Type {
+	case watch.Deleted:
+		return PortForwardingLost{PodName: podName, Reason: "pod was deleted"}
+	case watch.Added, watch.Modified:
+		pod, isPod := event.Object.(*corev1.Pod)
+		if !isPod {
+			return nil
+		}
+		if pod.DeletionTimestamp != nil {
+			return PortForwardingLost{PodName: podName, Reason: "pod is terminating"}
+		}
+		if pod.Status.Phase != corev1.PodRunning {
+			return PortForwardingLost{PodName: podName, Reason: fmt.Sprintf("pod is in phase %s", pod.Status.Phase)}
+		}
+		for _, containerStatus := range pod.Status.ContainerStatuses {
+			initialCount, seen := restartCounts[containerStatus.Name]
+			if !seen {
+				restartCounts[containerStatus.Name] = containerStatus.RestartCount
+				continue
+			}
+			if containerStatus.RestartCount > initialCount {
+				return PortForwardingLost{PodName: podName, Reason: fmt.Sprintf("container %s restarted", containerStatus.Name)}
+			}
+		}
 	}
+	return nil
 }
 
 // GetAvailablePort retrieves an available port on the host machine. This delegates the port selection to the golang net
@@ -16,6 +16,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/require"
+
 	http_helper "github.com/gruntwork-io/terratest/modules/http-helper"
 	"github.com/gruntwork-io/terratest/modules/random"
 )
@@ -108,6 +110,87 @@ func TestTunnelOpensAPortForwardTunnelToService(t *testing.T) {
 	)
 }
 
+func TestTunnelOpensAPortForwardTunnelToStatefulSet(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(ExampleStatefulSetYAMLTemplate, uniqueID, uniqueID)
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+	WaitUntilPodAvailable(t, options, "nginx-statefulset-0", 60, 1*time.Second)
+
+	// Open a tunnel forwarding the same remote port twice, from any available ports locally
+	tunnel := NewMultiPortTunnel(options, ResourceTypeStatefulSet, "nginx-statefulset", []TunnelPort{{Local: 0, Remote: 80}, {Local: 0, Remote: 80}})
+	defer tunnel.Close()
+	tunnel.ForwardPort(t)
+	require.Equal(t, TunnelStateConnected, tunnel.State())
+	require.Equal(t, "nginx-statefulset-0", tunnel.PodName())
+
+	// Setup a TLS configuration to submit with the helper, a blank struct is acceptable
+	tlsConfig := tls.Config{}
+
+	for _, port := range tunnel.Ports() {
+		http_helper.HttpGetWithRetryWithCustomValidation(
+			t,
+			fmt.Sprintf("http://localhost:%d", port.Local),
+			&tlsConfig,
+			60,
+			5*time.Second,
+			verifyNginxWelcomePage,
+		)
+	}
+}
+
+func TestTunnelReconnectsWhenPodIsReplaced(t *testing.T) {
+	t.Parallel()
+
+	uniqueID := strings.ToLower(random.UniqueId())
+	options := NewKubectlOptions("", "", uniqueID)
+	configData := fmt.Sprintf(ExampleStatefulSetYAMLTemplate, uniqueID, uniqueID)
+	defer KubectlDeleteFromString(t, options, configData)
+	KubectlApplyFromString(t, options, configData)
+	WaitUntilPodAvailable(t, options, "nginx-statefulset-0", 60, 1*time.Second)
+
+	// Open a tunnel to the pods of the statefulset using a label selector
+	tunnel := NewTunnel(options, ResourceTypeLabelSelector, "app=nginx-statefulset", 0, 80)
+	defer tunnel.Close()
+	tunnel.ForwardPort(t)
+
+	// Delete the pod backing the tunnel, and wait for the tunnel to come back once the statefulset recreates it
+	RunKubectl(t, options, "delete", "pod", "nginx-statefulset-0")
+	waitForTunnelState(t, tunnel, TunnelStateReconnecting, 2*time.Minute)
+	waitForTunnelState(t, tunnel, TunnelStateConnected, 2*time.Minute)
+
+	// Setup a TLS configuration to submit with the helper, a blank struct is acceptable
+	tlsConfig := tls.Config{}
+
+	http_helper.HttpGetWithRetryWithCustomValidation(
+		t,
+		fmt.Sprintf("http://%s", tunnel.Endpoint()),
+		&tlsConfig,
+		60,
+		5*time.Second,
+		verifyNginxWelcomePage,
+	)
+}
+
+// waitForTunnelState reads the events of the tunnel until it reaches the expected state, failing the test on timeout.
+func waitForTunnelState(t *testing.T, tunnel *Tunnel, expectedState TunnelState, timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case event := <-tunnel.Events():
+			if event.State == expectedState {
+				return
+			}
+		case <-timer.C:
+			t.Fatalf("Timed out waiting for tunnel to be %s, current state is %s", expectedState, tunnel.State())
+		}
+	}
+}
+
 func verifyNginxWelcomePage(statusCode int, body string) bool {
 	if statusCode != 200 {
 		return false
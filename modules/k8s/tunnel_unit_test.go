@@ -0,0 +1,79 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPodGoneFromWatchEvent(t *testing.T) {
+	t.Parallel()
+
+	runningPod := func(restartCount int32) *corev1.Pod {
+		return &corev1.Pod{
+			Status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "nginx", RestartCount: restartCount}},
+			},
+		}
+	}
+	terminatingPod := runningPod(0)
+	terminatingPod.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	failedPod := runningPod(0)
+	failedPod.Status.Phase = corev1.PodFailed
+
+	cases := []struct {
+		title        string
+		events       []watch.Event
+		expectedGone bool
+	}{
+		{"RunningPod", []watch.Event{{Type: watch.Added, Object: runningPod(2)}}, false},
+		{"DeletedPod", []watch.Event{{Type: watch.Deleted, Object: runningPod(0)}}, true},
+		{"TerminatingPod", []watch.Event{{Type: watch.Modified, Object: terminatingPod}}, true},
+		{"FailedPod", []watch.Event{{Type: watch.Modified, Object: failedPod}}, true},
+		{"RestartedContainer", []watch.Event{{Type: watch.Added, Object: runningPod(2)}, {Type: watch.Modified, Object: runningPod(3)}}, true},
+		{"UnchangedContainer", []watch.Event{{Type: watch.Added, Object: runningPod(2)}, {Type: watch.Modified, Object: runningPod(2)}}, false},
+	}
+	for _, tc := range cases {
+		// capture range variable so that it doesn't update when the subtest goroutine swaps.
+		tc := tc
+		t.Run(tc.title, func(t *testing.T) {
+			t.Parallel()
+
+			restartCounts := map[string]int32{}
+			var err error
+			for _, event := range tc.events {
+				err = podGoneFromWatchEvent("nginx-pod", event, restartCounts)
+			}
+			if tc.expectedGone {
+				require.IsType(t, PortForwardingLost{}, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWatchPodUntilClosedSeesPodsDeletedBetweenWatches(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	pods := fake.NewSimpleClientset(pod).CoreV1().Pods("default")
+
+	// The pod is deleted while no watch is open: a watch alone would never report it.
+	require.NoError(t, pods.Delete(ctx, "nginx", metav1.DeleteOptions{}))
+	goneErr, err := watchPodUntilClosedE(ctx, pods, "nginx", map[string]int32{})
+	require.NoError(t, err)
+	require.Error(t, goneErr)
+	require.IsType(t, PortForwardingLost{}, goneErr)
+}
@@ -0,0 +1,7 @@
+# An example rego policy that requires every Kubernetes object to have the standard app.kubernetes.io/name label. The
+# input is a single object of the manifests, in its JSON representation.
+package require_labels
+
+allow = true {
+    input.metadata.labels["app.kubernetes.io/name"]
+}
@@ -0,0 +1,59 @@
+//go:build kubeall || helm
+// +build kubeall helm
+
+// **NOTE**: we have build tags to differentiate kubernetes tests from non-kubernetes tests, and further differentiate helm
+// tests. This is done because minikube is heavy and can interfere with docker related tests in terratest. Similarly, helm
+// can overload the minikube system and thus interfere with the other kubernetes tests. Specifically, many of the tests
+// start to fail with `connection refused` errors from `minikube`. To avoid overloading the system, we run the kubernetes
+// tests and helm tests separately from the others. This may not be necessary if you have a sufficiently powerful machine.
+// We recommend at least 4 cores and 16GB of RAM if you want to run all the tests together.
+
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/gruntwork-io/terratest/modules/opa"
+)
+
+// An example of how to check the objects rendered by a helm chart against security and best-practice rules, along with
+// an OPA policy.
+func TestHelmBasicExamplePolicies(t *testing.T) {
+	t.Parallel()
+
+	options := &helm.Options{
+		SetValues: map[string]string{
+			"containerImageRepo": "nginx",
+			"containerImageTag":  "1.15.8",
+		},
+	}
+	output := helm.RenderTemplate(t, options, "../examples/helm-basic-example", "policy", []string{})
+
+	// The example does not set resources, a security context nor a readiness probe, which the built-in rules report.
+	report := k8s.CheckManifestPolicies(t, nil, output)
+	assert.NotEmpty(t, report.ViolationsOf(k8s.PolicyRuleResources))
+	assert.NotEmpty(t, report.ViolationsOf(k8s.PolicyRuleRunAsNonRoot))
+	assert.NotEmpty(t, report.ViolationsOf(k8s.PolicyRuleReadinessProbe))
+	assert.Empty(t, report.ViolationsOf(k8s.PolicyRuleNoLatestTag))
+	assert.Empty(t, report.ViolationsOf(k8s.PolicyRulePodDisruptionBudget))
+
+	// Exempt the chart from the rules it does not follow, and also check the OPA policy, which requires the standard
+	// labels on every object.
+	policyOptions := &k8s.PolicyOptions{
+		Exemptions: []k8s.PolicyExemption{
+			{Rule: k8s.PolicyRuleResources},
+			{Rule: k8s.PolicyRuleRunAsNonRoot},
+			{Rule: k8s.PolicyRuleReadinessProbe},
+		},
+		OPAEvalOptions: &opa.EvalOptions{
+			RulePath: "./fixtures/kubernetes-manifest-policy/require_labels.rego",
+			FailMode: opa.FailUndefined,
+		},
+		OPAQuery: "data.require_labels.allow",
+	}
+	k8s.AssertManifestPolicies(t, policyOptions, output)
+}
@@ -74,4 +74,17 @@ func TestKubernetesRBACExample(t *testing.T) {
 		Resource:  "pod",
 	}
 	require.True(t, k8s.CanIDo(t, serviceAccountKubectlOptions, namespaceListPodAction))
+
+	// We can also check the full permission matrix of the ServiceAccount without using its token, by having the admin
+	// user ask the API server what the ServiceAccount is allowed to do. This reports every permission that is
+	// unexpectedly granted or denied.
+	verbs := []string{"get", "list", "watch", "create", "update", "patch", "delete"}
+	resources := []string{"pods", "pods/log", "secrets", "deployments.apps", "roles.rbac.authorization.k8s.io"}
+	subject := k8s.NewServiceAccountAccessReviewSubject(namespaceName, serviceAccountName)
+	expectations := append(
+		k8s.NewPermissionExpectations(verbs, resources, []string{namespaceName}, true),
+		k8s.NewPermissionExpectations(verbs, resources, []string{"default", "kube-system"}, false)...,
+	)
+	expectations = append(expectations, k8s.NewPermissionExpectations(verbs, []string{"nodes", "namespaces"}, nil, false)...)
+	k8s.AssertPermissionMatrix(t, options, subject, expectations)
 }